@@ -2,40 +2,217 @@ package main
 
 import (
 	"NovelScraper/internal/app"
+	"NovelScraper/internal/models"
+	"NovelScraper/pkg/config"
+	"bufio"
+	"context"
+	"errors"
 	"flag"
+	"fmt"
 	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 )
 
 func main() {
 	log.SetFlags(log.LstdFlags | log.Lshortfile)
 
-	task := flag.String("task", "server", "Task to run: scrape-products, scrape-details, or server")
+	task := flag.String("task", "server", "Task to run: migrate, scrape-products, scrape-details, refresh, translate, publish, requeue, failures, export, automatic or daemon")
+	site := flag.String("site", "amazon.ae", "Site to collect products from: amazon.ae or noon.com; with -task=requeue or export, only these products")
+	status := flag.Bool("status", false, "With -task=migrate: only show which migrations are applied")
+	dryRun := flag.Bool("dry-run", false, "With -task=migrate: show the pending migrations without applying them")
+	requeueTo := flag.String("to", "", "With -task=requeue: status to move products back to: needs_details, needs_translation or completed")
+	requeueFrom := flag.String("from", "", "With -task=requeue: only move products in these comma-separated statuses")
+	category := flag.String("category", "", "With -task=requeue or export: only products in this category")
+	olderThan := flag.Duration("older-than", 0, "With -task=requeue: only move products scraped longer ago than this, e.g. 72h")
+	ids := flag.String("ids", "", "With -task=requeue: only move these comma-separated product IDs")
+	reason := flag.String("reason", "", "With -task=requeue: reason recorded in the product history")
+	out := flag.String("out", "", "With -task=export: file to write, standard output if empty")
+	format := flag.String("format", "", "With -task=export: csv, jsonl or xlsx; by default taken from the -out extension, else csv")
+	columns := flag.String("columns", "", "With -task=export: comma-separated columns, or 'all' (default: "+strings.Join(app.DefaultExportColumns, ",")+")")
+	statuses := flag.String("statuses", "", "With -task=export: only products in these comma-separated statuses")
+	minPrice := flag.Float64("min-price", 0, "With -task=export: only products whose discounted price is at least this")
+	maxPrice := flag.Float64("max-price", 0, "With -task=export: only products whose discounted price is at most this")
+	minOriginalPrice := flag.Float64("min-original-price", 0, "With -task=export: only products whose original price is at least this")
+	maxOriginalPrice := flag.Float64("max-original-price", 0, "With -task=export: only products whose original price is at most this")
+	minDiscount := flag.Int("min-discount", 0, "With -task=export: only products discounted by at least this percent")
+	maxDiscount := flag.Int("max-discount", 0, "With -task=export: only products discounted by at most this percent")
+	scrapedAfter := flag.String("scraped-after", "", "With -task=export: only products scraped on or after this date, e.g. 2025-01-31")
+	scrapedBefore := flag.String("scraped-before", "", "With -task=export: only products scraped before this date")
+	limit := flag.Int("limit", 0, "With -task=export: export at most this many products")
 	flag.Parse()
 
+	// -site defaults to amazon.ae for scraping, so it only filters when given.
+	siteFilter := ""
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "site" {
+			siteFilter = *site
+		}
+	})
+
+	// Migrations run before the app is created, because it refuses to start
+	// against a database that is not up to date.
+	if *task == "migrate" {
+		opts := app.MigrateOptions{StatusOnly: *status, DryRun: *dryRun}
+		cfg := config.LoadConfig("config.yml")
+		if err := app.RunMigrations(cfg.Database, opts, os.Stdout); err != nil {
+			log.Fatalf("Migration failed: %v", err)
+		}
+		return
+	}
+
 	application := app.New()
 	defer application.Repo.Close()
 
 	log.Printf("Running task: %s", *task)
 
+	var err error
 	switch *task {
 	case "scrape-products":
 		// This is Phase 1: Collects product links from the deals page.
-		application.RunProductScraper()
+		err = application.RunProductScraper(*site)
 
 	case "scrape-details":
 		// This is Phase 2: Scrapes details for products collected in Phase 1.
-		application.RunDetailScraper()
+		err = application.RunDetailScraper()
+
+	case "refresh":
+		// Re-scrapes prices and availability of products whose refresh interval has passed.
+		err = application.RunRefresher()
 
 	case "translate":
-		application.RunTranslator()
+		err = application.RunTranslator()
 
 	case "publish":
-		application.PublishCompletedProducts()
+		err = application.PublishCompletedProducts()
+
+	case "requeue":
+		filter := models.RequeueFilter{SourceSite: siteFilter, Category: *category}
+		for _, s := range splitList(*requeueFrom) {
+			filter.Statuses = append(filter.Statuses, models.ProductStatus(s))
+		}
+		if *olderThan > 0 {
+			filter.ScrapedBefore = time.Now().Add(-*olderThan)
+		}
+		for _, s := range splitList(*ids) {
+			id, err := strconv.ParseInt(s, 10, 64)
+			if err != nil {
+				log.Fatalf("Invalid product ID %q in -ids", s)
+			}
+			filter.IDs = append(filter.IDs, id)
+		}
+		err = application.RunRequeue(filter, models.ProductStatus(*requeueTo), *reason)
+
+	case "failures":
+		if err = application.RunFailureReport(os.Stdout); err != nil {
+			err = fmt.Errorf("failed to build failure report: %w", err)
+		}
+
+	case "export":
+		after, afterErr := parseDate("scraped-after", *scrapedAfter)
+		before, beforeErr := parseDate("scraped-before", *scrapedBefore)
+		if err = errors.Join(afterErr, beforeErr); err != nil {
+			break
+		}
+		filters := models.ProductFilters{
+			SourceSite:         siteFilter,
+			Category:           *category,
+			MinOriginalPrice:   *minOriginalPrice,
+			MaxOriginalPrice:   *maxOriginalPrice,
+			MinDiscountPrice:   *minPrice,
+			MaxDiscountPrice:   *maxPrice,
+			MinDiscountPercent: *minDiscount,
+			MaxDiscountPercent: *maxDiscount,
+			ScrapedAfter:       after,
+			ScrapedBefore:      before,
+			Limit:              *limit,
+		}
+		for _, s := range splitList(*statuses) {
+			filters.Statuses = append(filters.Statuses, models.ProductStatus(s))
+		}
+		opts := app.ExportOptions{Format: *format, Columns: splitList(*columns)}
+		if opts.Format == "" {
+			opts.Format = app.ExportFormat(*out)
+		}
+		if *columns == "all" {
+			opts.Columns = app.ExportColumns()
+		}
+		err = runExport(application, filters, opts, *out)
 
 	case "automatic": // <-- ADD THIS NEW CASE
-		application.RunAutomaticWorkflow()
+		// Streams products through every stage; Ctrl-C stops listing and
+		// lets the products already in the pipeline finish.
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		err = application.RunAutomaticWorkflow(ctx, *site)
+
+	case "daemon":
+		// Runs every stage on its own schedule from config.yml until stopped.
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		err = application.RunDaemon(ctx)
 
 	default:
 		log.Fatalf("Unknown task: %s.", *task)
 	}
+	if err != nil {
+		log.Fatalf("Task %s failed: %v", *task, err)
+	}
+}
+
+// runExport writes the export to path, or to standard output. A failed
+// export doesn't leave a partial file behind.
+func runExport(application *app.App, filters models.ProductFilters, opts app.ExportOptions, path string) error {
+	if path == "" {
+		w := bufio.NewWriter(os.Stdout)
+		if err := application.RunExport(filters, opts, w); err != nil {
+			return err
+		}
+		return w.Flush()
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	err = application.RunExport(filters, opts, w)
+	if err == nil {
+		err = w.Flush()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+	}
+	return err
+}
+
+// parseDate parses a date flag, either a day or an RFC 3339 time, in the
+// local time zone.
+func parseDate(name, value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	for _, layout := range []string{"2006-01-02", time.RFC3339} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q in -%s, want e.g. 2025-01-31", value, name)
+}
+
+// splitList splits a comma-separated flag value, dropping empty items.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
 }
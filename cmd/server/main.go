@@ -2,6 +2,7 @@ package main
 
 import (
 	"NovelScraper/internal/server"
+	"NovelScraper/internal/sqldb"
 	"NovelScraper/internal/wpdatabase"
 	"NovelScraper/pkg/config"
 	"log"
@@ -14,7 +15,14 @@ func main() {
 	cfg := config.LoadConfig("config.yml")
 
 	// The server connects to its own dedicated database
-	wpRepo := wpdatabase.InitDB("wordpress.db")
+	_, wordpressDB, err := sqldb.FromConfig(cfg.Database)
+	if err != nil {
+		log.Fatalf("Invalid database config: %v", err)
+	}
+	wpRepo, err := wpdatabase.InitDB(wordpressDB)
+	if err != nil {
+		log.Fatal(err)
+	}
 	defer wpRepo.Close()
 
 	// Start the server with the config and the wordpress database
@@ -3,12 +3,16 @@ package app
 import (
 	"NovelScraper/internal/database"
 	"NovelScraper/internal/models"
+	"NovelScraper/internal/scraper"
 	"NovelScraper/internal/scraper/amazon"
+	"NovelScraper/internal/scraper/noon"
+	"NovelScraper/internal/sqldb"
 	"NovelScraper/internal/translator"
 	"NovelScraper/internal/wpdatabase"
 	"NovelScraper/pkg/config"
 	"NovelScraper/utils"
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"strings"
@@ -22,119 +26,370 @@ import (
 // App is the main application structure holding all dependencies.
 type App struct {
 	Config *config.Config
-	Repo   *database.DBRepository
+	Repo   database.Repository
+	Owner  string // identifies this process in job leases
+
+	// Tests replace how browsers, scrapers and translators are started.
+	launchBrowser func() (*rod.Browser, error)
+	scraperFor    func(browser *rod.Browser, site string) (scraper.Scraper, error)
+	translators   []translator.Translator
 }
 
 // New creates a new application instance with all initial settings.
 func New() *App {
 	cfg := config.LoadConfig("config.yml")
-	repo := database.InitDB("products.db")
+	productsDB, _, err := sqldb.FromConfig(cfg.Database)
+	if err != nil {
+		log.Fatalf("Invalid database config: %v", err)
+	}
+	repo := database.InitDB(productsDB)
+	owner := leaseOwner()
+	repo.RunID = time.Now().UTC().Format("20060102T150405Z") + "@" + owner
 	return &App{
 		Config: cfg,
 		Repo:   repo,
+		Owner:  owner,
 	}
 }
 
-// RunProductScraper now only orchestrates the product list scraping.
-// All the Amazon-specific logic has been moved to the amazon package.
-func (a *App) RunProductScraper() {
-	log.Println("--- Starting Product List Scraping Task ---")
+// newScraper returns the scraper implementation for a source site.
+// Products saved before Noon support have no site and are treated as Amazon.
+func (a *App) newScraper(browser *rod.Browser, site string) (scraper.Scraper, error) {
+	if a.scraperFor != nil {
+		return a.scraperFor(browser, site)
+	}
+	switch site {
+	case amazon.SourceSite, "":
+		return amazon.New(browser, a.Config.Scraper, a.Config.Amazon), nil
+	case noon.SourceSite:
+		return noon.New(browser, a.Config.Scraper, a.Config.Noon), nil
+	default:
+		return nil, fmt.Errorf("no scraper available for site %q", site)
+	}
+}
 
+// RunProductScraper only orchestrates the product list scraping for one site.
+// All the site-specific logic lives in the scraper packages. Products are
+// saved as soon as they are found, so an interrupted crawl loses nothing and
+// resumes on the next run.
+func (a *App) RunProductScraper(site string) error {
+	log.Printf("--- Starting Product List Scraping Task (%s) ---", site)
+
+	crawl := a.newProductCrawl(nil)
+	if err := a.collectProducts(site, crawl); err != nil {
+		return err
+	}
+	crawl.report()
+	log.Printf("Task finished. Successfully saved %d products.", crawl.saved)
+	return nil
+}
+
+// collectProducts scrapes the product list of a site into crawl.
+func (a *App) collectProducts(site string, crawl *productCrawl) error {
 	// A browser is needed for the scraper to work with.
-	u := launcher.New().Headless(a.Config.Scraper.Headless).MustLaunch()
-	browser := rod.New().ControlURL(u).MustConnect()
-	defer browser.MustClose()
+	browser, err := a.startBrowser()
+	if err != nil {
+		return err
+	}
+	defer closeBrowser(browser)
+
+	// 1. Create a new scraper instance for the requested site.
+	siteScraper, err := a.newScraper(browser, site)
+	if err != nil {
+		return fmt.Errorf("failed to create scraper: %w", err)
+	}
 
-	// 1. Create a new scraper instance for Amazon.
-	amazonScraper := amazon.New(browser, a.Config.Scraper, a.Config.Amazon)
+	// 2. Call the generic method to walk the product list.
+	if err := siteScraper.ScrapeProductList(crawl); err != nil {
+		return fmt.Errorf("failed to scrape product list: %w", err)
+	}
+	return nil
+}
 
-	// 2. Call the generic method to get the product list.
-	productsToScrape, err := amazonScraper.ScrapeProductList()
+// startBrowser launches the browser the scrapers run in.
+func (a *App) startBrowser() (*rod.Browser, error) {
+	if a.launchBrowser != nil {
+		return a.launchBrowser()
+	}
+	l := launcher.New().Headless(a.Config.Scraper.Headless)
+	u, err := l.Launch()
 	if err != nil {
-		log.Fatalf("Failed to scrape product list: %v", err)
+		return nil, fmt.Errorf("failed to launch browser: %w", err)
+	}
+	browser := rod.New().ControlURL(u)
+	if err := browser.Connect(); err != nil {
+		l.Kill()
+		return nil, fmt.Errorf("failed to connect to browser: %w", err)
 	}
+	return browser, nil
+}
 
-	// 3. Save the collected products to the database.
-	log.Printf("Collected %d product links. Saving to database...", len(productsToScrape))
-	var savedCount int
-	for _, p := range productsToScrape {
-		if err := a.Repo.SaveProduct(p); err == nil {
-			savedCount++
-		}
+// closeBrowser closes a browser from startBrowser, if there is one.
+func closeBrowser(browser *rod.Browser) {
+	if browser == nil {
+		return
+	}
+	if err := browser.Close(); err != nil {
+		log.Printf("WARN: Failed to close browser: %v", err)
 	}
-	log.Printf("Task finished. Successfully saved %d new products.", savedCount)
 }
 
 // RunDetailScraper scrapes details for products with status 'needs_details'.
-func (a *App) RunDetailScraper() {
+// Products are claimed in batches through the job queue, so several
+// processes can share the backlog.
+func (a *App) RunDetailScraper() error {
 	log.Println("--- Starting Product Detail Scraping Task ---")
+	if err := a.enqueue(models.StageDetails, models.StatusNeedsDetails); err != nil {
+		return err
+	}
 
-	productsToScrape, err := a.Repo.GetProductsForDetailScrape()
-	if err != nil {
-		log.Fatalf("Failed to get products for detail scraping: %v", err)
+	var scrapedCount, failedCount int
+	for {
+		productsToScrape, jobs, err := a.claimBatch(models.StageDetails, a.Repo.GetProductsForDetailScrape)
+		if err != nil {
+			return fmt.Errorf("failed to get products for detail scraping: %w", err)
+		}
+		if jobs == nil {
+			break
+		}
+		if len(productsToScrape) == 0 {
+			continue
+		}
+		log.Printf("Claimed %d products to scrape for details.", len(productsToScrape))
+
+		// Each job's lease is renewed as its product comes up, as the batch
+		// can take longer than one lease.
+		results := a.scrapeAll(productsToScrape, func(s scraper.Scraper, p *models.Product) error {
+			if _, err := a.renewJob(jobs[p.ID]); err != nil {
+				return err
+			}
+			return s.ScrapeProductDetails(p)
+		})
+
+		// Update the DB with every product that was scraped successfully
+		for _, res := range results {
+			job := jobs[res.product.ID]
+			err := res.err
+			if errors.Is(err, database.ErrLeaseLost) {
+				log.Printf("Skipping %s: its job was taken over by another worker.", res.product.ProductURL)
+				continue
+			}
+			if err == nil {
+				if err = a.Repo.UpdateProductDetails(res.product); err != nil {
+					log.Printf("DB Update failed for %s: %v", res.product.ProductURL, err)
+				}
+			}
+			if err != nil {
+				failedCount++
+				a.failDetails(job, err)
+				continue
+			}
+			scrapedCount++
+			a.completeJob(job)
+		}
 	}
 
-	if len(productsToScrape) == 0 {
+	if scrapedCount+failedCount == 0 {
 		log.Println("No products are awaiting detail scraping. Task finished.")
+		return nil
+	}
+	log.Printf("Scraped %d products, %d failed. Run with -task=failures for a report.", scrapedCount, failedCount)
+	log.Println("--- Product Detail Scraping Task Finished ---")
+	return nil
+}
+
+// failDetails records why a detail scrape failed and retries the product
+// later, or moves it to 'dead_letter' when retrying cannot help or it has
+// used all its attempts.
+func (a *App) failDetails(job models.Job, cause error) {
+	reason := scraper.Classify(cause)
+	if err := a.Repo.RecordFailure(job.ProductID, string(reason), cause); err != nil {
+		log.Printf("WARN: Failed to record failure of product ID %d: %v", job.ProductID, err)
+	}
+
+	if !reason.Permanent() && job.Attempts < a.Config.Queue.WithDefaults().MaxAttempts {
+		a.failJob(job, cause)
 		return
 	}
-	log.Printf("Found %d products to scrape for details.", len(productsToScrape))
+	if reason == scraper.FailureNotFound {
+		if err := a.Repo.MarkProductRemoved(job.ProductID); err != nil {
+			log.Printf("WARN: Failed to mark product ID %d as removed: %v", job.ProductID, err)
+		}
+	}
+	log.Printf("Giving up on product ID %d after %d attempts (%s). Setting status to 'dead_letter'.", job.ProductID, job.Attempts, reason)
+	if err := a.Repo.UpdateProductStatus(job.ProductID, models.StatusDeadLetter, string(reason)+": "+cause.Error()); err != nil {
+		log.Printf("WARN: Failed to dead-letter product ID %d: %v", job.ProductID, err)
+	}
+	a.completeJob(job)
+}
 
+// scrapeResult is the outcome of scraping one product.
+type scrapeResult struct {
+	product models.Product
+	err     error
+}
+
+// scrapeAll runs scrape on every product with a pool of browser workers,
+// retrying failures, and returns one result per product.
+func (a *App) scrapeAll(products []models.Product, scrape func(scraper.Scraper, *models.Product) error) []scrapeResult {
 	numWorkers := utils.GetOptimalWorkerCount(a.Config.Scraper.Workers)
-	jobs := make(chan models.Product, len(productsToScrape))
-	results := make(chan models.Product, len(productsToScrape))
-	const maxRetries = 3
+	jobs := make(chan models.Product, len(products))
+	results := make(chan scrapeResult, len(products))
 
-	// Start workers
+	// Start workers. A worker whose browser fails to start still takes
+	// its share of products and fails them, so they are retried later.
 	for w := 1; w <= numWorkers; w++ {
 		go func(workerID int) {
-			workerLauncher := launcher.New().Headless(a.Config.Scraper.Headless).MustLaunch()
-			workerBrowser := rod.New().ControlURL(workerLauncher).MustConnect()
-			defer workerBrowser.MustClose()
-
-			amazonScraper := amazon.New(workerBrowser, a.Config.Scraper, a.Config.Amazon)
+			worker, err := a.newScrapeWorker(workerID)
+			if err != nil {
+				log.Printf("[Worker %d] %v", workerID, err)
+			} else {
+				defer worker.close()
+			}
 
 			for product := range jobs {
-				log.Printf("[Worker %d] Scraping details for: %s", workerID, product.ProductURL)
-				var err error
-				for attempt := 1; attempt <= maxRetries; attempt++ {
-					err = amazonScraper.ScrapeProductDetails(&product)
-					if err == nil {
-						break
-					}
-					log.Printf("[Worker %d] Attempt %d failed for %s: %v", workerID, attempt, product.ProductURL, err)
-					if attempt < maxRetries {
-						time.Sleep(time.Duration(1000) * time.Millisecond)
-					}
+				if worker != nil {
+					err = worker.scrape(&product, scrape)
 				}
-				results <- product // Send to results channel regardless of success to unblock the main loop.
+				results <- scrapeResult{product: product, err: err} // Send regardless of success to unblock the collector.
 			}
 		}(w)
 	}
 
 	// Send jobs
-	for _, p := range productsToScrape {
+	for _, p := range products {
 		jobs <- p
 	}
 	close(jobs)
 
-	// Collect results and update DB
-	for i := 0; i < len(productsToScrape); i++ {
-		detailedProduct := <-results
-		if detailedProduct.TitleEnglish != "" { // A simple check for successful scrape
-			if err := a.Repo.UpdateProductDetails(detailedProduct); err != nil {
-				log.Printf("DB Update failed for %s: %v", detailedProduct.ProductURL, err)
-			}
+	collected := make([]scrapeResult, 0, len(products))
+	for i := 0; i < len(products); i++ {
+		collected = append(collected, <-results)
+	}
+	return collected
+}
+
+// scrapeWorker scrapes products on a browser of its own. Products can come
+// from different sites, so it keeps one scraper per site.
+type scrapeWorker struct {
+	id       int
+	app      *App
+	browser  *rod.Browser
+	scrapers map[string]scraper.Scraper
+}
+
+func (a *App) newScrapeWorker(id int) (*scrapeWorker, error) {
+	browser, err := a.startBrowser()
+	if err != nil {
+		return nil, err
+	}
+	return &scrapeWorker{
+		id:       id,
+		app:      a,
+		browser:  browser,
+		scrapers: make(map[string]scraper.Scraper),
+	}, nil
+}
+
+func (w *scrapeWorker) close() {
+	closeBrowser(w.browser)
+}
+
+// scrape runs scrape on product with the scraper of its site, retrying
+// failures that are not permanent.
+func (w *scrapeWorker) scrape(product *models.Product, scrape func(scraper.Scraper, *models.Product) error) error {
+	const maxRetries = 3
+	log.Printf("[Worker %d] Scraping: %s", w.id, product.ProductURL)
+	siteScraper, ok := w.scrapers[product.SourceSite]
+	if !ok {
+		var err error
+		siteScraper, err = w.app.newScraper(w.browser, product.SourceSite)
+		if err != nil {
+			log.Printf("[Worker %d] Skipping %s: %v", w.id, product.ProductURL, err)
+			return err
 		}
+		w.scrapers[product.SourceSite] = siteScraper
 	}
-	log.Println("--- Product Detail Scraping Task Finished ---")
+
+	var err error
+	for attempt := 1; attempt <= maxRetries; attempt++ {
+		err = scrapeSafely(siteScraper, product, scrape)
+		if err == nil || errors.Is(err, database.ErrLeaseLost) {
+			break
+		}
+		log.Printf("[Worker %d] Attempt %d failed for %s: %v", w.id, attempt, product.ProductURL, err)
+		if scraper.Classify(err).Permanent() {
+			break
+		}
+		if attempt < maxRetries {
+			time.Sleep(time.Duration(1000) * time.Millisecond)
+		}
+	}
+	return err
+}
+
+// scrapeSafely runs scrape and turns a panic into an error. The scrapers
+// panic when the browser fails, and a panic in a worker goroutine would
+// otherwise bring down the whole process.
+func scrapeSafely(s scraper.Scraper, product *models.Product, scrape func(scraper.Scraper, *models.Product) error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("scraper panicked: %v", r)
+		}
+	}()
+	return scrape(s, product)
 }
 
 // RunTranslator fetches products needing translation and processes them using a fallback mechanism.
-func (a *App) RunTranslator() {
+func (a *App) RunTranslator() error {
 	log.Println("--- Starting Smart Translation Task ---")
 
 	// 1. Build an ordered list of translator clients
+	clients, err := a.translatorClients()
+	if err != nil {
+		return err
+	}
+
+	// 2. Claim products to translate, a batch at a time
+	if err := a.enqueue(models.StageTranslate, models.StatusNeedsTranslation); err != nil {
+		return err
+	}
+	var translatedCount int
+	for {
+		products, jobs, err := a.claimBatch(models.StageTranslate, a.Repo.GetProductsForTranslation)
+		if err != nil {
+			return fmt.Errorf("failed to get products for translation: %w", err)
+		}
+		if jobs == nil {
+			break
+		}
+		log.Printf("Claimed %d products to translate.", len(products))
+		for i, p := range products {
+			job, err := a.renewJob(jobs[p.ID])
+			if err != nil {
+				log.Printf("Skipping product ID %d: %v", p.ID, err)
+				continue
+			}
+			log.Printf("Processing product [%d/%d]: %s", i+1, len(products), p.TitleEnglish)
+			if a.translateProduct(clients, p, job) {
+				translatedCount++
+			}
+		}
+	}
+
+	if translatedCount == 0 {
+		log.Println("No products were translated. Task finished.")
+	}
+	log.Println("--- Smart Translation Task Finished ---")
+	return nil
+}
+
+// translatorClients returns the translation providers in the order they
+// are tried: the primary provider first, then the fallbacks.
+func (a *App) translatorClients() ([]translator.Translator, error) {
+	if a.translators != nil {
+		return a.translators, nil
+	}
 	var clients []translator.Translator
 	providerMap := make(map[string]config.ProviderConfig) // Helper map
 	for _, p := range a.Config.Translator.Providers {
@@ -146,7 +401,7 @@ func (a *App) RunTranslator() {
 		log.Printf("Primary provider set to: '%s'", primaryConf.Name)
 		clients = append(clients, translator.NewOpenAICompatibleClient(primaryConf.ApiURL, primaryConf.ApiKey, primaryConf.Model))
 	} else {
-		log.Fatalf("Primary provider '%s' not found in config!", a.Config.Translator.PrimaryProvider)
+		return nil, fmt.Errorf("primary provider '%s' not found in config", a.Config.Translator.PrimaryProvider)
 	}
 
 	// Add fallback providers in order
@@ -158,49 +413,67 @@ func (a *App) RunTranslator() {
 			log.Printf("Warning: Fallback provider '%s' not found in config, skipping.", name)
 		}
 	}
+	return clients, nil
+}
 
-	// 2. Get products to translate
-	products, err := a.Repo.GetProductsForTranslation()
+// translateProduct translates one product and reports whether it succeeded.
+// Failed products are retried later through the job queue.
+func (a *App) translateProduct(clients []translator.Translator, p models.Product, job models.Job) bool {
+	// -- Translate Title with Fallback --
+	titlePrompt := fmt.Sprintf("Translate the following product title to simple and fluent Persian. Do not translate technical terms, brand names, or units like '4K', 'HD', '256GB', '5G'. Only translate the descriptive parts.\n\nTitle: \"%s\"", p.TitleEnglish)
+	translatedTitle, err := tryTranslate(clients, titlePrompt, true)
 	if err != nil {
-		log.Fatalf("Failed to get products for translation: %v", err)
-	}
-	if len(products) == 0 {
-		log.Println("No products are awaiting translation. Task finished.")
-		return
+		log.Printf("ERROR: All providers failed for title of product ID %d: %v", p.ID, err)
+		a.failTranslation(job, "", err)
+		return false
 	}
-	log.Printf("Found %d products to translate.", len(products))
 
-	// 3. Process each product
-	for i, p := range products {
-		log.Printf("Processing product [%d/%d]: %s", i+1, len(products), p.TitleEnglish)
-
-		// -- Translate Title with Fallback --
-		titlePrompt := fmt.Sprintf("Translate the following product title to simple and fluent Persian. Do not translate technical terms, brand names, or units like '4K', 'HD', '256GB', '5G'. Only translate the descriptive parts.\n\nTitle: \"%s\"", p.TitleEnglish)
-		translatedTitle, err := tryTranslate(clients, titlePrompt, true)
-		if err != nil {
-			log.Printf("ERROR: All providers failed for title of product ID %d: %v. Setting status to 'translation_failed'.", p.ID, err)
-			a.Repo.UpdateProductTranslation(p.ID, "", "", "translation_failed")
-			continue // Move to the next product
-		}
+	// -- Translate Description with Fallback --
+	descPrompt := fmt.Sprintf("Translate the following product description and specifications into fluent Persian. Keep the original HTML structure (like tables, lists, etc.) intact. Do not translate technical terms, brand names, or model numbers. Combine the description and specifications into a single, cohesive HTML block.\n\nDescription:\n%s\n\nSpecifications (HTML Table/List):\n%s", p.DescriptionEnglish, p.Specifications)
+	translatedDesc, err := tryTranslate(clients, descPrompt, false) // Non-verbose for description
+	if err != nil {
+		log.Printf("ERROR: All providers failed for description of product ID %d: %v", p.ID, err)
+		a.failTranslation(job, translatedTitle, err) // Save title at least
+		return false
+	}
 
-		// -- Translate Description with Fallback --
-		descPrompt := fmt.Sprintf("Translate the following product description and specifications into fluent Persian. Keep the original HTML structure (like tables, lists, etc.) intact. Do not translate technical terms, brand names, or model numbers. Combine the description and specifications into a single, cohesive HTML block.\n\nDescription:\n%s\n\nSpecifications (HTML Table/List):\n%s", p.DescriptionEnglish, p.Specifications)
-		translatedDesc, err := tryTranslate(clients, descPrompt, false) // Non-verbose for description
-		if err != nil {
-			log.Printf("ERROR: All providers failed for description of product ID %d: %v. Setting status to 'translation_failed'.", p.ID, err)
-			a.Repo.UpdateProductTranslation(p.ID, translatedTitle, "", "translation_failed") // Save title at least
-			continue
+	// -- Translate A+ content, if any --
+	// A+ content is supplementary, so a failure here does not fail the product;
+	// the publisher falls back to the English version. The model may return
+	// any HTML, so it is sanitized like the scraped original.
+	if p.APlusHTML != "" {
+		aplusPrompt := fmt.Sprintf("Translate the text of the following product HTML into fluent Persian. Keep every HTML tag, image and table exactly as it is and only translate the text between tags. Do not translate brand names or model numbers.\n\nHTML:\n%s", p.APlusHTML)
+		if translatedAPlus, err := tryTranslate(clients, aplusPrompt, false); err != nil {
+			log.Printf("WARN: All providers failed for A+ content of product ID %d: %v", p.ID, err)
+		} else if err := a.Repo.UpdateProductAPlusTranslation(p.ID, utils.SanitizeHTML(translatedAPlus)); err != nil {
+			log.Printf("WARN: Could not save A+ translation for product ID %d: %v", p.ID, err)
 		}
+	}
 
-		// 4. Update database on success
-		log.Printf("Successfully translated product ID %d. Setting status to 'completed'.", p.ID)
-		err = a.Repo.UpdateProductTranslation(p.ID, translatedTitle, translatedDesc, "completed")
-		if err != nil {
-			log.Printf("FATAL: Could not update database for product ID %d: %v", p.ID, err)
-		}
+	// Update database on success
+	log.Printf("Successfully translated product ID %d. Setting status to 'completed'.", p.ID)
+	err = a.Repo.UpdateProductTranslation(p.ID, translatedTitle, translatedDesc, models.StatusCompleted, "translated")
+	if err != nil {
+		log.Printf("FATAL: Could not update database for product ID %d: %v", p.ID, err)
+		a.failJob(job, err)
+		return false
 	}
+	a.completeJob(job)
+	return true
+}
 
-	log.Println("--- Smart Translation Task Finished ---")
+// failTranslation retries a product later, or gives up once it has used all
+// its attempts and sets its status to 'translation_failed'.
+func (a *App) failTranslation(job models.Job, translatedTitle string, cause error) {
+	if job.Attempts < a.Config.Queue.WithDefaults().MaxAttempts {
+		a.failJob(job, cause)
+		return
+	}
+	log.Printf("Giving up on product ID %d after %d attempts. Setting status to 'translation_failed'.", job.ProductID, job.Attempts)
+	if err := a.Repo.UpdateProductTranslation(job.ProductID, translatedTitle, "", models.StatusTranslationFailed, cause.Error()); err != nil {
+		log.Printf("FATAL: Could not update database for product ID %d: %v", job.ProductID, err)
+	}
+	a.completeJob(job)
 }
 
 // tryTranslate attempts to translate a prompt using a list of clients until one succeeds.
@@ -236,76 +509,189 @@ func tryTranslate(clients []translator.Translator, prompt string, verbose bool)
 	return "", fmt.Errorf("all providers failed. last error: %w", lastErr)
 }
 
-// RunAutomaticWorkflow executes the entire scraping and processing pipeline in sequence.
-func (a *App) RunAutomaticWorkflow() {
-	log.Println("====== STARTING AUTOMATIC WORKFLOW ======")
-
-	log.Println("--- STEP 1 of 3: Scraping Product Deals ---")
-	a.RunProductScraper()
-	log.Println("--- STEP 1 of 3: COMPLETED ---")
+// PublishCompletedProducts transfers translated data to the clean WordPress database.
+func (a *App) PublishCompletedProducts() error {
+	log.Println("--- Starting Publishing Task ---")
 
-	// A short pause between stages can be helpful
-	time.Sleep(2 * time.Second)
+	wpRepo, err := a.openWordpress()
+	if err != nil {
+		return err
+	}
+	defer wpRepo.Close()
 
-	log.Println("--- STEP 2 of 3: Scraping Product Details ---")
-	a.RunDetailScraper()
-	log.Println("--- STEP 2 of 3: COMPLETED ---")
+	if err := a.enqueue(models.StagePublish, models.StatusCompleted); err != nil {
+		return err
+	}
+	var successCount, skippedCount, mergedCount, failedCount int
+	for {
+		products, jobs, err := a.claimBatch(models.StagePublish, a.Repo.GetCompletedProducts)
+		if err != nil {
+			return fmt.Errorf("failed to get completed products: %w", err)
+		}
+		if jobs == nil {
+			break
+		}
+		log.Printf("Claimed %d completed products to publish to wordpress.db.", len(products))
 
-	time.Sleep(2 * time.Second)
+		for _, p := range products {
+			job, err := a.renewJob(jobs[p.ID])
+			if err != nil {
+				log.Printf("Skipping product ID %d: %v", p.ID, err)
+				continue
+			}
+			outcome, err := a.publishProduct(wpRepo, p)
+			if err != nil {
+				log.Printf("Failed to publish product %s: %v", p.ProductURL, err)
+				failedCount++
+				a.failPublish(job, err)
+				continue
+			}
+			switch outcome {
+			case publishSkipped:
+				skippedCount++
+			case publishMerged:
+				mergedCount++
+			default:
+				successCount++
+			}
+			a.completeJob(job)
+		}
+	}
 
-	log.Println("--- STEP 3 of 3: Translating Product Content ---")
-	a.RunTranslator()
-	log.Println("--- STEP 3 of 3: COMPLETED ---")
+	if successCount+skippedCount+mergedCount+failedCount == 0 {
+		log.Println("No new completed products to publish.")
+		return nil
+	}
+	if skippedCount > 0 {
+		log.Printf("Skipped %d products that do not match the publish filters.", skippedCount)
+	}
+	if mergedCount > 0 {
+		log.Printf("Merged %d variations into existing variable products.", mergedCount)
+	}
+	if failedCount > 0 {
+		log.Printf("%d products failed to publish and will be retried.", failedCount)
+	}
+	log.Printf("--- Publishing Task Finished. Successfully published %d products. ---", successCount)
+	return nil
+}
 
-	log.Println("====== AUTOMATIC WORKFLOW FINISHED SUCCESSFULLY ======")
+// failPublish retries publishing a product later, or gives up once it has
+// used all its attempts and sets its status to 'publish_failed'.
+func (a *App) failPublish(job models.Job, cause error) {
+	if job.Attempts < a.Config.Queue.WithDefaults().MaxAttempts {
+		a.failJob(job, cause)
+		return
+	}
+	log.Printf("Giving up on product ID %d after %d attempts. Setting status to 'publish_failed'.", job.ProductID, job.Attempts)
+	if err := a.Repo.UpdateProductStatus(job.ProductID, models.StatusPublishFailed, cause.Error()); err != nil {
+		log.Printf("WARN: Failed to update status of product ID %d: %v", job.ProductID, err)
+	}
+	a.completeJob(job)
 }
 
-// PublishCompletedProducts transfers translated data to the clean WordPress database.
-func (a *App) PublishCompletedProducts() {
-	log.Println("--- Starting Publishing Task ---")
+// publishOutcome is what publishProduct did with a product.
+type publishOutcome int
 
-	wpRepo := wpdatabase.InitDB("wordpress.db")
-	defer wpRepo.Close()
+const (
+	publishSaved   publishOutcome = iota // saved as its own listing
+	publishMerged                        // added to its parent's variable listing
+	publishSkipped                       // filtered out by the publish filters
+)
 
-	products, err := a.Repo.GetCompletedProducts()
-	if err != nil {
-		log.Fatalf("Failed to get completed products: %v", err)
+// publishProduct saves one completed product to wordpress.db.
+func (a *App) publishProduct(wpRepo wpdatabase.Repository, p models.Product) (publishOutcome, error) {
+	if !a.publishAllowed(p) {
+		return publishSkipped, nil
 	}
-	if len(products) == 0 {
-		log.Println("No new completed products to publish.")
-		return
+
+	// --- Data Transformation ---
+	// 1. The ASIN is stored at scrape time; older rows fall back to the URL.
+	asin := p.ASIN
+	if asin == "" {
+		asin = utils.ExtractASIN(p.ProductURL)
 	}
-	log.Printf("Found %d completed products to publish to wordpress.db.", len(products))
 
-	var successCount int
-	for _, p := range products {
-		// --- Data Transformation ---
-		// 1. Generate ASIN (as a string)
-		asin := ""
-		parts := strings.Split(p.ProductURL, "/")
-		for i, part := range parts {
-			if (part == "dp" || part == "gp") && i+1 < len(parts) {
-				asin = strings.Split(parts[i+1], "?")[0]
-				break
+	// Children of the same parent share one variable listing; later
+	// siblings only refresh its variants.
+	if p.ParentASIN != "" {
+		variants, err := a.Repo.GetVariants(p.SourceSite, p.ParentASIN)
+		if err != nil {
+			return 0, fmt.Errorf("failed to load variants for parent %s: %w", p.ParentASIN, err)
+		}
+		p.Variants = variants
+
+		listed, err := wpRepo.HasParentListing(p.SourceSite, p.ParentASIN, p.ProductURL)
+		if err != nil {
+			return 0, fmt.Errorf("failed to check listing for parent %s: %w", p.ParentASIN, err)
+		}
+		if listed {
+			if err := wpRepo.SaveVariants(p.SourceSite, p.ParentASIN, p.Variants); err != nil {
+				return 0, fmt.Errorf("failed to save variants for parent %s: %w", p.ParentASIN, err)
+			}
+			if err := a.Repo.UpdateProductStatus(p.ID, models.StatusPublished, "merged into parent listing "+p.ParentASIN); err != nil {
+				log.Printf("WARN: Failed to update status for product ID %d in source db: %v", p.ID, err)
 			}
+			return publishMerged, nil
 		}
+	}
 
-		// 2. Generate Slug (as a string)
-		slug := utils.CreateSlug(p.TitleFarsi)
+	reviews, err := a.Repo.GetReviews(p.ID)
+	if err != nil {
+		log.Printf("WARN: Failed to load reviews for product ID %d: %v", p.ID, err)
+	}
+	p.Reviews = reviews
 
-		// 3. Save to the clean database by passing the extra arguments.
-		if err := wpRepo.SaveProduct(p, asin, slug); err != nil {
-			log.Printf("Failed to save product %s to wordpress.db: %v", p.ProductURL, err)
-			continue
-		}
+	// 2. Generate Slug (as a string)
+	slug := utils.CreateSlug(p.TitleFarsi)
 
-		// 4. (Optional) Update status in original database.
-		// Note: p.ID is the original int64 database ID.
-		if err := a.Repo.UpdateProductStatus(p.ID, "published"); err != nil {
-			log.Printf("WARN: Failed to update status for product ID %d in source db: %v", p.ID, err)
+	// 3. Save to the clean database by passing the extra arguments.
+	if err := wpRepo.SaveProduct(p, asin, slug); err != nil {
+		return 0, fmt.Errorf("failed to save to wordpress.db: %w", err)
+	}
+
+	// Copy the price history for charts and the 30-day low shown with the deal.
+	if p.ASIN != "" {
+		if err := a.syncPriceHistory(wpRepo, p.SourceSite, p.ASIN); err != nil {
+			log.Printf("WARN: Failed to sync price history for %s: %v", p.ASIN, err)
 		}
-		successCount++
 	}
 
-	log.Printf("--- Publishing Task Finished. Successfully published %d products. ---", successCount)
+	// 4. (Optional) Update status in original database.
+	// Note: p.ID is the original int64 database ID.
+	if err := a.Repo.UpdateProductStatus(p.ID, models.StatusPublished, "published"); err != nil {
+		log.Printf("WARN: Failed to update status for product ID %d in source db: %v", p.ID, err)
+	}
+	return publishSaved, nil
+}
+
+// publishAllowed reports whether a product passes the configured publish filters.
+func (a *App) publishAllowed(p models.Product) bool {
+	filters := a.Config.Publish
+	if filters.OnlySoldByAmazon && !p.SoldByAmazon {
+		return false
+	}
+	if filters.OnlyFulfilledByAmazon && !p.FulfilledByAmazon {
+		return false
+	}
+	if filters.OnlyPrime && !p.PrimeEligible {
+		return false
+	}
+	return true
+}
+
+// syncPriceHistory copies a product's price observations and summary to
+// wordpress.db.
+func (a *App) syncPriceHistory(wpRepo wpdatabase.Repository, sourceSite, asin string) error {
+	history, err := a.Repo.GetPriceHistory(sourceSite, asin, time.Time{})
+	if err != nil {
+		return err
+	}
+	if err := wpRepo.SavePriceHistory(sourceSite, asin, history); err != nil {
+		return err
+	}
+	stats, err := a.Repo.GetPriceStats(sourceSite, asin)
+	if err != nil {
+		return err
+	}
+	return wpRepo.UpdatePriceStats(sourceSite, asin, stats)
 }
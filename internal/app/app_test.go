@@ -0,0 +1,148 @@
+package app
+
+import (
+	"NovelScraper/internal/database"
+	"NovelScraper/internal/models"
+	"NovelScraper/internal/scraper"
+	"NovelScraper/internal/sqldb"
+	"NovelScraper/internal/translator"
+	"NovelScraper/pkg/config"
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-rod/rod"
+)
+
+// newTestApp returns an App on a fresh, migrated SQLite products database.
+func newTestApp(t *testing.T) *App {
+	t.Helper()
+	db, err := sqldb.Open(sqldb.Config{Dialect: sqldb.SQLite, DSN: filepath.Join(t.TempDir(), "products.db"), BusyTimeout: 5 * time.Second})
+	if err != nil {
+		t.Fatalf("failed to open sqlite: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	migrator, err := database.NewMigrator(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	return &App{Config: &config.Config{}, Repo: &database.DBRepository{DB: db}, Owner: "test"}
+}
+
+// fakeTranslator answers every prompt with reply.
+type fakeTranslator struct {
+	reply func(prompt string) (string, error)
+}
+
+func (f fakeTranslator) Translate(ctx context.Context, prompt string) (string, error) {
+	return f.reply(prompt)
+}
+
+func (f fakeTranslator) TranslateStream(ctx context.Context, prompt string) (<-chan string, error) {
+	text, err := f.reply(prompt)
+	if err != nil {
+		return nil, err
+	}
+	stream := make(chan string, 1)
+	stream <- text
+	close(stream)
+	return stream, nil
+}
+
+// fakeScraper stands in for the site scrapers, with no browser behind it.
+type fakeScraper struct {
+	list    func(crawl scraper.Crawl) error
+	details func(p *models.Product) error
+}
+
+func (f fakeScraper) ScrapeProductList(crawl scraper.Crawl) error { return f.list(crawl) }
+
+func (f fakeScraper) ScrapeProductDetails(p *models.Product) error { return f.details(p) }
+
+func (f fakeScraper) RefreshProduct(p *models.Product) error { return f.details(p) }
+
+// useScraper makes a run its scrapers on s instead of a browser.
+func useScraper(a *App, s scraper.Scraper) {
+	a.launchBrowser = func() (*rod.Browser, error) { return nil, nil }
+	a.scraperFor = func(*rod.Browser, string) (scraper.Scraper, error) { return s, nil }
+}
+
+func TestTranslateSanitizesAPlus(t *testing.T) {
+	a := newTestApp(t)
+	p := models.Product{
+		SourceSite: "amazon.ae", ProductURL: "https://www.amazon.ae/dp/B000000001", ASIN: "B000000001",
+		TitleEnglish: "Kettle",
+	}
+	if err := a.Repo.SaveProduct(&p); err != nil {
+		t.Fatal(err)
+	}
+	p.APlusHTML = `<h3>Fast boil</h3><img src="https://m.media-amazon.com/a.jpg">`
+	if err := a.Repo.UpdateProductDetails(p); err != nil {
+		t.Fatal(err)
+	}
+
+	clients := []translator.Translator{fakeTranslator{reply: func(prompt string) (string, error) {
+		if strings.Contains(prompt, "HTML:") {
+			return `<h3>جوش سریع</h3><script>alert(1)</script><img src="https://m.media-amazon.com/a.jpg" onerror="alert(2)">`, nil
+		}
+		return "کتری", nil
+	}}}
+	if !a.translateProduct(clients, p, models.Job{ProductID: p.ID, Stage: models.StageTranslate}) {
+		t.Fatal("translateProduct() failed")
+	}
+
+	products, err := a.Repo.GetCompletedProducts([]int64{p.ID})
+	if err != nil || len(products) != 1 {
+		t.Fatalf("GetCompletedProducts() = %d, %v; want 1", len(products), err)
+	}
+	aplus := products[0].APlusFarsi
+	if strings.Contains(aplus, "script") || strings.Contains(aplus, "alert") || strings.Contains(aplus, "onerror") {
+		t.Errorf("A+ translation was not sanitized: %s", aplus)
+	}
+	if !strings.Contains(aplus, "<h3>جوش سریع</h3>") || !strings.Contains(aplus, `src="https://m.media-amazon.com/a.jpg"`) {
+		t.Errorf("A+ translation lost its content: %s", aplus)
+	}
+}
+
+func TestFailPublishGivesUp(t *testing.T) {
+	a := newTestApp(t)
+	a.Config.Queue.MaxAttempts = 2
+	p := models.Product{SourceSite: "amazon.ae", ProductURL: "https://www.amazon.ae/dp/B000000002", ASIN: "B000000002"}
+	if err := a.Repo.SaveProduct(&p); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Repo.UpdateProductDetails(p); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Repo.UpdateProductTranslation(p.ID, "کتری", "", models.StatusCompleted, "translated"); err != nil {
+		t.Fatal(err)
+	}
+
+	for attempt := 1; attempt <= 2; attempt++ {
+		job, ok, err := a.Repo.ClaimProductJob(models.StagePublish, p.ID, a.Owner, time.Hour)
+		if err != nil || !ok || job.Attempts != attempt {
+			t.Fatalf("ClaimProductJob() #%d = %+v, %v, %v", attempt, job, ok, err)
+		}
+		a.failPublish(job, errors.New("wordpress.db is locked"))
+		// Let the retry come due at once.
+		if _, err := a.Repo.(*database.DBRepository).DB.Exec("UPDATE jobs SET next_run_at = ?", time.Now().UTC().Add(-time.Minute)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var status string
+	var jobs int
+	db := a.Repo.(*database.DBRepository).DB
+	if err := db.QueryRow("SELECT status, (SELECT COUNT(*) FROM jobs) FROM products WHERE id = ?", p.ID).Scan(&status, &jobs); err != nil {
+		t.Fatal(err)
+	}
+	if status != string(models.StatusPublishFailed) || jobs != 0 {
+		t.Errorf("after 2 failed publishes status = %q with %d jobs, want %q with none", status, jobs, models.StatusPublishFailed)
+	}
+}
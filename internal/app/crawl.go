@@ -0,0 +1,178 @@
+package app
+
+import (
+	"NovelScraper/internal/models"
+	"NovelScraper/internal/scraper"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+)
+
+// productCrawl saves the products of a list crawl as soon as they are found
+// and keeps the crawl's checkpoint in products.db. It implements
+// scraper.Crawl. Found products are compared with the products already
+// stored and with the previous complete crawl of the same listing, which
+// lets an incremental crawl stop early and the crawl be reported on.
+type productCrawl struct {
+	app *App
+	// next, if set, is called with every product after it has been saved.
+	next func(p models.Product) error
+
+	incremental    bool
+	stopAfterKnown int
+
+	previous map[string]bool // keys found by the previous complete crawl
+	last     models.CrawlState
+
+	saved, added, returning, known int
+	knownRun                       int // known products found in a row
+	stopped                        bool
+}
+
+func (a *App) newProductCrawl(next func(p models.Product) error) *productCrawl {
+	cfg := a.Config.Crawl.WithDefaults()
+	return &productCrawl{
+		app:            a,
+		next:           next,
+		incremental:    cfg.Incremental,
+		stopAfterKnown: cfg.StopAfterKnown,
+	}
+}
+
+// Found saves the products of one page of the listing in one transaction.
+// A page that fails to save stops the crawl before it is checkpointed, so
+// a resumed crawl finds its products again. An incremental crawl stops at
+// the product that completes a run of known products, and the products
+// after it are not saved.
+func (c *productCrawl) Found(products []models.Product) error {
+	end := len(products)
+	added, returning, known, knownRun := 0, 0, 0, c.knownRun
+	stopped := false
+	for i, p := range products {
+		exists, err := c.app.Repo.ProductExists(p)
+		if err != nil {
+			log.Printf("WARN: Failed to look up %s: %v", p.ProductURL, err)
+		}
+		key := models.CrawlKey(p)
+		switch {
+		case !exists:
+			added++
+		case len(c.previous) > 0 && !c.previous[key]:
+			returning++
+		default:
+			known++
+		}
+		if exists || c.previous[key] {
+			knownRun++
+		} else {
+			knownRun = 0
+		}
+		if c.incremental && knownRun >= c.stopAfterKnown {
+			stopped = true
+			end = i + 1
+			break
+		}
+	}
+
+	found := products[:end]
+	if len(found) > 0 {
+		if err := c.app.Repo.SaveProducts(found); err != nil {
+			return fmt.Errorf("failed to save %d found products: %w", len(found), err)
+		}
+	}
+	c.saved += len(found)
+	c.added += added
+	c.returning += returning
+	c.known += known
+	c.knownRun = knownRun
+	c.stopped = stopped
+	if c.next != nil {
+		for _, p := range found {
+			if err := c.next(p); err != nil {
+				return err
+			}
+		}
+	}
+
+	if c.stopped {
+		log.Printf("Found %d known products in a row, stopping the incremental crawl.", c.knownRun)
+		return scraper.ErrStopCrawl
+	}
+	return nil
+}
+
+// Resume returns the checkpoint of an unfinished crawl of url, or starts a
+// new crawl that remembers what the previous one found.
+func (c *productCrawl) Resume(url string) (models.CrawlState, error) {
+	state, ok, err := c.app.Repo.GetCrawlCheckpoint(url)
+	if err != nil {
+		return state, err
+	}
+	switch {
+	case !ok:
+		state = models.NewCrawlState(url, "")
+	case state.Done:
+		previous := state.Seen
+		state = models.NewCrawlState(url, state.SourceSite)
+		state.Previous = previous
+	default:
+		log.Printf("Resuming the crawl of %s after %d rounds and %d products (started %s).",
+			url, state.Iterations, len(state.Seen), state.StartedAt.Format("2006-01-02 15:04"))
+	}
+	c.previous = state.Previous
+	c.last = state
+	return state, nil
+}
+
+// Checkpoint stores the progress of the crawl. An incremental crawl that
+// stopped early has not seen the rest of the listing, so the products the
+// previous crawl found there are kept in Seen for the next crawl to be
+// compared with.
+func (c *productCrawl) Checkpoint(state models.CrawlState) error {
+	if state.Done && c.stopped {
+		seen := make(map[string]bool, len(c.previous)+len(state.Seen))
+		for key := range c.previous {
+			seen[key] = true
+		}
+		for key := range state.Seen {
+			seen[key] = true
+		}
+		state.Seen = seen
+	}
+	c.last = state
+	return c.app.Repo.SaveCrawlCheckpoint(state)
+}
+
+// maxVanishedListed caps how many vanished products report names.
+const maxVanishedListed = 20
+
+// report logs the new, returning and vanished products of the crawl
+// compared with the previous crawl of the listing. Vanished products are
+// only known when the crawl went through the whole listing.
+func (c *productCrawl) report() {
+	log.Printf("Crawl of %s: %d new, %d returning, %d already known products.", c.last.URL, c.added, c.returning, c.known)
+	if !c.last.Done || len(c.previous) == 0 {
+		return
+	}
+	if c.stopped {
+		log.Println("The incremental crawl stopped early, so vanished deals were not checked.")
+		return
+	}
+
+	var vanished []string
+	for key := range c.previous {
+		if !c.last.Seen[key] {
+			vanished = append(vanished, key)
+		}
+	}
+	if len(vanished) == 0 {
+		return
+	}
+	sort.Strings(vanished)
+	listed := vanished
+	if len(listed) > maxVanishedListed {
+		listed = listed[:maxVanishedListed]
+	}
+	log.Printf("%d deals from the previous crawl have vanished: %s", len(vanished), strings.Join(listed, ", "))
+}
@@ -0,0 +1,205 @@
+package app
+
+import (
+	"NovelScraper/internal/database"
+	"NovelScraper/internal/models"
+	"NovelScraper/internal/scraper"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+const testListingURL = "https://www.amazon.ae/deals"
+
+// pagedListing returns a list scraper that walks pages of test products
+// the way the site scrapers do: it resumes after the pages of an
+// unfinished crawl, checkpoints after every page and stops at the first
+// page whose products are all known. Loading page failAt fails. The pages
+// it loads are recorded in loaded.
+func pagedListing(pages [][]int, failAt int, loaded *[]int) func(scraper.Crawl) error {
+	return func(crawl scraper.Crawl) error {
+		state, err := crawl.Resume(testListingURL)
+		if err != nil {
+			return err
+		}
+		for page := state.Iterations + 1; page <= len(pages); page++ {
+			if page == failAt {
+				return fmt.Errorf("page %d did not load", page)
+			}
+			*loaded = append(*loaded, page)
+			var found []models.Product
+			for _, n := range pages[page-1] {
+				p := testProduct(n)
+				state.Seen[models.CrawlKey(p)] = true
+				found = append(found, p)
+			}
+			stopped := false
+			if err := crawl.Found(found); errors.Is(err, scraper.ErrStopCrawl) {
+				stopped = true
+			} else if err != nil {
+				return err
+			}
+			state.Iterations = page
+			if err := crawl.Checkpoint(state); err != nil {
+				return err
+			}
+			if stopped {
+				break
+			}
+		}
+		state.Done = true
+		return crawl.Checkpoint(state)
+	}
+}
+
+func TestCrawlSavesProductsAsFound(t *testing.T) {
+	a := newTestApp(t)
+	var loaded []int
+	useScraper(a, fakeScraper{list: pagedListing([][]int{{1, 2}, {3}}, 0, &loaded)})
+
+	var handedOn int
+	crawl := a.newProductCrawl(func(p models.Product) error {
+		// Products are stored before the crawl hands them on.
+		if exists, err := a.Repo.ProductExists(p); err != nil || !exists || p.ID == 0 {
+			t.Errorf("%s was handed on before it was saved (exists %v, ID %d, %v)", p.ASIN, exists, p.ID, err)
+		}
+		handedOn++
+		return nil
+	})
+	if err := a.collectProducts("amazon.ae", crawl); err != nil {
+		t.Fatal(err)
+	}
+	if crawl.saved != 3 || crawl.added != 3 || handedOn != 3 {
+		t.Errorf("saved %d, added %d and handed on %d products, want 3 of each", crawl.saved, crawl.added, handedOn)
+	}
+}
+
+func TestCrawlResumesInterruptedCrawl(t *testing.T) {
+	a := newTestApp(t)
+	pages := [][]int{{1, 2}, {3, 4}, {5, 6}}
+
+	// The first run breaks off at page 3, but keeps what it found.
+	var loaded []int
+	useScraper(a, fakeScraper{list: pagedListing(pages, 3, &loaded)})
+	if err := a.RunProductScraper("amazon.ae"); err == nil {
+		t.Fatal("RunProductScraper() with a failing page = nil, want an error")
+	}
+	for n := 1; n <= 4; n++ {
+		if exists, err := a.Repo.ProductExists(testProduct(n)); err != nil || !exists {
+			t.Errorf("product %d found before the failure was not saved: %v, %v", n, exists, err)
+		}
+	}
+	state, ok, err := a.Repo.GetCrawlCheckpoint(testListingURL)
+	if err != nil || !ok || state.Done || state.Iterations != 2 || len(state.Seen) != 4 {
+		t.Fatalf("checkpoint after the failure = %+v, %v, %v; want 2 unfinished pages with 4 products", state, ok, err)
+	}
+
+	// The next run picks up at page 3.
+	loaded = nil
+	useScraper(a, fakeScraper{list: pagedListing(pages, 0, &loaded)})
+	if err := a.RunProductScraper("amazon.ae"); err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded) != 1 || loaded[0] != 3 {
+		t.Errorf("the resumed crawl loaded pages %v, want [3]", loaded)
+	}
+	state, _, err = a.Repo.GetCrawlCheckpoint(testListingURL)
+	if err != nil || !state.Done || len(state.Seen) != 6 {
+		t.Errorf("checkpoint after resuming = %+v, %v; want a finished crawl of 6 products", state, err)
+	}
+	if exists, err := a.Repo.ProductExists(testProduct(6)); err != nil || !exists {
+		t.Errorf("product 6 from the resumed page was not saved: %v, %v", exists, err)
+	}
+
+	// Once finished, the crawl starts over and remembers what it found.
+	crawl := a.newProductCrawl(nil)
+	state, err = crawl.Resume(testListingURL)
+	if err != nil || state.Iterations != 0 || len(state.Seen) != 0 || len(state.Previous) != 6 {
+		t.Errorf("Resume() after a finished crawl = %+v, %v; want a new crawl with 6 previous products", state, err)
+	}
+}
+
+func TestIncrementalCrawlStoppedEarly(t *testing.T) {
+	a := newTestApp(t)
+	var loaded []int
+	crawlPages := func(pages [][]int) *productCrawl {
+		t.Helper()
+		useScraper(a, fakeScraper{list: pagedListing(pages, 0, &loaded)})
+		crawl := a.newProductCrawl(nil)
+		if err := a.collectProducts("amazon.ae", crawl); err != nil {
+			t.Fatal(err)
+		}
+		return crawl
+	}
+
+	crawlPages([][]int{{1, 2}, {3, 4}, {5, 6}})
+
+	// The second crawl stops after two known products in a row, without
+	// seeing products 3 to 6.
+	a.Config.Crawl.Incremental = true
+	a.Config.Crawl.StopAfterKnown = 2
+	if crawl := crawlPages([][]int{{7, 1, 2}, {3, 4}, {5, 6}}); !crawl.stopped || crawl.added != 1 {
+		t.Fatalf("second crawl stopped %v after adding %d products, want it to stop after 1", crawl.stopped, crawl.added)
+	}
+
+	// The third crawl still compares with everything found so far, so
+	// product 5 is known rather than returning.
+	a.Config.Crawl.Incremental = false
+	crawl := crawlPages([][]int{{5, 7}})
+	if len(crawl.previous) != 7 {
+		t.Errorf("third crawl compares with %d previous products, want 7", len(crawl.previous))
+	}
+	if crawl.returning != 0 || crawl.known != 2 {
+		t.Errorf("third crawl found %d returning and %d known products, want 0 and 2", crawl.returning, crawl.known)
+	}
+}
+
+// failingSaves is a repository whose SaveProducts fails on the given call.
+type failingSaves struct {
+	database.Repository
+	calls, failAt int
+}
+
+func (r *failingSaves) SaveProducts(products []models.Product) error {
+	r.calls++
+	if r.calls == r.failAt {
+		return errors.New("database is locked")
+	}
+	return r.Repository.SaveProducts(products)
+}
+
+func TestCrawlStopsWhenPageFailsToSave(t *testing.T) {
+	a := newTestApp(t)
+	repo := a.Repo
+	pages := [][]int{{1, 2}, {3, 4}, {5, 6}}
+	var loaded []int
+	useScraper(a, fakeScraper{list: pagedListing(pages, 0, &loaded)})
+
+	a.Repo = &failingSaves{Repository: repo, failAt: 2}
+	crawl := a.newProductCrawl(nil)
+	if err := a.collectProducts("amazon.ae", crawl); err == nil {
+		t.Fatal("collectProducts() with a page that fails to save = nil, want an error")
+	}
+	if crawl.saved != 2 || crawl.added != 2 {
+		t.Errorf("saved %d and added %d products, want only the 2 that were stored", crawl.saved, crawl.added)
+	}
+	state, _, err := repo.GetCrawlCheckpoint(testListingURL)
+	if err != nil || state.Iterations != 1 || len(state.Seen) != 2 {
+		t.Fatalf("checkpoint after the failed save = %+v, %v; want 1 page with 2 products", state, err)
+	}
+
+	// The next run loads the page that was not saved again.
+	a.Repo = repo
+	loaded = nil
+	if err := a.RunProductScraper("amazon.ae"); err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded) != 2 || loaded[0] != 2 {
+		t.Errorf("the resumed crawl loaded pages %v, want [2 3]", loaded)
+	}
+	for n := 3; n <= 4; n++ {
+		if exists, err := repo.ProductExists(testProduct(n)); err != nil || !exists {
+			t.Errorf("product %d of the page that failed to save was not saved by the next run: %v, %v", n, exists, err)
+		}
+	}
+}
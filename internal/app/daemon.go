@@ -0,0 +1,128 @@
+package app
+
+import (
+	"NovelScraper/internal/scraper/amazon"
+	"NovelScraper/pkg/config"
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// daemonTasks are the tasks a daemon schedule can run.
+var daemonTasks = map[string]func(a *App, site string) error{
+	"scrape-products": func(a *App, site string) error {
+		if site == "" {
+			site = amazon.SourceSite
+		}
+		return a.RunProductScraper(site)
+	},
+	"scrape-details": func(a *App, _ string) error { return a.RunDetailScraper() },
+	"refresh":        func(a *App, _ string) error { return a.RunRefresher() },
+	"translate":      func(a *App, _ string) error { return a.RunTranslator() },
+	"publish":        func(a *App, _ string) error { return a.PublishCompletedProducts() },
+}
+
+// RunDaemon runs the schedules from config.yml until ctx is cancelled. Each
+// due schedule runs in a goroutine of its own, so a long or stuck task does
+// not hold up the others; a schedule that is still running, here or in
+// another process, is skipped until it has finished. A failing run is
+// logged and retried at its next interval. On cancellation the daemon
+// waits for the running schedules to finish.
+func (a *App) RunDaemon(ctx context.Context) error {
+	cfg := a.Config.Daemon.WithDefaults()
+
+	var schedules []config.Schedule
+	for _, s := range cfg.Schedules {
+		if _, ok := daemonTasks[s.Task]; !ok {
+			return fmt.Errorf("schedule %q: unknown task %q", s.Key(), s.Task)
+		}
+		if s.Every <= 0 {
+			log.Printf("Schedule %q is disabled.", s.Key())
+			continue
+		}
+		schedules = append(schedules, s)
+	}
+	if len(schedules) == 0 {
+		return fmt.Errorf("no daemon schedules are enabled in config.yml")
+	}
+
+	log.Printf("====== DAEMON STARTED (%d schedules, checked every %v) ======", len(schedules), cfg.Tick)
+	ticker := time.NewTicker(cfg.Tick)
+	defer ticker.Stop()
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	running := make(map[string]bool)
+	for {
+		for _, s := range schedules {
+			if ctx.Err() != nil {
+				break
+			}
+			mu.Lock()
+			if running[s.Key()] {
+				mu.Unlock()
+				continue
+			}
+			running[s.Key()] = true
+			mu.Unlock()
+
+			wg.Add(1)
+			go func(s config.Schedule) {
+				defer wg.Done()
+				defer func() {
+					mu.Lock()
+					delete(running, s.Key())
+					mu.Unlock()
+				}()
+				a.runSchedule(s, cfg.LockTimeout)
+			}(s)
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Println("Waiting for running schedules to finish...")
+			wg.Wait()
+			log.Println("====== DAEMON STOPPED ======")
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// runSchedule runs a schedule if it is due and not already running.
+func (a *App) runSchedule(s config.Schedule, lockTimeout time.Duration) {
+	started, err := a.Repo.StartScheduleRun(s.Key(), a.Owner, s.Every, lockTimeout)
+	if err != nil {
+		log.Printf("WARN: Failed to start schedule %q: %v", s.Key(), err)
+		return
+	}
+	if !started {
+		return
+	}
+
+	log.Printf("--- Schedule %q: starting %s ---", s.Key(), s.Task)
+	begin := time.Now()
+	runErr := a.runTask(s)
+	if runErr != nil {
+		log.Printf("ERROR: Schedule %q failed after %v: %v", s.Key(), time.Since(begin).Round(time.Second), runErr)
+	} else {
+		log.Printf("--- Schedule %q finished in %v, next run in %v ---", s.Key(), time.Since(begin).Round(time.Second), s.Every)
+	}
+	if err := a.Repo.FinishScheduleRun(s.Key(), a.Owner, runErr); err != nil {
+		log.Printf("WARN: Failed to record run of schedule %q: %v", s.Key(), err)
+	}
+}
+
+// runTask runs the task of a schedule. The scrapers panic when the browser
+// fails, so panics are turned into errors to keep the daemon running.
+// Goroutines a task starts recover their own panics; see scrapeSafely.
+func (a *App) runTask(s config.Schedule) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return daemonTasks[s.Task](a, s.Site)
+}
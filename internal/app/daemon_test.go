@@ -0,0 +1,62 @@
+package app
+
+import (
+	"NovelScraper/internal/database"
+	"NovelScraper/internal/models"
+	"NovelScraper/pkg/config"
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDaemonSurvivesPanicInWorker(t *testing.T) {
+	a := newTestApp(t)
+	a.Config.Scraper.Workers = "2"
+	useScraper(a, fakeScraper{details: func(p *models.Product) error {
+		panic("browser connection lost")
+	}})
+	p := models.Product{SourceSite: "amazon.ae", ProductURL: "https://www.amazon.ae/dp/B000000003", ASIN: "B000000003"}
+	if err := a.Repo.SaveProduct(&p); err != nil {
+		t.Fatal(err)
+	}
+
+	// A task that stays running must not hold up the detail scraper, which
+	// panics in its worker goroutines.
+	release := make(chan struct{})
+	daemonTasks["test-block"] = func(*App, string) error {
+		<-release
+		return nil
+	}
+	defer delete(daemonTasks, "test-block")
+	a.Config.Daemon = config.DaemonConfig{Tick: 10 * time.Millisecond, Schedules: []config.Schedule{
+		{Task: "test-block", Every: time.Hour},
+		{Task: "scrape-details", Every: time.Hour},
+	}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- a.RunDaemon(ctx) }()
+
+	db := a.Repo.(*database.DBRepository).DB
+	deadline := time.Now().Add(30 * time.Second)
+	for {
+		var lastError string
+		if err := db.QueryRow("SELECT COALESCE(last_error, '') FROM products WHERE id = ?", p.ID).Scan(&lastError); err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(lastError, "browser connection lost") {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the detail scrape never recorded the panic")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	cancel()
+	close(release)
+	if err := <-done; err != nil {
+		t.Errorf("RunDaemon() = %v", err)
+	}
+}
@@ -0,0 +1,21 @@
+package app
+
+import (
+	"NovelScraper/internal/sqldb"
+	"NovelScraper/internal/wpdatabase"
+	"fmt"
+)
+
+// openWordpress opens the published catalog, wordpress.db or the database
+// configured in its place.
+func (a *App) openWordpress() (wpdatabase.Repository, error) {
+	_, wordpressDB, err := sqldb.FromConfig(a.Config.Database)
+	if err != nil {
+		return nil, fmt.Errorf("invalid database config: %w", err)
+	}
+	repo, err := wpdatabase.InitDB(wordpressDB)
+	if err != nil {
+		return nil, err
+	}
+	return repo, nil
+}
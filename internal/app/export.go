@@ -0,0 +1,306 @@
+package app
+
+import (
+	"NovelScraper/internal/models"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// ExportOptions selects what RunExport writes.
+type ExportOptions struct {
+	Format  string   // csv, jsonl or xlsx
+	Columns []string // names from ExportColumns; DefaultExportColumns when empty
+}
+
+// exportColumn is a column that can be exported and how to read it from a
+// product. Values are strings, numbers, bools or times.
+type exportColumn struct {
+	name  string
+	value func(p *models.Product) interface{}
+}
+
+var exportColumns = []exportColumn{
+	{"id", func(p *models.Product) interface{} { return p.ID }},
+	{"source_site", func(p *models.Product) interface{} { return p.SourceSite }},
+	{"asin", func(p *models.Product) interface{} { return p.ASIN }},
+	{"product_url", func(p *models.Product) interface{} { return p.ProductURL }},
+	{"category", func(p *models.Product) interface{} { return p.Category }},
+	{"status", func(p *models.Product) interface{} { return string(p.Status) }},
+	{"deal_type", func(p *models.Product) interface{} { return p.DealType }},
+	{"title_english", func(p *models.Product) interface{} { return p.TitleEnglish }},
+	{"title_farsi", func(p *models.Product) interface{} { return p.TitleFarsi }},
+	{"description_english", func(p *models.Product) interface{} { return p.DescriptionEnglish }},
+	{"description_farsi", func(p *models.Product) interface{} { return p.DescriptionFarsi }},
+	{"brand", func(p *models.Product) interface{} { return p.Brand }},
+	{"availability", func(p *models.Product) interface{} { return p.Availability }},
+	{"availability_state", func(p *models.Product) interface{} { return string(p.AvailabilityState) }},
+	{"stock_count", func(p *models.Product) interface{} { return p.StockCount }},
+	{"original_price", func(p *models.Product) interface{} { return p.OriginalPrice }},
+	{"discount_price", func(p *models.Product) interface{} { return p.DiscountPrice }},
+	{"discount_percent", func(p *models.Product) interface{} { return p.DiscountPercent }},
+	{"effective_price", func(p *models.Product) interface{} { return p.EffectivePrice }},
+	{"effective_discount_percent", func(p *models.Product) interface{} { return p.EffectiveDiscountPercent }},
+	{"currency", func(p *models.Product) interface{} { return p.Currency }},
+	{"main_image_url", func(p *models.Product) interface{} { return p.MainImageURL }},
+	{"country_of_origin", func(p *models.Product) interface{} { return p.CountryOfOrigin }},
+	{"model_number", func(p *models.Product) interface{} { return p.ModelNumber }},
+	{"manufacturer", func(p *models.Product) interface{} { return p.Manufacturer }},
+	{"ean", func(p *models.Product) interface{} { return p.EAN }},
+	{"upc", func(p *models.Product) interface{} { return p.UPC }},
+	{"seller_name", func(p *models.Product) interface{} { return p.SellerName }},
+	{"sold_by_amazon", func(p *models.Product) interface{} { return p.SoldByAmazon }},
+	{"fulfilled_by_amazon", func(p *models.Product) interface{} { return p.FulfilledByAmazon }},
+	{"prime_eligible", func(p *models.Product) interface{} { return p.PrimeEligible }},
+	{"parent_asin", func(p *models.Product) interface{} { return p.ParentASIN }},
+	{"rating", func(p *models.Product) interface{} { return p.Rating }},
+	{"rating_count", func(p *models.Product) interface{} { return p.RatingCount }},
+	{"scraped_at", func(p *models.Product) interface{} { return p.ScrapedAt }},
+	{"refreshed_at", func(p *models.Product) interface{} { return p.RefreshedAt }},
+}
+
+// DefaultExportColumns are exported when no columns are selected.
+var DefaultExportColumns = []string{
+	"id", "source_site", "asin", "status", "category", "title_english", "title_farsi", "brand",
+	"original_price", "discount_price", "discount_percent", "effective_price", "currency",
+	"availability_state", "product_url", "scraped_at",
+}
+
+// ExportColumns returns the names of all columns that can be exported.
+func ExportColumns() []string {
+	names := make([]string, len(exportColumns))
+	for i, c := range exportColumns {
+		names[i] = c.name
+	}
+	return names
+}
+
+// ExportFormat returns the format for an output file name, csv unless the
+// extension names another one.
+func ExportFormat(path string) string {
+	for _, format := range []string{"jsonl", "xlsx"} {
+		if strings.HasSuffix(strings.ToLower(path), "."+format) {
+			return format
+		}
+	}
+	return "csv"
+}
+
+// RunExport writes the products matching filters to w, one row per product
+// as they are read from the database.
+func (a *App) RunExport(filters models.ProductFilters, opts ExportOptions, w io.Writer) error {
+	names := opts.Columns
+	if len(names) == 0 {
+		names = DefaultExportColumns
+	}
+	columns, err := selectExportColumns(names)
+	if err != nil {
+		return err
+	}
+	out, err := newRowWriter(opts.Format, w, names)
+	if err != nil {
+		return err
+	}
+
+	count := 0
+	values := make([]interface{}, len(columns))
+	err = a.Repo.EachFilteredProduct(filters, func(p models.Product) error {
+		for i, c := range columns {
+			values[i] = c.value(&p)
+		}
+		count++
+		return out.WriteRow(values)
+	})
+	if err == nil {
+		err = out.Close()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to export products: %w", err)
+	}
+	log.Printf("Exported %d products as %s.", count, opts.Format)
+	return nil
+}
+
+func selectExportColumns(names []string) ([]exportColumn, error) {
+	byName := make(map[string]exportColumn, len(exportColumns))
+	for _, c := range exportColumns {
+		byName[c.name] = c
+	}
+	columns := make([]exportColumn, len(names))
+	for i, name := range names {
+		c, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown column %q (available: %s)", name, strings.Join(ExportColumns(), ", "))
+		}
+		columns[i] = c
+	}
+	return columns, nil
+}
+
+// rowWriter writes exported rows in one format. The header is written when
+// it is created; Close finishes the output.
+type rowWriter interface {
+	WriteRow(values []interface{}) error
+	Close() error
+}
+
+func newRowWriter(format string, w io.Writer, header []string) (rowWriter, error) {
+	switch format {
+	case "csv":
+		out := &csvRowWriter{w: csv.NewWriter(w)}
+		return out, out.w.Write(header)
+	case "jsonl":
+		return &jsonlRowWriter{w: w, keys: header}, nil
+	case "xlsx":
+		return newXLSXRowWriter(w, header)
+	default:
+		return nil, fmt.Errorf("unknown export format %q (want csv, jsonl or xlsx)", format)
+	}
+}
+
+// exportTimeFormat is how times are written to CSV and XLSX cells.
+const exportTimeFormat = "2006-01-02 15:04:05"
+
+// exportText formats a value for a text cell. Unset times are left empty.
+func exportText(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return textCell(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+		return v.Format(exportTimeFormat)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// textCell keeps a spreadsheet from reading scraped text as a formula:
+// text starting with one of the characters that begin a formula is
+// prefixed with a quote.
+func textCell(s string) string {
+	if s != "" && strings.ContainsRune("=+-@", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+type csvRowWriter struct {
+	w      *csv.Writer
+	record []string
+}
+
+func (c *csvRowWriter) WriteRow(values []interface{}) error {
+	c.record = c.record[:0]
+	for _, v := range values {
+		c.record = append(c.record, exportText(v))
+	}
+	return c.w.Write(c.record)
+}
+
+func (c *csvRowWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// jsonlRowWriter writes an object per line with the keys in column order.
+// Unset times are null.
+type jsonlRowWriter struct {
+	w    io.Writer
+	keys []string
+	line []byte
+}
+
+func (j *jsonlRowWriter) WriteRow(values []interface{}) error {
+	j.line = append(j.line[:0], '{')
+	for i, v := range values {
+		if t, ok := v.(time.Time); ok && t.IsZero() {
+			v = nil
+		}
+		key, _ := json.Marshal(j.keys[i])
+		value, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			j.line = append(j.line, ',')
+		}
+		j.line = append(append(append(j.line, key...), ':'), value...)
+	}
+	j.line = append(j.line, '}', '\n')
+	_, err := j.w.Write(j.line)
+	return err
+}
+
+func (j *jsonlRowWriter) Close() error { return nil }
+
+// xlsxRowWriter streams rows into a single sheet. excelize keeps the rows
+// in a temporary file until the workbook is written out by Close, but it
+// builds the compressed workbook in memory there, for SaveAs as well as
+// WriteTo. An export therefore needs about the size of the .xlsx file in
+// memory; very large exports are better written as csv or jsonl.
+type xlsxRowWriter struct {
+	w      io.Writer
+	file   *excelize.File
+	stream *excelize.StreamWriter
+	row    int
+	cells  []interface{}
+}
+
+const xlsxSheet = "Products"
+
+func newXLSXRowWriter(w io.Writer, header []string) (*xlsxRowWriter, error) {
+	file := excelize.NewFile()
+	if err := file.SetSheetName("Sheet1", xlsxSheet); err != nil {
+		return nil, err
+	}
+	stream, err := file.NewStreamWriter(xlsxSheet)
+	if err != nil {
+		return nil, err
+	}
+	x := &xlsxRowWriter{w: w, file: file, stream: stream}
+	cells := make([]interface{}, len(header))
+	for i, name := range header {
+		cells[i] = name
+	}
+	return x, x.WriteRow(cells)
+}
+
+func (x *xlsxRowWriter) WriteRow(values []interface{}) error {
+	x.cells = x.cells[:0]
+	for _, v := range values {
+		switch v := v.(type) {
+		case string:
+			x.cells = append(x.cells, textCell(v))
+		case int, int64, float64, bool:
+			x.cells = append(x.cells, v)
+		default:
+			x.cells = append(x.cells, exportText(v))
+		}
+	}
+	x.row++
+	cell, err := excelize.CoordinatesToCellName(1, x.row)
+	if err != nil {
+		return err
+	}
+	return x.stream.SetRow(cell, x.cells)
+}
+
+func (x *xlsxRowWriter) Close() error {
+	defer x.file.Close()
+	if err := x.stream.Flush(); err != nil {
+		return err
+	}
+	_, err := x.file.WriteTo(x.w)
+	return err
+}
@@ -0,0 +1,60 @@
+package app
+
+import (
+	"bytes"
+	"encoding/csv"
+	"reflect"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestExportGuardsFormulas(t *testing.T) {
+	row := []interface{}{"=SUM(1)", "+1", "-5", "@x", "ok", -5.0}
+	want := []string{"'=SUM(1)", "'+1", "'-5", "'@x", "ok", "-5"}
+
+	var buf bytes.Buffer
+	w, err := newRowWriter("csv", &buf, []string{"a", "b", "c", "d", "e", "f"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteRow(row); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := records[1]; !reflect.DeepEqual(got, want) {
+		t.Errorf("csv row = %q, want %q", got, want)
+	}
+
+	buf.Reset()
+	if w, err = newRowWriter("xlsx", &buf, []string{"a", "b", "c", "d", "e", "f"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteRow(row); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	file, err := excelize.OpenReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	rows, err := file.GetRows(xlsxSheet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := rows[1]; !reflect.DeepEqual(got, want) {
+		t.Errorf("xlsx row = %q, want %q", got, want)
+	}
+	if formula, err := file.GetCellFormula(xlsxSheet, "A2"); err != nil || formula != "" {
+		t.Errorf("A2 has formula %q (%v), want none", formula, err)
+	}
+}
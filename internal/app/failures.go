@@ -0,0 +1,45 @@
+package app
+
+import (
+	"NovelScraper/internal/models"
+	"fmt"
+	"io"
+	"text/tabwriter"
+)
+
+// RunFailureReport writes the products whose details could not be scraped,
+// grouped by failure reason, followed by the dead-lettered products.
+func (a *App) RunFailureReport(w io.Writer) error {
+	summary, err := a.Repo.GetFailureSummary()
+	if err != nil {
+		return err
+	}
+	if len(summary) == 0 {
+		fmt.Fprintln(w, "No failed products.")
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "REASON\tPRODUCTS\tDEAD LETTER\tFAILED ATTEMPTS")
+	for _, s := range summary {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\n", s.Reason, s.Products, s.DeadLetter, s.Attempts)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	deadLetter, err := a.Repo.GetFailedProducts(models.StatusDeadLetter)
+	if err != nil {
+		return err
+	}
+	if len(deadLetter) == 0 {
+		return nil
+	}
+	fmt.Fprintf(w, "\nDead-lettered products (requeue with -task=requeue -from=dead_letter -to=needs_details):\n")
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tREASON\tATTEMPTS\tFAILED AT\tURL\tLAST ERROR")
+	for _, p := range deadLetter {
+		fmt.Fprintf(tw, "%d\t%s\t%d\t%s\t%s\t%s\n", p.ID, p.Reason, p.Attempts, p.FailedAt.Format("2006-01-02 15:04"), p.ProductURL, p.LastError)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,117 @@
+package app
+
+import (
+	"NovelScraper/internal/models"
+	"NovelScraper/utils"
+	"fmt"
+	"log"
+	"os"
+)
+
+// leaseOwner identifies this process in job leases.
+func leaseOwner() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s:%d", host, os.Getpid())
+}
+
+// enqueue creates jobs for a stage from the products in the stage's status.
+func (a *App) enqueue(stage string, status models.ProductStatus) error {
+	queued, err := a.Repo.EnqueueJobs(stage, status)
+	if err != nil {
+		return fmt.Errorf("failed to queue products for %s: %w", stage, err)
+	}
+	if queued > 0 {
+		log.Printf("Queued %d new products for %s.", queued, stage)
+	}
+	return nil
+}
+
+// claimBatch claims the next batch of due jobs of a stage and loads their
+// products with load. Jobs whose product has left the stage since it was
+// queued are completed on the spot. The returned jobs are keyed by product
+// ID and are nil once the stage has no due work left.
+func (a *App) claimBatch(stage string, load func(ids []int64) ([]models.Product, error)) ([]models.Product, map[int64]models.Job, error) {
+	queue := a.Config.Queue.WithDefaults()
+	jobs, err := a.Repo.ClaimJobs(stage, a.Owner, queue.BatchSize, queue.Lease)
+	if err != nil || len(jobs) == 0 {
+		return nil, nil, err
+	}
+
+	byProduct := make(map[int64]models.Job, len(jobs))
+	ids := make([]int64, len(jobs))
+	for i, job := range jobs {
+		ids[i] = job.ProductID
+		byProduct[job.ProductID] = job
+	}
+
+	products, err := load(ids)
+	if err != nil {
+		for _, job := range jobs {
+			a.failJob(job, err)
+		}
+		return nil, nil, err
+	}
+
+	loaded := make(map[int64]bool, len(products))
+	for _, p := range products {
+		loaded[p.ID] = true
+	}
+	for _, job := range jobs {
+		if !loaded[job.ProductID] {
+			a.completeJob(job)
+		}
+	}
+	return products, byProduct, nil
+}
+
+// claimProduct claims the job of a single product for a stage and loads the
+// product with load, like claimBatch does for a batch. ok is false when
+// another worker holds the job, it is waiting out a backoff, or the product
+// has left the stage.
+func (a *App) claimProduct(stage string, id int64, load func(ids []int64) ([]models.Product, error)) (p models.Product, job models.Job, ok bool, err error) {
+	queue := a.Config.Queue.WithDefaults()
+	job, ok, err = a.Repo.ClaimProductJob(stage, id, a.Owner, queue.Lease)
+	if err != nil || !ok {
+		return p, job, false, err
+	}
+
+	products, err := load([]int64{id})
+	if err != nil {
+		a.failJob(job, err)
+		return p, job, false, err
+	}
+	if len(products) == 0 {
+		a.completeJob(job)
+		return p, job, false, nil
+	}
+	return products[0], job, true, nil
+}
+
+// renewJob extends the lease of a job claimed with a batch just before its
+// product is processed. It fails with database.ErrLeaseLost when the lease
+// ran out while the job waited and another worker has taken it over.
+func (a *App) renewJob(job models.Job) (models.Job, error) {
+	return a.Repo.RenewJob(job, a.Config.Queue.WithDefaults().Lease)
+}
+
+// completeJob removes a job whose product is done with its stage.
+func (a *App) completeJob(job models.Job) {
+	if err := a.Repo.CompleteJob(job); err != nil {
+		log.Printf("WARN: Failed to complete %s job %d: %v", job.Stage, job.ID, err)
+	}
+}
+
+// failJob releases a job and schedules another attempt with exponential
+// backoff.
+func (a *App) failJob(job models.Job, cause error) {
+	queue := a.Config.Queue.WithDefaults()
+	backoff := utils.Backoff(job.Attempts, queue.BaseBackoff, queue.MaxBackoff)
+	if err := a.Repo.FailJob(job, cause, backoff); err != nil {
+		log.Printf("WARN: Failed to release %s job %d: %v", job.Stage, job.ID, err)
+		return
+	}
+	log.Printf("%s of product ID %d failed on attempt %d, retrying in %v: %v", job.Stage, job.ProductID, job.Attempts, backoff, cause)
+}
@@ -0,0 +1,111 @@
+package app
+
+import (
+	"NovelScraper/internal/database"
+	"NovelScraper/internal/migrate"
+	"NovelScraper/internal/sqldb"
+	"NovelScraper/internal/wpdatabase"
+	"NovelScraper/pkg/config"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+)
+
+// MigrateOptions selects what RunMigrations does.
+type MigrateOptions struct {
+	StatusOnly bool // only print which migrations are applied
+	DryRun     bool // print the pending migrations without applying them
+}
+
+// migrationTarget is a database and the migrations that belong to it.
+type migrationTarget struct {
+	name        string
+	db          sqldb.Config
+	open        func(sqldb.Config) (*sqldb.DB, error)
+	newMigrator func(*sqldb.DB) (*migrate.Migrator, error)
+}
+
+// RunMigrations brings products.db and wordpress.db, or the databases
+// configured in their place, up to the schema this binary expects, writing
+// a report to w. It runs before App is created, since New refuses to open a
+// database with pending migrations.
+func RunMigrations(dbCfg config.DatabaseConfig, opts MigrateOptions, w io.Writer) error {
+	productsDB, wordpressDB, err := sqldb.FromConfig(dbCfg)
+	if err != nil {
+		return err
+	}
+	targets := []migrationTarget{
+		{"products.db", productsDB, database.Open, database.NewMigrator},
+		{"wordpress.db", wordpressDB, wpdatabase.Open, wpdatabase.NewMigrator},
+	}
+	for _, target := range targets {
+		if err := migrateDatabase(target, opts, w); err != nil {
+			return fmt.Errorf("%s: %w", target.name, err)
+		}
+	}
+	return nil
+}
+
+func migrateDatabase(target migrationTarget, opts MigrateOptions, w io.Writer) error {
+	db, err := target.open(target.db)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	migrator, err := target.newMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	statuses, err := migrator.Status()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "%s (%s):\n", target.name, target.db.Dialect)
+
+	if opts.StatusOnly {
+		for _, s := range statuses {
+			state := "pending"
+			if s.Applied {
+				state = "applied " + s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "  %03d_%-28s %s\n", s.Version, s.Name, state)
+		}
+		return nil
+	}
+
+	pending, err := migrator.Pending()
+	if err != nil {
+		return err
+	}
+	if len(pending) == 0 {
+		fmt.Fprintln(w, "  up to date")
+		return nil
+	}
+
+	if opts.DryRun {
+		for _, m := range pending {
+			fmt.Fprintf(w, "  would apply %03d_%s\n", m.Version, m.Name)
+			if m.Up != nil {
+				fmt.Fprintln(w, "    (Go migration)")
+				continue
+			}
+			for _, stmt := range migrate.SplitStatements(m.SQL) {
+				fmt.Fprintf(w, "    %s;\n", strings.ReplaceAll(stmt, "\n", "\n    "))
+			}
+		}
+		return nil
+	}
+
+	applied, err := migrator.Up()
+	for _, m := range applied {
+		fmt.Fprintf(w, "  applied %03d_%s\n", m.Version, m.Name)
+	}
+	if err != nil {
+		return err
+	}
+	log.Printf("%s: applied %d migrations", target.name, len(applied))
+	return nil
+}
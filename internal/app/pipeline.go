@@ -0,0 +1,323 @@
+package app
+
+import (
+	"NovelScraper/internal/models"
+	"NovelScraper/internal/scraper"
+	"NovelScraper/internal/translator"
+	"NovelScraper/internal/wpdatabase"
+	"NovelScraper/utils"
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// pipelineProgress counts the products that have passed each stage of the
+// automatic workflow.
+type pipelineProgress struct {
+	listed          atomic.Int64
+	backlog         atomic.Int64 // taken from products.db rather than the crawl
+	scraped         atomic.Int64
+	scrapeFailed    atomic.Int64
+	translated      atomic.Int64
+	translateFailed atomic.Int64
+	published       atomic.Int64
+	publishSkipped  atomic.Int64
+	publishFailed   atomic.Int64
+}
+
+// report formats the counters together with how many products wait in
+// front of each stage.
+func (p *pipelineProgress) report(waitDetails, waitTranslate, waitPublish int) string {
+	return fmt.Sprintf("listed %d, %d from the backlog | details %d done, %d failed, %d waiting | translation %d done, %d failed, %d waiting | published %d, %d skipped, %d failed, %d waiting",
+		p.listed.Load(), p.backlog.Load(),
+		p.scraped.Load(), p.scrapeFailed.Load(), waitDetails,
+		p.translated.Load(), p.translateFailed.Load(), waitTranslate,
+		p.published.Load(), p.publishSkipped.Load(), p.publishFailed.Load(), waitPublish)
+}
+
+// RunAutomaticWorkflow runs the whole pipeline for one site as a stream:
+// each product moves on to detail scraping, translation and publishing as
+// soon as the previous stage is done with it, instead of waiting for the
+// stage to finish with every product. Stages are connected by bounded
+// channels, so a slow stage holds back the ones before it. Besides the
+// products the list crawl finds, each stage takes the backlog waiting for
+// it in products.db, such as products left by an interrupted run. Products
+// are claimed through the job queue, so the workflow can run next to the
+// individual tasks or a daemon. When ctx is cancelled no more products are
+// listed and the products already in the pipeline are finished.
+func (a *App) RunAutomaticWorkflow(ctx context.Context, site string) error {
+	cfg := a.Config.Pipeline.WithDefaults()
+	detailWorkers := cfg.DetailWorkers
+	if detailWorkers <= 0 {
+		detailWorkers = utils.GetOptimalWorkerCount(a.Config.Scraper.Workers)
+	}
+
+	clients, err := a.translatorClients()
+	if err != nil {
+		return err
+	}
+	wpRepo, err := a.openWordpress()
+	if err != nil {
+		return err
+	}
+	defer wpRepo.Close()
+
+	log.Printf("====== STARTING AUTOMATIC WORKFLOW (%s: %d detail workers, %d translation workers) ======", site, detailWorkers, cfg.TranslateWorkers)
+
+	var progress pipelineProgress
+	queues := pipelineQueues{
+		details:   make(chan int64, cfg.Buffer),
+		translate: make(chan int64, cfg.Buffer),
+		publish:   make(chan int64, cfg.Buffer),
+	}
+
+	// A stage is fed by the list crawl, its backlog and the stage before
+	// it; its channel is closed once all of them are done.
+	var feedDetails, feedTranslate, feedPublish sync.WaitGroup
+	feedDetails.Add(1)
+	feedTranslate.Add(1)
+	feedPublish.Add(1)
+	listErr := make(chan error, 1)
+	go func() {
+		defer feedDetails.Done()
+		defer feedTranslate.Done()
+		defer feedPublish.Done()
+		listErr <- a.listProducts(ctx, site, queues, &progress)
+	}()
+
+	a.seedBacklog(ctx, models.StatusNeedsDetails, queues.details, &feedDetails, &progress)
+	a.seedBacklog(ctx, models.StatusNeedsTranslation, queues.translate, &feedTranslate, &progress)
+	a.seedBacklog(ctx, models.StatusCompleted, queues.publish, &feedPublish, &progress)
+
+	runWorkers(detailWorkers, &feedTranslate, func(workerID int) {
+		worker, err := a.newScrapeWorker(workerID)
+		if err != nil {
+			// Keep taking products so the list stage is not blocked; they
+			// stay in 'needs_details' for the next run.
+			log.Printf("[Worker %d] %v", workerID, err)
+			for range queues.details {
+				progress.scrapeFailed.Add(1)
+			}
+			return
+		}
+		defer worker.close()
+		for id := range queues.details {
+			if a.pipelineDetails(worker, id, &progress) {
+				queues.translate <- id
+			}
+		}
+	})
+
+	runWorkers(cfg.TranslateWorkers, &feedPublish, func(int) {
+		for id := range queues.translate {
+			if a.pipelineTranslate(clients, id, &progress) {
+				queues.publish <- id
+			}
+		}
+	})
+
+	// wordpress.db has a single publisher.
+	published := make(chan struct{})
+	go func() {
+		defer close(published)
+		for id := range queues.publish {
+			a.pipelinePublish(wpRepo, id, &progress)
+		}
+	}()
+
+	closeWhenFed(&feedDetails, queues.details)
+	closeWhenFed(&feedTranslate, queues.translate)
+	closeWhenFed(&feedPublish, queues.publish)
+
+	ticker := time.NewTicker(cfg.ProgressInterval)
+	defer ticker.Stop()
+	for done := false; !done; {
+		select {
+		case <-published:
+			done = true
+		case <-ticker.C:
+			log.Printf("Progress: %s", progress.report(len(queues.details), len(queues.translate), len(queues.publish)))
+		}
+	}
+	log.Printf("Finished: %s", progress.report(0, 0, 0))
+
+	if err := <-listErr; err != nil && ctx.Err() == nil {
+		return err
+	}
+	if ctx.Err() != nil {
+		log.Println("====== AUTOMATIC WORKFLOW STOPPED ======")
+		return nil
+	}
+	log.Println("====== AUTOMATIC WORKFLOW FINISHED SUCCESSFULLY ======")
+	return nil
+}
+
+// pipelineQueues are the bounded channels in front of the pipeline stages.
+type pipelineQueues struct {
+	details, translate, publish chan int64
+}
+
+// forStatus returns the queue of the stage a product in status waits for,
+// or nil when it waits for none, e.g. because it is published.
+func (q pipelineQueues) forStatus(status models.ProductStatus) chan int64 {
+	switch status {
+	case models.StatusNeedsDetails:
+		return q.details
+	case models.StatusNeedsTranslation:
+		return q.translate
+	case models.StatusCompleted:
+		return q.publish
+	default:
+		return nil
+	}
+}
+
+// runWorkers starts n workers that feed the next stage.
+func runWorkers(n int, feeds *sync.WaitGroup, work func(workerID int)) {
+	feeds.Add(n)
+	for w := 1; w <= n; w++ {
+		go func(workerID int) {
+			defer feeds.Done()
+			work(workerID)
+		}(w)
+	}
+}
+
+// closeWhenFed closes a stage's channel once everything feeding it is done.
+func closeWhenFed(feeds *sync.WaitGroup, out chan int64) {
+	go func() {
+		feeds.Wait()
+		close(out)
+	}()
+}
+
+// seedBacklog sends the products already waiting in status to the stage
+// that takes them, alongside the products the crawl finds.
+func (a *App) seedBacklog(ctx context.Context, status models.ProductStatus, out chan<- int64, feeds *sync.WaitGroup, progress *pipelineProgress) {
+	feeds.Add(1)
+	go func() {
+		defer feeds.Done()
+		ids, err := a.Repo.GetProductIDsByStatus(status)
+		if err != nil {
+			log.Printf("WARN: Failed to load the products in '%s': %v", status, err)
+			return
+		}
+		if len(ids) > 0 {
+			log.Printf("Found %d products in '%s' from earlier runs.", len(ids), status)
+		}
+		for _, id := range ids {
+			select {
+			case out <- id:
+				progress.backlog.Add(1)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// listProducts scrapes the product list of a site, saving every product as
+// it is found and sending it on to the stage it waits for. Products that
+// were already known wait for the stage they had reached.
+func (a *App) listProducts(ctx context.Context, site string, queues pipelineQueues, progress *pipelineProgress) error {
+	crawl := a.newProductCrawl(func(p models.Product) error {
+		progress.listed.Add(1)
+		out := queues.forStatus(p.Status)
+		if out == nil {
+			return nil
+		}
+		select {
+		case out <- p.ID:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+	if err := a.collectProducts(site, crawl); err != nil {
+		return err
+	}
+	crawl.report()
+	return nil
+}
+
+// pipelineDetails scrapes the details of one product and reports whether
+// it is ready for translation.
+func (a *App) pipelineDetails(worker *scrapeWorker, id int64, progress *pipelineProgress) bool {
+	p, job, ok, err := a.claimProduct(models.StageDetails, id, a.Repo.GetProductsForDetailScrape)
+	if err != nil {
+		log.Printf("WARN: Failed to claim product ID %d for details: %v", id, err)
+		progress.scrapeFailed.Add(1)
+		return false
+	}
+	if !ok {
+		return false
+	}
+
+	err = worker.scrape(&p, func(s scraper.Scraper, p *models.Product) error {
+		return s.ScrapeProductDetails(p)
+	})
+	if err == nil {
+		if err = a.Repo.UpdateProductDetails(p); err != nil {
+			log.Printf("DB Update failed for %s: %v", p.ProductURL, err)
+		}
+	}
+	if err != nil {
+		progress.scrapeFailed.Add(1)
+		a.failDetails(job, err)
+		return false
+	}
+	progress.scraped.Add(1)
+	a.completeJob(job)
+	return true
+}
+
+// pipelineTranslate translates one product and reports whether it is ready
+// to be published.
+func (a *App) pipelineTranslate(clients []translator.Translator, id int64, progress *pipelineProgress) bool {
+	p, job, ok, err := a.claimProduct(models.StageTranslate, id, a.Repo.GetProductsForTranslation)
+	if err != nil {
+		log.Printf("WARN: Failed to claim product ID %d for translation: %v", id, err)
+		progress.translateFailed.Add(1)
+		return false
+	}
+	if !ok {
+		return false
+	}
+
+	if !a.translateProduct(clients, p, job) {
+		progress.translateFailed.Add(1)
+		return false
+	}
+	progress.translated.Add(1)
+	return true
+}
+
+// pipelinePublish publishes one translated product to wordpress.db.
+func (a *App) pipelinePublish(wpRepo wpdatabase.Repository, id int64, progress *pipelineProgress) {
+	p, job, ok, err := a.claimProduct(models.StagePublish, id, a.Repo.GetCompletedProducts)
+	if err != nil {
+		log.Printf("WARN: Failed to claim product ID %d for publishing: %v", id, err)
+		progress.publishFailed.Add(1)
+		return
+	}
+	if !ok {
+		return
+	}
+
+	outcome, err := a.publishProduct(wpRepo, p)
+	if err != nil {
+		log.Printf("Failed to publish product %s: %v", p.ProductURL, err)
+		progress.publishFailed.Add(1)
+		a.failPublish(job, err)
+		return
+	}
+	if outcome == publishSkipped {
+		progress.publishSkipped.Add(1)
+	} else {
+		progress.published.Add(1)
+	}
+	a.completeJob(job)
+}
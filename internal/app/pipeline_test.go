@@ -0,0 +1,254 @@
+package app
+
+import (
+	"NovelScraper/internal/database"
+	"NovelScraper/internal/models"
+	"NovelScraper/internal/scraper"
+	"NovelScraper/internal/sqldb"
+	"NovelScraper/internal/translator"
+	"NovelScraper/internal/wpdatabase"
+	"NovelScraper/pkg/config"
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newPipelineApp returns a test App with a migrated wordpress.db, one
+// worker per stage and room for a single product between two stages.
+func newPipelineApp(t *testing.T) *App {
+	t.Helper()
+	a := newTestApp(t)
+	wpDSN := filepath.Join(t.TempDir(), "wordpress.db")
+	wpDB, err := wpdatabase.Open(sqldb.Config{Dialect: sqldb.SQLite, DSN: wpDSN, BusyTimeout: 5 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wpDB.Close()
+	migrator, err := wpdatabase.NewMigrator(wpDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(); err != nil {
+		t.Fatalf("failed to migrate wordpress.db: %v", err)
+	}
+	a.Config.Database = config.DatabaseConfig{Backend: "sqlite", WordpressDSN: wpDSN}
+	a.Config.Pipeline = config.PipelineConfig{DetailWorkers: 1, TranslateWorkers: 1, Buffer: 1}
+	a.translators = []translator.Translator{fakeTranslator{reply: func(string) (string, error) { return "ترجمه", nil }}}
+	return a
+}
+
+// testProduct is the listing of the n-th test product.
+func testProduct(n int) models.Product {
+	asin := fmt.Sprintf("B%09d", n)
+	return models.Product{SourceSite: "amazon.ae", ProductURL: "https://www.amazon.ae/dp/" + asin, ASIN: asin, TitleEnglish: "Product " + asin}
+}
+
+// listing returns a list scraper that finds the products from..to-1 and
+// counts them in found.
+func listing(from, to int, found *atomic.Int64) func(scraper.Crawl) error {
+	return func(crawl scraper.Crawl) error {
+		for n := from; n < to; n++ {
+			found.Add(1)
+			if err := crawl.Found([]models.Product{testProduct(n)}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func scrapeDetails(p *models.Product) error {
+	p.TitleEnglish = "Product " + p.ASIN
+	p.DescriptionEnglish = "A product."
+	p.DiscountPrice = 10
+	return nil
+}
+
+// runWorkflow runs the workflow and fails the test if it does not return.
+func runWorkflow(t *testing.T, ctx context.Context, a *App) error {
+	t.Helper()
+	done := make(chan error, 1)
+	go func() { done <- a.RunAutomaticWorkflow(ctx, "amazon.ae") }()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(30 * time.Second):
+		t.Fatal("the workflow deadlocked")
+		return nil
+	}
+}
+
+// statusCounts counts the products in each status.
+func statusCounts(t *testing.T, a *App) map[models.ProductStatus]int {
+	t.Helper()
+	rows, err := a.Repo.(*database.DBRepository).DB.Query("SELECT status, COUNT(*) FROM products GROUP BY status")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	counts := map[models.ProductStatus]int{}
+	for rows.Next() {
+		var status models.ProductStatus
+		var n int
+		if err := rows.Scan(&status, &n); err != nil {
+			t.Fatal(err)
+		}
+		counts[status] = n
+	}
+	return counts
+}
+
+func TestWorkflowTakesBacklog(t *testing.T) {
+	a := newPipelineApp(t)
+	save := func(n int, status models.ProductStatus) {
+		p := testProduct(n)
+		if err := a.Repo.SaveProduct(&p); err != nil {
+			t.Fatal(err)
+		}
+		if status == models.StatusNeedsDetails {
+			return
+		}
+		scrapeDetails(&p)
+		if err := a.Repo.UpdateProductDetails(p); err != nil {
+			t.Fatal(err)
+		}
+		if status == models.StatusCompleted {
+			if err := a.Repo.UpdateProductTranslation(p.ID, "ترجمه", "", status, "translated"); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	// Left by an earlier run: 1 needs details, 2 a translation and 3 and 4
+	// are translated. The crawl finds 4 again, and 5 for the first time.
+	save(1, models.StatusNeedsDetails)
+	save(2, models.StatusNeedsTranslation)
+	save(3, models.StatusCompleted)
+	save(4, models.StatusCompleted)
+
+	var found atomic.Int64
+	useScraper(a, fakeScraper{list: listing(4, 6, &found), details: scrapeDetails})
+	if err := runWorkflow(t, context.Background(), a); err != nil {
+		t.Fatalf("RunAutomaticWorkflow() = %v", err)
+	}
+	if counts := statusCounts(t, a); counts[models.StatusPublished] != 5 {
+		t.Errorf("statuses after the workflow = %v, want all 5 products published", counts)
+	}
+}
+
+func TestWorkflowBackpressure(t *testing.T) {
+	a := newPipelineApp(t)
+	translating := make(chan struct{}, 1)
+	release := make(chan struct{})
+	a.translators = []translator.Translator{fakeTranslator{reply: func(string) (string, error) {
+		select {
+		case translating <- struct{}{}:
+		default:
+		}
+		<-release
+		return "ترجمه", nil
+	}}}
+	var found atomic.Int64
+	useScraper(a, fakeScraper{list: listing(1, 21, &found), details: scrapeDetails})
+
+	done := make(chan error, 1)
+	go func() { done <- runWorkflow(t, context.Background(), a) }()
+
+	// With translation stuck, the crawl can only run ahead by what the
+	// stages and the channels between them hold.
+	<-translating
+	time.Sleep(200 * time.Millisecond)
+	if n := found.Load(); n > 6 {
+		t.Errorf("the crawl found %d products while translation was stuck, want at most 6", n)
+	}
+	close(release)
+
+	if err := <-done; err != nil {
+		t.Fatalf("RunAutomaticWorkflow() = %v", err)
+	}
+	if counts := statusCounts(t, a); counts[models.StatusPublished] != 20 {
+		t.Errorf("statuses after the workflow = %v, want all 20 products published", counts)
+	}
+}
+
+func TestWorkflowCancel(t *testing.T) {
+	a := newPipelineApp(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var translated atomic.Int64
+	a.translators = []translator.Translator{fakeTranslator{reply: func(prompt string) (string, error) {
+		if strings.Contains(prompt, "Title:") && translated.Add(1) == 3 {
+			cancel()
+		}
+		return "ترجمه", nil
+	}}}
+	var found atomic.Int64
+	useScraper(a, fakeScraper{list: listing(1, 1000, &found), details: scrapeDetails})
+
+	if err := runWorkflow(t, ctx, a); err != nil {
+		t.Fatalf("RunAutomaticWorkflow() after cancel = %v, want nil", err)
+	}
+	if n := found.Load(); n >= 999 {
+		t.Errorf("the crawl went on to find %d products after the workflow was cancelled", n)
+	}
+
+	// The products already in the pipeline were finished: none is left
+	// half way with its job leased.
+	var leased int
+	if err := a.Repo.(*database.DBRepository).DB.QueryRow("SELECT COUNT(*) FROM jobs WHERE lease_owner <> ''").Scan(&leased); err != nil {
+		t.Fatal(err)
+	}
+	if leased != 0 {
+		t.Errorf("%d jobs are still leased after the workflow stopped", leased)
+	}
+	counts := statusCounts(t, a)
+	if counts[models.StatusPublished] < 3 || counts[models.StatusNeedsTranslation] != 0 || counts[models.StatusCompleted] != 0 {
+		t.Errorf("statuses after the workflow = %v, want every translated product published", counts)
+	}
+}
+
+func TestWorkflowStageErrors(t *testing.T) {
+	a := newPipelineApp(t)
+	listErr := errors.New("deals page changed")
+	var found atomic.Int64
+	useScraper(a, fakeScraper{
+		list: func(crawl scraper.Crawl) error {
+			if err := listing(1, 11, &found)(crawl); err != nil {
+				return err
+			}
+			return listErr
+		},
+		details: func(p *models.Product) error {
+			if p.ASIN == testProduct(2).ASIN || p.ASIN == testProduct(5).ASIN {
+				return scraper.Fail(scraper.FailureNotFound, "dog page")
+			}
+			return scrapeDetails(p)
+		},
+	})
+	a.translators = []translator.Translator{fakeTranslator{reply: func(prompt string) (string, error) {
+		if strings.Contains(prompt, testProduct(7).TitleEnglish) {
+			return "", errors.New("rate limited")
+		}
+		return "ترجمه", nil
+	}}}
+
+	if err := runWorkflow(t, context.Background(), a); !errors.Is(err, listErr) {
+		t.Fatalf("RunAutomaticWorkflow() = %v, want the list error", err)
+	}
+	counts := statusCounts(t, a)
+	want := map[models.ProductStatus]int{
+		models.StatusPublished:        7,
+		models.StatusDeadLetter:       2,
+		models.StatusNeedsTranslation: 1, // retried after its backoff
+	}
+	for status, n := range want {
+		if counts[status] != n {
+			t.Errorf("statuses after the workflow = %v, want %v", counts, want)
+			break
+		}
+	}
+}
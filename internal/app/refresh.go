@@ -0,0 +1,105 @@
+package app
+
+import (
+	"NovelScraper/internal/models"
+	"NovelScraper/internal/scraper"
+	"NovelScraper/internal/wpdatabase"
+	"fmt"
+	"log"
+	"time"
+)
+
+// RunRefresher re-scrapes the price, offers and availability of products
+// whose refresh interval has passed. Intervals come from the refresh
+// policies in config.yml and depend on the product's status and deal type.
+func (a *App) RunRefresher() error {
+	log.Println("--- Starting Refresh Task ---")
+
+	candidates, err := a.Repo.GetProductsForRefresh()
+	if err != nil {
+		return fmt.Errorf("failed to get products for refresh: %w", err)
+	}
+
+	due := dueForRefresh(candidates, a.Config.Refresh.IntervalFor, time.Now())
+	if len(due) == 0 {
+		log.Println("No products are due for a refresh. Task finished.")
+		return nil
+	}
+	log.Printf("Found %d of %d products due for a refresh.", len(due), len(candidates))
+
+	// Published products are shown on the site, so their listing is
+	// updated as well, and hidden or unpublished when they can no longer
+	// be bought.
+	var wpRepo wpdatabase.Repository
+	for _, p := range due {
+		if p.Status == models.StatusPublished {
+			if wpRepo, err = a.openWordpress(); err != nil {
+				return err
+			}
+			defer wpRepo.Close()
+			break
+		}
+	}
+
+	results := a.scrapeAll(due, func(s scraper.Scraper, p *models.Product) error {
+		return s.RefreshProduct(p)
+	})
+
+	var refreshed, removed, failed int
+	for _, res := range results {
+		p := res.product
+		if res.err != nil {
+			failed++
+			if scraper.Classify(res.err) != scraper.FailureNotFound {
+				continue
+			}
+			log.Printf("Product %s no longer exists, marking it as removed.", p.ProductURL)
+			if err := a.Repo.MarkProductRemoved(p.ID); err != nil {
+				log.Printf("WARN: Failed to mark product ID %d as removed: %v", p.ID, err)
+				continue
+			}
+			removed++
+			if p.Status == models.StatusPublished && p.ASIN != "" {
+				if err := wpRepo.SetAvailability(p.ASIN, models.AvailabilityRemoved); err != nil {
+					log.Printf("WARN: Failed to unpublish %s: %v", p.ASIN, err)
+				}
+			}
+			continue
+		}
+		if err := a.Repo.UpdateProductPrices(p); err != nil {
+			failed++
+			continue
+		}
+		refreshed++
+
+		if p.Status != models.StatusPublished || p.ASIN == "" {
+			continue
+		}
+		if err := wpRepo.UpdatePrices(p.ASIN, p); err != nil {
+			log.Printf("WARN: Failed to update published prices of %s: %v", p.ASIN, err)
+			continue
+		}
+		if err := a.syncPriceHistory(wpRepo, p.SourceSite, p.ASIN); err != nil {
+			log.Printf("WARN: Failed to sync price history for %s: %v", p.ASIN, err)
+		}
+	}
+
+	log.Printf("--- Refresh Task Finished. Refreshed %d products, %d failed (%d removed). ---", refreshed, failed, removed)
+	return nil
+}
+
+// dueForRefresh returns the products whose refresh interval has passed
+// since they were last scraped or refreshed.
+func dueForRefresh(products []models.Product, intervalFor func(status, dealType string) time.Duration, now time.Time) []models.Product {
+	var due []models.Product
+	for _, p := range products {
+		interval := intervalFor(string(p.Status), p.DealType)
+		if interval <= 0 {
+			continue
+		}
+		if now.Sub(p.LastRefreshedAt()) >= interval {
+			due = append(due, p)
+		}
+	}
+	return due
+}
@@ -0,0 +1,26 @@
+package app
+
+import (
+	"NovelScraper/internal/models"
+	"fmt"
+	"log"
+)
+
+// RunRequeue moves the products matching filter back to an earlier stage,
+// e.g. to re-scrape or re-translate them.
+func (a *App) RunRequeue(filter models.RequeueFilter, to models.ProductStatus, reason string) error {
+	log.Printf("--- Requeueing products to '%s' ---", to)
+	if reason == "" {
+		reason = "requeued"
+	}
+
+	moved, skipped, err := a.Repo.RequeueProducts(filter, to, reason)
+	if err != nil {
+		return fmt.Errorf("failed to requeue products: %w", err)
+	}
+	if skipped > 0 {
+		log.Printf("Skipped %d products that cannot move to '%s'.", skipped, to)
+	}
+	log.Printf("--- Requeue Finished. Moved %d products to '%s'. ---", moved, to)
+	return nil
+}
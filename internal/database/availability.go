@@ -0,0 +1,48 @@
+package database
+
+import (
+	"NovelScraper/internal/models"
+	"database/sql"
+	"log"
+)
+
+// backfillAvailabilityState fills the availability_state and stock_count
+// columns added in 019 from the availability text of products scraped
+// before they existed.
+func backfillAvailabilityState(tx *sql.Tx) error {
+	rows, err := tx.Query(`SELECT id, COALESCE(availability, '') FROM products
+		WHERE COALESCE(availability_state, 'unknown') = 'unknown' AND COALESCE(availability, '') != ''`)
+	if err != nil {
+		return err
+	}
+	type row struct {
+		id   int64
+		text string
+	}
+	var all []row
+	for rows.Next() {
+		var r row
+		if err := rows.Scan(&r.id, &r.text); err != nil {
+			rows.Close()
+			return err
+		}
+		all = append(all, r)
+	}
+	rows.Close()
+
+	var updated int
+	for _, r := range all {
+		state, count := models.ParseAvailability(r.text)
+		if state == models.AvailabilityUnknown {
+			continue
+		}
+		if _, err := tx.Exec("UPDATE products SET availability_state = ?, stock_count = ? WHERE id = ?", state, count, r.id); err != nil {
+			return err
+		}
+		updated++
+	}
+	if updated > 0 {
+		log.Printf("Availability migration: normalized the availability of %d products", updated)
+	}
+	return nil
+}
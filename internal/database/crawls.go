@@ -0,0 +1,99 @@
+package database
+
+import (
+	"NovelScraper/internal/models"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+)
+
+// GetCrawlCheckpoint returns the last checkpoint of the crawl of url, and
+// false if the URL has never been crawled.
+func (repo *DBRepository) GetCrawlCheckpoint(url string) (models.CrawlState, bool, error) {
+	state := models.CrawlState{URL: url}
+	var seen, previous string
+	err := repo.DB.QueryRow(`
+		SELECT source_site, iterations, seen, COALESCE(previous_seen, '[]'), done, started_at, updated_at
+		FROM crawl_checkpoints WHERE url = ?`, url).
+		Scan(&state.SourceSite, &state.Iterations, &seen, &previous, &state.Done, &state.StartedAt, &state.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return state, false, nil
+	}
+	if err != nil {
+		return state, false, err
+	}
+
+	if state.Seen, err = decodeKeySet(seen); err != nil {
+		return state, false, err
+	}
+	if state.Previous, err = decodeKeySet(previous); err != nil {
+		return state, false, err
+	}
+	return state, true, nil
+}
+
+// SaveCrawlCheckpoint stores the progress of a crawl, replacing its
+// previous checkpoint.
+func (repo *DBRepository) SaveCrawlCheckpoint(state models.CrawlState) error {
+	seen, err := encodeKeySet(state.Seen)
+	if err != nil {
+		return err
+	}
+	previous, err := encodeKeySet(state.Previous)
+	if err != nil {
+		return err
+	}
+
+	if state.StartedAt.IsZero() {
+		state.StartedAt = time.Now()
+	}
+	_, err = repo.DB.Exec(`
+		INSERT INTO crawl_checkpoints (url, source_site, iterations, seen, previous_seen, done, started_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(url) DO UPDATE SET
+			source_site=excluded.source_site,
+			iterations=excluded.iterations,
+			seen=excluded.seen,
+			previous_seen=excluded.previous_seen,
+			done=excluded.done,
+			started_at=excluded.started_at,
+			updated_at=excluded.updated_at`,
+		state.URL, state.SourceSite, state.Iterations, seen, previous, state.Done, state.StartedAt, time.Now())
+	return err
+}
+
+// encodeKeySet stores a set of product keys as a sorted JSON array.
+func encodeKeySet(set map[string]bool) (string, error) {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	data, err := json.Marshal(keys)
+	return string(data), err
+}
+
+func decodeKeySet(data string) (map[string]bool, error) {
+	var keys []string
+	if err := json.Unmarshal([]byte(data), &keys); err != nil {
+		return nil, err
+	}
+	set := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		set[key] = true
+	}
+	return set, nil
+}
+
+// ProductExists reports whether a product is already stored, matched by
+// ASIN where it has one and by URL otherwise.
+func (repo *DBRepository) ProductExists(product models.Product) (bool, error) {
+	var exists bool
+	err := repo.DB.QueryRow(`
+		SELECT EXISTS(SELECT 1 FROM products
+			WHERE product_url = ? OR (? != '' AND source_site = ? AND asin = ?))`,
+		product.ProductURL, product.ASIN, product.SourceSite, product.ASIN).Scan(&exists)
+	return exists, err
+}
@@ -0,0 +1,143 @@
+package database
+
+import (
+	"NovelScraper/utils"
+	"database/sql"
+	"fmt"
+	"log"
+)
+
+// statusRank orders statuses by how far a product has progressed, so the
+// most complete copy of a duplicated product is the one that is kept.
+var statusRank = map[string]int{
+	"needs_details":      1,
+	"needs_translation":  2,
+	"translation_failed": 2,
+	"completed":          3,
+	"publish_failed":     3,
+	"published":          4,
+}
+
+// mergeDuplicateASINs brings rows saved before ASINs were stored in line with
+// the (source_site, asin) key: it fills the asin column from the URL (the
+// ASIN, or the SKU on Noon), merges rows that are the same product reached
+// through different URLs, and rewrites Amazon URLs to their canonical
+// /dp/{ASIN} form. It runs again as migration 24, as the first run left Noon
+// rows without their SKU.
+func mergeDuplicateASINs(tx *sql.Tx) error {
+	type row struct {
+		id         int64
+		sourceSite string
+		url        string
+		asin       string
+		status     string
+	}
+	rows, err := tx.Query(`SELECT id, COALESCE(source_site, ''), product_url, COALESCE(asin, ''), COALESCE(status, '')
+		FROM products ORDER BY id`)
+	if err != nil {
+		return err
+	}
+	var all []row
+	for rows.Next() {
+		var r row
+		if err := rows.Scan(&r.id, &r.sourceSite, &r.url, &r.asin, &r.status); err != nil {
+			rows.Close()
+			return err
+		}
+		all = append(all, r)
+	}
+	rows.Close()
+
+	// Pick one row per (source_site, asin): the most advanced status, and
+	// the newest row among equals.
+	keepers := make(map[[2]string]row)
+	type loser struct {
+		id  int64
+		key [2]string
+	}
+	var losers []loser
+	for _, r := range all {
+		if r.asin == "" {
+			if r.asin = utils.ProductKey(r.sourceSite, r.url); r.asin == "" {
+				continue
+			}
+		}
+
+		key := [2]string{r.sourceSite, r.asin}
+		kept, ok := keepers[key]
+		if !ok {
+			keepers[key] = r
+			continue
+		}
+		if statusRank[r.status] >= statusRank[kept.status] {
+			keepers[key] = r
+			losers = append(losers, loser{kept.id, key})
+		} else {
+			losers = append(losers, loser{r.id, key})
+		}
+	}
+
+	// Duplicates go first so the kept rows can take over their ASIN and URL
+	// without tripping the unique constraints.
+	for _, l := range losers {
+		if err := mergeProduct(tx, l.id, keepers[l.key].id); err != nil {
+			return err
+		}
+	}
+
+	var updated int
+	for _, r := range keepers {
+		canonical := utils.CanonicalAmazonURL(r.url)
+		res, err := tx.Exec("UPDATE products SET asin = ?, product_url = ? WHERE id = ? AND (COALESCE(asin, '') != ? OR product_url != ?)",
+			r.asin, canonical, r.id, r.asin, canonical)
+		if err != nil {
+			return fmt.Errorf("failed to normalize product %d: %w", r.id, err)
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			updated++
+		}
+	}
+
+	if len(losers)+updated > 0 {
+		log.Printf("ASIN migration: merged %d duplicate rows, normalized %d products", len(losers), updated)
+	}
+	return nil
+}
+
+// mergeProduct removes the duplicate product id, kept as keep. Its price
+// snapshots and status history move to the kept product; its reviews and
+// jobs are dropped, as the kept product has its own. The first run of the
+// migration comes before those tables exist.
+func mergeProduct(tx *sql.Tx, id, keep int64) error {
+	moves := []struct{ table, query string }{
+		{"price_history", "UPDATE price_history SET product_id = ? WHERE product_id = ?"},
+		{"product_events", "UPDATE product_events SET product_id = ? WHERE product_id = ?"},
+	}
+	for _, m := range moves {
+		if exists, err := tableExists(tx, m.table); err != nil {
+			return err
+		} else if exists {
+			if _, err := tx.Exec(m.query, keep, id); err != nil {
+				return fmt.Errorf("failed to move %s of product %d: %w", m.table, id, err)
+			}
+		}
+	}
+	for _, table := range []string{"reviews", "jobs"} {
+		if exists, err := tableExists(tx, table); err != nil {
+			return err
+		} else if exists {
+			if _, err := tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE product_id = ?", table), id); err != nil {
+				return err
+			}
+		}
+	}
+	_, err := tx.Exec("DELETE FROM products WHERE id = ?", id)
+	return err
+}
+
+// tableExists reports whether the SQLite database has the table yet.
+func tableExists(tx *sql.Tx, table string) (bool, error) {
+	var n int
+	err := tx.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?", table).Scan(&n)
+	return n > 0, err
+}
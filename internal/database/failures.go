@@ -0,0 +1,80 @@
+package database
+
+import (
+	"NovelScraper/internal/models"
+	"database/sql"
+	"log"
+	"time"
+)
+
+// RecordFailure stores why the latest detail scrape of a product failed and
+// counts the failed attempt.
+func (repo *DBRepository) RecordFailure(productID int64, reason string, cause error) error {
+	_, err := repo.DB.Exec(`
+		UPDATE products SET
+			failure_reason = ?,
+			failure_count = COALESCE(failure_count, 0) + 1,
+			last_error = ?,
+			failed_at = ?
+		WHERE id = ?`, reason, cause.Error(), time.Now(), productID)
+	return err
+}
+
+// GetFailureSummary groups the products whose last detail scrape failed by
+// the reason it failed, most common first.
+func (repo *DBRepository) GetFailureSummary() ([]models.FailureSummary, error) {
+	rows, err := repo.DB.Query(`
+		SELECT failure_reason, COUNT(*), SUM(CASE WHEN status = ? THEN 1 ELSE 0 END), SUM(failure_count)
+		FROM products
+		WHERE failure_count > 0
+		GROUP BY failure_reason
+		ORDER BY COUNT(*) DESC, failure_reason`, models.StatusDeadLetter)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var summary []models.FailureSummary
+	for rows.Next() {
+		var s models.FailureSummary
+		if err := rows.Scan(&s.Reason, &s.Products, &s.DeadLetter, &s.Attempts); err != nil {
+			return nil, err
+		}
+		summary = append(summary, s)
+	}
+	return summary, rows.Err()
+}
+
+// GetFailedProducts returns the products whose last detail scrape failed,
+// optionally only those in the given status, most recent failure first.
+func (repo *DBRepository) GetFailedProducts(status models.ProductStatus) ([]models.FailedProduct, error) {
+	query := `
+		SELECT id, product_url, status, failure_reason, failure_count, last_error, failed_at
+		FROM products
+		WHERE failure_count > 0`
+	var args []interface{}
+	if status != "" {
+		query += " AND status = ?"
+		args = append(args, status)
+	}
+	query += " ORDER BY failure_reason, failed_at DESC"
+
+	rows, err := repo.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var products []models.FailedProduct
+	for rows.Next() {
+		var p models.FailedProduct
+		var failedAt sql.NullTime
+		if err := rows.Scan(&p.ID, &p.ProductURL, &p.Status, &p.Reason, &p.Attempts, &p.LastError, &failedAt); err != nil {
+			log.Printf("Error scanning failed product row: %v", err)
+			continue
+		}
+		p.FailedAt = failedAt.Time
+		products = append(products, p)
+	}
+	return products, nil
+}
@@ -0,0 +1,159 @@
+package database
+
+import (
+	"NovelScraper/internal/models"
+	"NovelScraper/internal/sqldb"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrLeaseLost is returned when a job is finished by a worker whose lease
+// has expired and been taken over by another worker.
+var ErrLeaseLost = errors.New("job lease was lost")
+
+// EnqueueJobs creates a job for every product in the given status that does
+// not already have one for the stage, and returns how many were created.
+func (repo *DBRepository) EnqueueJobs(stage string, status models.ProductStatus) (int64, error) {
+	now := time.Now().UTC()
+	res, err := repo.DB.Exec(`
+		INSERT INTO jobs (stage, product_id, next_run_at, created_at, updated_at)
+		SELECT ?, id, ?, ?, ? FROM products WHERE status = ?
+		ON CONFLICT(stage, product_id) DO NOTHING`,
+		stage, now, now, now, status)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// ClaimJobs leases up to limit due jobs of a stage to owner. The claim is a
+// single UPDATE, so concurrent workers never receive the same job.
+func (repo *DBRepository) ClaimJobs(stage, owner string, limit int, lease time.Duration) ([]models.Job, error) {
+	// On PostgreSQL concurrent claims skip the jobs another worker is
+	// claiming instead of waiting for them and then finding them leased.
+	locking := ""
+	if repo.DB.Dialect == sqldb.Postgres {
+		locking = " FOR UPDATE SKIP LOCKED"
+	}
+	now := time.Now().UTC()
+	rows, err := repo.DB.Query(`
+		UPDATE jobs SET lease_owner = ?, lease_expires_at = ?, attempts = attempts + 1, updated_at = ?
+		WHERE id IN (
+			SELECT id FROM jobs
+			WHERE stage = ? AND next_run_at <= ? AND (lease_expires_at IS NULL OR lease_expires_at <= ?)
+			ORDER BY next_run_at, id
+			LIMIT ?`+locking+`)
+		RETURNING id, product_id, attempts, next_run_at, last_error`,
+		owner, now.Add(lease), now, stage, now, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []models.Job
+	for rows.Next() {
+		job := models.Job{Stage: stage, LeaseOwner: owner, LeaseExpiresAt: now.Add(lease)}
+		if err := rows.Scan(&job.ID, &job.ProductID, &job.Attempts, &job.NextRunAt, &job.LastError); err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
+
+// ClaimProductJob leases the job of one product for a stage to owner,
+// creating the job if the product has none. It returns false when the job
+// is leased by another worker or is waiting out a backoff.
+func (repo *DBRepository) ClaimProductJob(stage string, productID int64, owner string, lease time.Duration) (models.Job, bool, error) {
+	now := time.Now().UTC()
+	if _, err := repo.DB.Exec(`
+		INSERT INTO jobs (stage, product_id, next_run_at, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(stage, product_id) DO NOTHING`,
+		stage, productID, now, now, now); err != nil {
+		return models.Job{}, false, err
+	}
+
+	job := models.Job{Stage: stage, ProductID: productID, LeaseOwner: owner, LeaseExpiresAt: now.Add(lease)}
+	err := repo.DB.QueryRow(`
+		UPDATE jobs SET lease_owner = ?, lease_expires_at = ?, attempts = attempts + 1, updated_at = ?
+		WHERE stage = ? AND product_id = ? AND next_run_at <= ? AND (lease_expires_at IS NULL OR lease_expires_at <= ?)
+		RETURNING id, attempts, next_run_at, last_error`,
+		owner, now.Add(lease), now, stage, productID, now, now).
+		Scan(&job.ID, &job.Attempts, &job.NextRunAt, &job.LastError)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Job{}, false, nil
+	}
+	if err != nil {
+		return models.Job{}, false, err
+	}
+	return job, true, nil
+}
+
+// RenewJob extends the lease of a job its owner is about to work on, so a
+// job claimed with a batch is not taken over while it waits its turn.
+func (repo *DBRepository) RenewJob(job models.Job, lease time.Duration) (models.Job, error) {
+	now := time.Now().UTC()
+	res, err := repo.DB.Exec("UPDATE jobs SET lease_expires_at = ?, updated_at = ? WHERE id = ? AND lease_owner = ?",
+		now.Add(lease), now, job.ID, job.LeaseOwner)
+	if err != nil {
+		return job, err
+	}
+	if err := checkLease(res.RowsAffected()); err != nil {
+		return job, err
+	}
+	job.LeaseExpiresAt = now.Add(lease)
+	return job, nil
+}
+
+// CompleteJob removes a finished job from the queue.
+func (repo *DBRepository) CompleteJob(job models.Job) error {
+	res, err := repo.DB.Exec("DELETE FROM jobs WHERE id = ? AND lease_owner = ?", job.ID, job.LeaseOwner)
+	if err != nil {
+		return err
+	}
+	return checkLease(res.RowsAffected())
+}
+
+// FailJob releases a job after a failed attempt and schedules the next
+// attempt after backoff.
+func (repo *DBRepository) FailJob(job models.Job, cause error, backoff time.Duration) error {
+	now := time.Now().UTC()
+	res, err := repo.DB.Exec(`
+		UPDATE jobs SET lease_owner = '', lease_expires_at = NULL, next_run_at = ?, last_error = ?, updated_at = ?
+		WHERE id = ? AND lease_owner = ?`,
+		now.Add(backoff), cause.Error(), now, job.ID, job.LeaseOwner)
+	if err != nil {
+		return err
+	}
+	return checkLease(res.RowsAffected())
+}
+
+func checkLease(affected int64, err error) error {
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+// idFilter returns an " AND id IN (...)" condition limiting a query to ids,
+// or nothing when ids is nil.
+func idFilter(ids []int64) (string, []interface{}) {
+	if ids == nil {
+		return "", nil
+	}
+	if len(ids) == 0 {
+		return " AND 1 = 0", nil
+	}
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	return fmt.Sprintf(" AND id IN (%s)", strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ")), args
+}
@@ -0,0 +1,85 @@
+package database
+
+import (
+	"NovelScraper/internal/models"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestJobQueue(t *testing.T) {
+	runRepositoryTest(t, func(t *testing.T, repo *DBRepository) {
+		for _, url := range []string{"https://a", "https://b", "https://c"} {
+			saveTestProduct(t, repo, models.Product{SourceSite: "amazon.ae", ProductURL: url})
+		}
+		for i, want := range []int64{3, 0} {
+			if n, err := repo.EnqueueJobs(models.StageDetails, models.StatusNeedsDetails); err != nil || n != want {
+				t.Errorf("EnqueueJobs() #%d = %d, %v; want %d", i+1, n, err, want)
+			}
+		}
+
+		first, err := repo.ClaimJobs(models.StageDetails, "w1", 2, time.Hour)
+		if err != nil || len(first) != 2 {
+			t.Fatalf("ClaimJobs(w1) = %d, %v; want 2", len(first), err)
+		}
+		second, err := repo.ClaimJobs(models.StageDetails, "w2", 2, time.Hour)
+		if err != nil || len(second) != 1 || second[0].ProductID == first[0].ProductID || second[0].ProductID == first[1].ProductID {
+			t.Fatalf("ClaimJobs(w2) = %+v, %v; want the remaining job", second, err)
+		}
+
+		if err := repo.CompleteJob(models.Job{ID: first[0].ID, LeaseOwner: "w2"}); !errors.Is(err, ErrLeaseLost) {
+			t.Errorf("CompleteJob() by another owner = %v, want ErrLeaseLost", err)
+		}
+		if err := repo.CompleteJob(first[0]); err != nil {
+			t.Errorf("CompleteJob() error: %v", err)
+		}
+		if err := repo.FailJob(first[1], errors.New("boom"), time.Hour); err != nil {
+			t.Errorf("FailJob() error: %v", err)
+		}
+
+		// The failed job waits out its backoff; the completed one is gone
+		// and can be claimed again as a new job.
+		if _, ok, err := repo.ClaimProductJob(models.StageDetails, first[1].ProductID, "w3", time.Hour); err != nil || ok {
+			t.Errorf("ClaimProductJob() during backoff = %v, %v; want false", ok, err)
+		}
+		job, ok, err := repo.ClaimProductJob(models.StageDetails, first[0].ProductID, "w3", time.Hour)
+		if err != nil || !ok || job.Attempts != 1 {
+			t.Errorf("ClaimProductJob() = %+v, %v, %v; want a new job", job, ok, err)
+		}
+	})
+}
+
+func TestRenewJob(t *testing.T) {
+	runRepositoryTest(t, func(t *testing.T, repo *DBRepository) {
+		for _, url := range []string{"https://a", "https://b"} {
+			saveTestProduct(t, repo, models.Product{SourceSite: "amazon.ae", ProductURL: url})
+		}
+		if _, err := repo.EnqueueJobs(models.StageDetails, models.StatusNeedsDetails); err != nil {
+			t.Fatal(err)
+		}
+
+		// w1 claims both jobs under a lease that runs out before it gets to
+		// the second one.
+		jobs, err := repo.ClaimJobs(models.StageDetails, "w1", 2, time.Millisecond)
+		if err != nil || len(jobs) != 2 {
+			t.Fatalf("ClaimJobs(w1) = %d, %v; want 2", len(jobs), err)
+		}
+		time.Sleep(10 * time.Millisecond)
+		renewed, err := repo.RenewJob(jobs[0], time.Hour)
+		if err != nil || !renewed.LeaseExpiresAt.After(time.Now().Add(time.Minute)) {
+			t.Fatalf("RenewJob() = %+v, %v; want the lease extended", renewed, err)
+		}
+
+		// The renewed job stays with w1; the expired one is taken over.
+		taken, err := repo.ClaimJobs(models.StageDetails, "w2", 2, time.Hour)
+		if err != nil || len(taken) != 1 || taken[0].ID != jobs[1].ID || taken[0].Attempts != 2 {
+			t.Fatalf("ClaimJobs(w2) = %+v, %v; want only the expired job", taken, err)
+		}
+		if _, err := repo.RenewJob(jobs[1], time.Hour); !errors.Is(err, ErrLeaseLost) {
+			t.Errorf("RenewJob() of a job taken over = %v, want ErrLeaseLost", err)
+		}
+		if err := repo.CompleteJob(renewed); err != nil {
+			t.Errorf("CompleteJob() of the renewed job: %v", err)
+		}
+	})
+}
@@ -0,0 +1,35 @@
+package database
+
+import (
+	"NovelScraper/internal/migrate"
+	"NovelScraper/internal/sqldb"
+	"embed"
+)
+
+//go:embed migrations/*.sql migrations/postgres/*.sql
+var migrationFiles embed.FS
+
+// goMigrations are the schema changes that need Go code, numbered in the
+// same sequence as the SQL files in migrations/.
+var goMigrations = []migrate.Migration{
+	{Version: 12, Name: "merge_duplicate_asins", Up: mergeDuplicateASINs},
+	{Version: 20, Name: "backfill_availability_state", Up: backfillAvailabilityState},
+	{Version: 24, Name: "backfill_noon_skus", Up: mergeDuplicateASINs},
+	{Version: 25, Name: "normalize_price_times", Up: normalizePriceTimes},
+	{Version: 26, Name: "normalize_product_times", Up: normalizeProductTimes},
+}
+
+// NewMigrator returns the migrator for a products database. PostgreSQL
+// databases start from the current schema in migrations/postgres/, so the
+// SQLite history and its Go migrations do not apply to them.
+func NewMigrator(db *sqldb.DB) (*migrate.Migrator, error) {
+	dir, extra := "migrations", goMigrations
+	if db.Dialect == sqldb.Postgres {
+		dir, extra = "migrations/postgres", nil
+	}
+	migrations, err := migrate.Load(migrationFiles, dir)
+	if err != nil {
+		return nil, err
+	}
+	return migrate.New(db, append(migrations, extra...))
+}
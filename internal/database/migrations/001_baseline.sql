@@ -0,0 +1,30 @@
+-- Schema of the first release. Later columns are added by the migrations
+-- that follow, so databases created by any version end up the same.
+CREATE TABLE IF NOT EXISTS products (
+	"id" INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+	"source_site" TEXT,
+	"product_url" TEXT UNIQUE,
+	"title_english" TEXT,
+	"title_farsi" TEXT,
+	"description_english" TEXT,
+	"description_farsi" TEXT,
+	"brand" TEXT,
+	"availability" TEXT,
+	"original_price" REAL,
+	"discount_price" REAL,
+	"discount_percent" INTEGER,
+	"main_image_url" TEXT,
+	"gallery_image_urls" TEXT,
+	"specifications" TEXT,
+	"country_of_origin" TEXT,
+	"scraped_at" DATETIME,
+	"posted_to_wp" BOOLEAN DEFAULT 0,
+	"wp_post_id" INTEGER
+);
+
+CREATE TABLE IF NOT EXISTS categories (
+	"id" INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+	"name" TEXT,
+	"node" TEXT UNIQUE,
+	"source_site" TEXT
+);
@@ -0,0 +1,5 @@
+ALTER TABLE products ADD COLUMN "category" TEXT;
+ALTER TABLE products ADD COLUMN "status" TEXT DEFAULT 'new';
+
+-- Rows from before the pipeline stages existed still need their details.
+UPDATE products SET status = 'needs_details' WHERE status IS NULL OR status = 'new';
@@ -0,0 +1,2 @@
+-- Specifications as ordered key/value JSON; "specifications" keeps the rendered HTML.
+ALTER TABLE products ADD COLUMN "specs" TEXT;
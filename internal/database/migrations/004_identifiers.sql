@@ -0,0 +1,8 @@
+ALTER TABLE products ADD COLUMN "model_number" TEXT DEFAULT '';
+ALTER TABLE products ADD COLUMN "manufacturer" TEXT DEFAULT '';
+ALTER TABLE products ADD COLUMN "ean" TEXT DEFAULT '';
+ALTER TABLE products ADD COLUMN "upc" TEXT DEFAULT '';
+ALTER TABLE products ADD COLUMN "length_cm" REAL DEFAULT 0;
+ALTER TABLE products ADD COLUMN "width_cm" REAL DEFAULT 0;
+ALTER TABLE products ADD COLUMN "height_cm" REAL DEFAULT 0;
+ALTER TABLE products ADD COLUMN "weight_g" REAL DEFAULT 0;
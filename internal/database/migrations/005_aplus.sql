@@ -0,0 +1,2 @@
+ALTER TABLE products ADD COLUMN "aplus_html" TEXT DEFAULT '';
+ALTER TABLE products ADD COLUMN "aplus_farsi" TEXT DEFAULT '';
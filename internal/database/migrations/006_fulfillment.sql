@@ -0,0 +1,7 @@
+ALTER TABLE products ADD COLUMN "seller_name" TEXT DEFAULT '';
+ALTER TABLE products ADD COLUMN "seller_id" TEXT DEFAULT '';
+ALTER TABLE products ADD COLUMN "ships_from" TEXT DEFAULT '';
+ALTER TABLE products ADD COLUMN "sold_by_amazon" BOOLEAN DEFAULT 0;
+ALTER TABLE products ADD COLUMN "fulfilled_by_amazon" BOOLEAN DEFAULT 0;
+ALTER TABLE products ADD COLUMN "prime_eligible" BOOLEAN DEFAULT 0;
+ALTER TABLE products ADD COLUMN "delivery_estimate" TEXT DEFAULT '';
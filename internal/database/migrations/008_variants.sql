@@ -0,0 +1,17 @@
+ALTER TABLE products ADD COLUMN "parent_asin" TEXT DEFAULT '';
+ALTER TABLE products ADD COLUMN "variation_dimensions" TEXT;
+
+-- Child variations of products with a parent ASIN, shared by every
+-- product row that points at the same parent.
+CREATE TABLE IF NOT EXISTS product_variants (
+	"id" INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+	"source_site" TEXT NOT NULL,
+	"parent_asin" TEXT NOT NULL,
+	"asin" TEXT NOT NULL,
+	"attributes" TEXT,
+	"price" REAL DEFAULT 0,
+	"availability" TEXT DEFAULT '',
+	"url" TEXT DEFAULT '',
+	"updated_at" DATETIME,
+	UNIQUE(source_site, parent_asin, asin)
+);
@@ -0,0 +1,18 @@
+ALTER TABLE products ADD COLUMN "rating" REAL DEFAULT 0;
+ALTER TABLE products ADD COLUMN "rating_count" INTEGER DEFAULT 0;
+ALTER TABLE products ADD COLUMN "rating_histogram" TEXT;
+
+-- The top reviews from the product page, replaced on every detail scrape.
+CREATE TABLE IF NOT EXISTS reviews (
+	"id" INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+	"product_id" INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+	"review_id" TEXT DEFAULT '',
+	"author" TEXT DEFAULT '',
+	"stars" REAL DEFAULT 0,
+	"title" TEXT DEFAULT '',
+	"body" TEXT DEFAULT '',
+	"reviewed_at" DATETIME,
+	"verified" BOOLEAN DEFAULT 0,
+	"helpful_votes" INTEGER DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS idx_reviews_product_id ON reviews(product_id);
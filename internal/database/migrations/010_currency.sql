@@ -0,0 +1,3 @@
+-- ISO 4217 code of all prices of the row; empty for rows scraped before
+-- prices carried a currency, which use their site's currency.
+ALTER TABLE products ADD COLUMN "currency" TEXT DEFAULT '';
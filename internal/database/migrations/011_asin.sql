@@ -0,0 +1 @@
+ALTER TABLE products ADD COLUMN "asin" TEXT DEFAULT '';
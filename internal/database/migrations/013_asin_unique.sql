@@ -0,0 +1,3 @@
+-- Products are keyed by (source_site, asin); 012 merged the rows that
+-- would violate it.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_products_site_asin ON products(source_site, asin) WHERE asin != '';
@@ -0,0 +1,22 @@
+-- Every price/availability observation, appended on each scrape, keyed by
+-- (source_site, asin) so history survives product rows being merged.
+CREATE TABLE IF NOT EXISTS price_history (
+	"id" INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+	"product_id" INTEGER,
+	"source_site" TEXT NOT NULL,
+	"asin" TEXT NOT NULL,
+	"price" REAL DEFAULT 0,
+	"original_price" REAL DEFAULT 0,
+	"effective_price" REAL DEFAULT 0,
+	"currency" TEXT DEFAULT '',
+	"availability" TEXT DEFAULT '',
+	"observed_at" DATETIME NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_price_history_asin ON price_history(source_site, asin, observed_at);
+
+-- Seed the history with the prices already stored on the products.
+INSERT INTO price_history (product_id, source_site, asin, price, original_price, effective_price, currency, availability, observed_at)
+SELECT id, COALESCE(source_site, ''), asin, COALESCE(discount_price, 0), COALESCE(original_price, 0),
+	COALESCE(effective_price, 0), currency, COALESCE(availability, ''), COALESCE(scraped_at, CURRENT_TIMESTAMP)
+FROM products
+WHERE asin != '' AND COALESCE(discount_price, 0) > 0;
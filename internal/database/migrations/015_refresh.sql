@@ -0,0 +1,2 @@
+ALTER TABLE products ADD COLUMN "deal_type" TEXT DEFAULT '';
+ALTER TABLE products ADD COLUMN "refreshed_at" DATETIME;
@@ -0,0 +1,18 @@
+-- Work queue shared by the pipeline stages. A job is one product waiting for
+-- one stage; it is deleted when the stage finishes with the product. Workers
+-- claim jobs by taking a lease, so an expired lease (a crashed worker) makes
+-- the job claimable again. Times are stored in UTC.
+CREATE TABLE IF NOT EXISTS jobs (
+	"id" INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+	"stage" TEXT NOT NULL,
+	"product_id" INTEGER NOT NULL,
+	"lease_owner" TEXT DEFAULT '',
+	"lease_expires_at" DATETIME,
+	"attempts" INTEGER DEFAULT 0,
+	"next_run_at" DATETIME NOT NULL,
+	"last_error" TEXT DEFAULT '',
+	"created_at" DATETIME NOT NULL,
+	"updated_at" DATETIME NOT NULL,
+	UNIQUE(stage, product_id)
+);
+CREATE INDEX IF NOT EXISTS idx_jobs_claim ON jobs(stage, next_run_at);
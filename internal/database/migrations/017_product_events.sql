@@ -0,0 +1,11 @@
+-- Audit log of product status changes.
+CREATE TABLE IF NOT EXISTS product_events (
+	"id" INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+	"product_id" INTEGER NOT NULL,
+	"from_status" TEXT NOT NULL,
+	"to_status" TEXT NOT NULL,
+	"reason" TEXT DEFAULT '',
+	"run_id" TEXT DEFAULT '',
+	"created_at" DATETIME NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_product_events_product_id ON product_events(product_id);
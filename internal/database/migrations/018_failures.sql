@@ -0,0 +1,5 @@
+-- The last detail-scrape failure of a product, cleared when a scrape succeeds.
+ALTER TABLE products ADD COLUMN "failure_reason" TEXT DEFAULT '';
+ALTER TABLE products ADD COLUMN "failure_count" INTEGER DEFAULT 0;
+ALTER TABLE products ADD COLUMN "last_error" TEXT DEFAULT '';
+ALTER TABLE products ADD COLUMN "failed_at" DATETIME;
@@ -0,0 +1,3 @@
+-- Normalized availability (see models.AvailabilityState) next to the raw text.
+ALTER TABLE products ADD COLUMN "availability_state" TEXT DEFAULT 'unknown';
+ALTER TABLE products ADD COLUMN "stock_count" INTEGER DEFAULT 0;
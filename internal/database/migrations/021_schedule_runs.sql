@@ -0,0 +1,13 @@
+-- Run history of the daemon's schedules, one row per schedule. A running
+-- schedule holds a lock so it never runs twice at once, also across
+-- processes; an expired lock (a crashed daemon) can be taken over. Times are
+-- stored in UTC.
+CREATE TABLE IF NOT EXISTS schedule_runs (
+	"name" TEXT NOT NULL PRIMARY KEY,
+	"last_started_at" DATETIME,
+	"last_finished_at" DATETIME,
+	"last_error" TEXT DEFAULT '',
+	"runs" INTEGER DEFAULT 0,
+	"lock_owner" TEXT DEFAULT '',
+	"lock_expires_at" DATETIME
+);
@@ -0,0 +1,12 @@
+-- Progress of product list crawls, one row per listing URL. An unfinished
+-- crawl (done = 0) is resumed by the next crawl of the same URL; "seen" is a
+-- JSON array of the product keys already saved.
+CREATE TABLE IF NOT EXISTS crawl_checkpoints (
+	"url" TEXT NOT NULL PRIMARY KEY,
+	"source_site" TEXT DEFAULT '',
+	"iterations" INTEGER DEFAULT 0,
+	"seen" TEXT DEFAULT '[]',
+	"done" BOOLEAN DEFAULT 0,
+	"started_at" DATETIME NOT NULL,
+	"updated_at" DATETIME NOT NULL
+);
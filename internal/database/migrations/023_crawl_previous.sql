@@ -0,0 +1,3 @@
+-- Product keys found by the previous complete crawl of the same URL, to tell
+-- returning and vanished deals apart from new ones.
+ALTER TABLE crawl_checkpoints ADD COLUMN "previous_seen" TEXT DEFAULT '[]';
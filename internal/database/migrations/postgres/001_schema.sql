@@ -0,0 +1,158 @@
+-- Schema of products.db for PostgreSQL, matching the SQLite schema after
+-- migration 023. Later changes are added as migrations that follow, numbered
+-- on their own.
+CREATE TABLE IF NOT EXISTS products (
+	"id" BIGSERIAL PRIMARY KEY,
+	"source_site" TEXT,
+	"product_url" TEXT UNIQUE,
+	"category" TEXT,
+	"status" TEXT DEFAULT 'new',
+	"title_english" TEXT,
+	"title_farsi" TEXT,
+	"description_english" TEXT,
+	"description_farsi" TEXT,
+	"brand" TEXT,
+	"availability" TEXT,
+	"original_price" DOUBLE PRECISION,
+	"discount_price" DOUBLE PRECISION,
+	"discount_percent" INTEGER,
+	"main_image_url" TEXT,
+	"gallery_image_urls" TEXT,
+	"specifications" TEXT,
+	"country_of_origin" TEXT,
+	"scraped_at" TIMESTAMPTZ,
+	"posted_to_wp" BOOLEAN DEFAULT FALSE,
+	"wp_post_id" BIGINT,
+	"specs" TEXT,
+	"model_number" TEXT DEFAULT '',
+	"manufacturer" TEXT DEFAULT '',
+	"ean" TEXT DEFAULT '',
+	"upc" TEXT DEFAULT '',
+	"length_cm" DOUBLE PRECISION DEFAULT 0,
+	"width_cm" DOUBLE PRECISION DEFAULT 0,
+	"height_cm" DOUBLE PRECISION DEFAULT 0,
+	"weight_g" DOUBLE PRECISION DEFAULT 0,
+	"aplus_html" TEXT DEFAULT '',
+	"aplus_farsi" TEXT DEFAULT '',
+	"seller_name" TEXT DEFAULT '',
+	"seller_id" TEXT DEFAULT '',
+	"ships_from" TEXT DEFAULT '',
+	"sold_by_amazon" BOOLEAN DEFAULT FALSE,
+	"fulfilled_by_amazon" BOOLEAN DEFAULT FALSE,
+	"prime_eligible" BOOLEAN DEFAULT FALSE,
+	"delivery_estimate" TEXT DEFAULT '',
+	"offers" TEXT,
+	"effective_price" DOUBLE PRECISION DEFAULT 0,
+	"effective_discount_percent" INTEGER DEFAULT 0,
+	"parent_asin" TEXT DEFAULT '',
+	"variation_dimensions" TEXT,
+	"rating" DOUBLE PRECISION DEFAULT 0,
+	"rating_count" INTEGER DEFAULT 0,
+	"rating_histogram" TEXT,
+	"currency" TEXT DEFAULT '',
+	"asin" TEXT DEFAULT '',
+	"deal_type" TEXT DEFAULT '',
+	"refreshed_at" TIMESTAMPTZ,
+	"failure_reason" TEXT DEFAULT '',
+	"failure_count" INTEGER DEFAULT 0,
+	"last_error" TEXT DEFAULT '',
+	"failed_at" TIMESTAMPTZ,
+	"availability_state" TEXT DEFAULT 'unknown',
+	"stock_count" INTEGER DEFAULT 0
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_products_site_asin ON products(source_site, asin) WHERE asin != '';
+
+CREATE TABLE IF NOT EXISTS categories (
+	"id" BIGSERIAL PRIMARY KEY,
+	"name" TEXT,
+	"node" TEXT UNIQUE,
+	"source_site" TEXT
+);
+
+CREATE TABLE IF NOT EXISTS product_variants (
+	"id" BIGSERIAL PRIMARY KEY,
+	"source_site" TEXT NOT NULL,
+	"parent_asin" TEXT NOT NULL,
+	"asin" TEXT NOT NULL,
+	"attributes" TEXT,
+	"price" DOUBLE PRECISION DEFAULT 0,
+	"availability" TEXT DEFAULT '',
+	"url" TEXT DEFAULT '',
+	"updated_at" TIMESTAMPTZ,
+	UNIQUE(source_site, parent_asin, asin)
+);
+
+CREATE TABLE IF NOT EXISTS reviews (
+	"id" BIGSERIAL PRIMARY KEY,
+	"product_id" BIGINT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+	"review_id" TEXT DEFAULT '',
+	"author" TEXT DEFAULT '',
+	"stars" DOUBLE PRECISION DEFAULT 0,
+	"title" TEXT DEFAULT '',
+	"body" TEXT DEFAULT '',
+	"reviewed_at" TIMESTAMPTZ,
+	"verified" BOOLEAN DEFAULT FALSE,
+	"helpful_votes" INTEGER DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS idx_reviews_product_id ON reviews(product_id);
+
+CREATE TABLE IF NOT EXISTS price_history (
+	"id" BIGSERIAL PRIMARY KEY,
+	"product_id" BIGINT,
+	"source_site" TEXT NOT NULL,
+	"asin" TEXT NOT NULL,
+	"price" DOUBLE PRECISION DEFAULT 0,
+	"original_price" DOUBLE PRECISION DEFAULT 0,
+	"effective_price" DOUBLE PRECISION DEFAULT 0,
+	"currency" TEXT DEFAULT '',
+	"availability" TEXT DEFAULT '',
+	"observed_at" TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_price_history_asin ON price_history(source_site, asin, observed_at);
+
+CREATE TABLE IF NOT EXISTS jobs (
+	"id" BIGSERIAL PRIMARY KEY,
+	"stage" TEXT NOT NULL,
+	"product_id" BIGINT NOT NULL,
+	"lease_owner" TEXT DEFAULT '',
+	"lease_expires_at" TIMESTAMPTZ,
+	"attempts" INTEGER DEFAULT 0,
+	"next_run_at" TIMESTAMPTZ NOT NULL,
+	"last_error" TEXT DEFAULT '',
+	"created_at" TIMESTAMPTZ NOT NULL,
+	"updated_at" TIMESTAMPTZ NOT NULL,
+	UNIQUE(stage, product_id)
+);
+CREATE INDEX IF NOT EXISTS idx_jobs_claim ON jobs(stage, next_run_at);
+
+CREATE TABLE IF NOT EXISTS product_events (
+	"id" BIGSERIAL PRIMARY KEY,
+	"product_id" BIGINT NOT NULL,
+	"from_status" TEXT NOT NULL,
+	"to_status" TEXT NOT NULL,
+	"reason" TEXT DEFAULT '',
+	"run_id" TEXT DEFAULT '',
+	"created_at" TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_product_events_product_id ON product_events(product_id);
+
+CREATE TABLE IF NOT EXISTS schedule_runs (
+	"name" TEXT NOT NULL PRIMARY KEY,
+	"last_started_at" TIMESTAMPTZ,
+	"last_finished_at" TIMESTAMPTZ,
+	"last_error" TEXT DEFAULT '',
+	"runs" INTEGER DEFAULT 0,
+	"lock_owner" TEXT DEFAULT '',
+	"lock_expires_at" TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS crawl_checkpoints (
+	"url" TEXT NOT NULL PRIMARY KEY,
+	"source_site" TEXT DEFAULT '',
+	"iterations" INTEGER DEFAULT 0,
+	"seen" TEXT DEFAULT '[]',
+	"previous_seen" TEXT DEFAULT '[]',
+	"done" BOOLEAN DEFAULT FALSE,
+	"started_at" TIMESTAMPTZ NOT NULL,
+	"updated_at" TIMESTAMPTZ NOT NULL
+);
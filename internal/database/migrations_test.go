@@ -0,0 +1,208 @@
+package database
+
+import (
+	"NovelScraper/internal/models"
+	"NovelScraper/internal/sqldb"
+	"NovelScraper/internal/sqldb/sqldbtest"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestBackfillNoonSKUs(t *testing.T) {
+	sqldbtest.Run(t, NewMigrator, func(t *testing.T, db *sqldb.DB) {
+		if db.Dialect != sqldb.SQLite {
+			t.Skip("the Go migrations only apply to SQLite")
+		}
+		// Rows saved before Noon SKUs were stored, two of them the same item.
+		for _, row := range []struct{ url, status string }{
+			{"https://www.noon.com/uae-en/kettle/N53346840A/p/", "needs_details"},
+			{"https://www.noon.com/uae-en/kettle-steel/N53346840A/p/", "completed"},
+			{"https://www.noon.com/uae-en/lamp/N70012345V/p/", "needs_details"},
+		} {
+			if _, err := db.Exec("INSERT INTO products (source_site, product_url, asin, status) VALUES ('noon.com', ?, '', ?)", row.url, row.status); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		tx, err := db.DB.Begin()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer tx.Rollback()
+		if err := mergeDuplicateASINs(tx); err != nil {
+			t.Fatalf("mergeDuplicateASINs() error: %v", err)
+		}
+		if err := tx.Commit(); err != nil {
+			t.Fatal(err)
+		}
+
+		rows, err := db.Query("SELECT asin, status FROM products ORDER BY asin")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rows.Close()
+		var got []string
+		for rows.Next() {
+			var asin, status string
+			if err := rows.Scan(&asin, &status); err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, asin+":"+status)
+		}
+		want := []string{"N53346840A:completed", "N70012345V:needs_details"}
+		if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+			t.Errorf("products = %v; want %v", got, want)
+		}
+	})
+}
+
+func TestMergeDuplicatesKeepsHistory(t *testing.T) {
+	sqldbtest.Run(t, NewMigrator, func(t *testing.T, db *sqldb.DB) {
+		if db.Dialect != sqldb.SQLite {
+			t.Skip("the Go migrations only apply to SQLite")
+		}
+		// The same Noon item saved twice before its SKU was stored; the
+		// completed copy is kept.
+		for _, row := range []struct{ url, status string }{
+			{"https://www.noon.com/uae-en/kettle/N53346840A/p/", "needs_details"},
+			{"https://www.noon.com/uae-en/kettle-steel/N53346840A/p/", "completed"},
+		} {
+			if _, err := db.Exec("INSERT INTO products (source_site, product_url, asin, status) VALUES ('noon.com', ?, '', ?)", row.url, row.status); err != nil {
+				t.Fatal(err)
+			}
+		}
+		now := sqldb.Timestamp(time.Now())
+		for _, query := range []string{
+			"INSERT INTO price_history (product_id, source_site, asin, price, observed_at) VALUES (1, 'noon.com', 'N53346840A', 10, ?)",
+			"INSERT INTO product_events (product_id, from_status, to_status, created_at) VALUES (1, '', 'needs_details', ?)",
+			"INSERT INTO jobs (stage, product_id, next_run_at, created_at, updated_at) VALUES ('details', 1, ?1, ?1, ?1)",
+		} {
+			if _, err := db.Exec(query, now); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		tx, err := db.DB.Begin()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer tx.Rollback()
+		if err := mergeDuplicateASINs(tx); err != nil {
+			t.Fatalf("mergeDuplicateASINs() error: %v", err)
+		}
+		if err := tx.Commit(); err != nil {
+			t.Fatal(err)
+		}
+
+		for query, want := range map[string]int{
+			"SELECT COUNT(*) FROM price_history WHERE product_id = 2":  1,
+			"SELECT COUNT(*) FROM product_events WHERE product_id = 2": 1,
+			"SELECT COUNT(*) FROM jobs":                                0,
+			"SELECT COUNT(*) FROM products":                            1,
+		} {
+			var n int
+			if err := db.QueryRow(query).Scan(&n); err != nil || n != want {
+				t.Errorf("%s = %d, %v; want %d", query, n, err, want)
+			}
+		}
+	})
+}
+
+func TestNormalizePriceTimes(t *testing.T) {
+	sqldbtest.Run(t, NewMigrator, func(t *testing.T, db *sqldb.DB) {
+		if db.Dialect != sqldb.SQLite {
+			t.Skip("the Go migrations only apply to SQLite")
+		}
+		// Times as they were written before: the driver's time.Time.String
+		// in local time, and CURRENT_TIMESTAMP in UTC from the 014 seed.
+		for _, observedAt := range []string{
+			"2025-08-29 22:11:28.3403801 -0700 PDT m=+365.122951501",
+			"2025-08-30 01:00:00",
+			"2025-08-30 09:30:00.5 +0400 +04",
+		} {
+			if _, err := db.Exec("INSERT INTO price_history (source_site, asin, price, observed_at) VALUES ('amazon.ae', 'B000000001', 10, ?)", observedAt); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		tx, err := db.DB.Begin()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer tx.Rollback()
+		if err := normalizePriceTimes(tx); err != nil {
+			t.Fatalf("normalizePriceTimes() error: %v", err)
+		}
+		if err := tx.Commit(); err != nil {
+			t.Fatal(err)
+		}
+
+		repo := &DBRepository{DB: db}
+		history, err := repo.GetPriceHistory("amazon.ae", "B000000001", time.Time{})
+		if err != nil || len(history) != 3 {
+			t.Fatalf("GetPriceHistory() = %+v, %v", history, err)
+		}
+		// In time order, the CURRENT_TIMESTAMP row comes first.
+		want := []time.Time{
+			time.Date(2025, 8, 30, 1, 0, 0, 0, time.UTC),
+			time.Date(2025, 8, 30, 5, 11, 28, 340380000, time.UTC),
+			time.Date(2025, 8, 30, 5, 30, 0, 500000000, time.UTC),
+		}
+		for i, s := range history {
+			if !s.ObservedAt.Equal(want[i]) {
+				t.Errorf("observation %d at %v; want %v", i, s.ObservedAt, want[i])
+			}
+		}
+		since := time.Date(2025, 8, 30, 5, 20, 0, 0, time.UTC)
+		if recent, err := repo.GetPriceHistory("amazon.ae", "B000000001", since); err != nil || len(recent) != 1 {
+			t.Errorf("GetPriceHistory(since %v) = %+v, %v; want 1 observation", since, recent, err)
+		}
+	})
+}
+
+func TestNormalizeProductTimes(t *testing.T) {
+	sqldbtest.Run(t, NewMigrator, func(t *testing.T, db *sqldb.DB) {
+		if db.Dialect != sqldb.SQLite {
+			t.Skip("the Go migrations only apply to SQLite")
+		}
+		// Scrape times as the driver wrote them before, in the local time of
+		// two different machines.
+		for i, row := range []struct{ scrapedAt, refreshedAt string }{
+			{"2025-08-29 22:11:28.3403801 -0700 PDT m=+365.122951501", "2025-08-30 09:30:00.5 +0400 +04"},
+			{"2025-08-30 09:00:00 +0400 +04", ""},
+		} {
+			var refreshedAt interface{}
+			if row.refreshedAt != "" {
+				refreshedAt = row.refreshedAt
+			}
+			if _, err := db.Exec("INSERT INTO products (source_site, product_url, asin, status, scraped_at, refreshed_at) VALUES ('amazon.ae', ?, ?, 'completed', ?, ?)",
+				fmt.Sprintf("https://www.amazon.ae/dp/B00000000%d", i), fmt.Sprintf("B00000000%d", i), row.scrapedAt, refreshedAt); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		tx, err := db.DB.Begin()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer tx.Rollback()
+		if err := normalizeProductTimes(tx); err != nil {
+			t.Fatalf("normalizeProductTimes() error: %v", err)
+		}
+		if err := tx.Commit(); err != nil {
+			t.Fatal(err)
+		}
+
+		// 05:11:28 and 05:00 UTC; the filter is given with a -05:00 offset.
+		repo := &DBRepository{DB: db}
+		after := time.Date(2025, 8, 30, 0, 5, 0, 0, time.FixedZone("-05", -5*3600))
+		products, err := repo.GetFilteredProducts(models.ProductFilters{ScrapedAfter: after})
+		if err != nil || len(products) != 1 || products[0].ASIN != "B000000000" {
+			t.Fatalf("GetFilteredProducts(scraped after %v) = %+v, %v; want only B000000000", after, products, err)
+		}
+		if want := time.Date(2025, 8, 30, 5, 30, 0, 500000000, time.UTC); !products[0].RefreshedAt.Equal(want) {
+			t.Errorf("RefreshedAt = %v; want %v", products[0].RefreshedAt, want)
+		}
+	})
+}
@@ -0,0 +1,119 @@
+package database
+
+import (
+	"NovelScraper/internal/migrate"
+	"NovelScraper/internal/models"
+	"NovelScraper/internal/sqldb"
+	"database/sql"
+	"time"
+)
+
+// RecordPriceSnapshot appends a price observation to the history. Products
+// without an ASIN or without any price or availability are skipped, as the
+// deals grid often lists products before their prices are known.
+func (repo *DBRepository) RecordPriceSnapshot(s models.PriceSnapshot) error {
+	tx, err := repo.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := recordPriceSnapshot(tx, s); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// recordPriceSnapshot is RecordPriceSnapshot within the transaction that
+// saves the product.
+func recordPriceSnapshot(tx *sqldb.Tx, s models.PriceSnapshot) error {
+	if s.ASIN == "" || (s.Price == 0 && s.Availability == "") {
+		return nil
+	}
+	if s.ObservedAt.IsZero() {
+		s.ObservedAt = time.Now()
+	}
+	if s.ProductID == 0 {
+		err := tx.QueryRow("SELECT id FROM products WHERE source_site = ? AND asin = ?", s.SourceSite, s.ASIN).Scan(&s.ProductID)
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+	}
+
+	stmt, err := tx.Prepared(`
+		INSERT INTO price_history (product_id, source_site, asin, price, original_price, effective_price, currency, availability, observed_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	_, err = stmt.Exec(s.ProductID, s.SourceSite, s.ASIN, s.Price, s.OriginalPrice, s.EffectivePrice, s.Currency, s.Availability, sqldb.Timestamp(s.ObservedAt))
+	return err
+}
+
+// normalizePriceTimes rewrites the observation times written before they
+// were stored in UTC, in sqldb.TimeFormat, so time windows select the right
+// observations.
+func normalizePriceTimes(tx *sql.Tx) error {
+	return migrate.NormalizeTimes(tx, "price_history", "observed_at")
+}
+
+// GetPriceHistory returns the observations of a product since the given
+// time, oldest first. A zero since returns the full history.
+func (repo *DBRepository) GetPriceHistory(sourceSite, asin string, since time.Time) ([]models.PriceSnapshot, error) {
+	rows, err := repo.DB.Query(`
+		SELECT product_id, price, original_price, effective_price, currency, availability, observed_at
+		FROM price_history
+		WHERE source_site = ? AND asin = ? AND observed_at >= ?
+		ORDER BY observed_at, id`, sourceSite, asin, sqldb.Timestamp(since))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var history []models.PriceSnapshot
+	for rows.Next() {
+		s := models.PriceSnapshot{SourceSite: sourceSite, ASIN: asin}
+		var productID sql.NullInt64
+		if err := rows.Scan(&productID, &s.Price, &s.OriginalPrice, &s.EffectivePrice, &s.Currency, &s.Availability, &s.ObservedAt); err != nil {
+			return nil, err
+		}
+		s.ProductID = productID.Int64
+		history = append(history, s)
+	}
+	return history, rows.Err()
+}
+
+// GetPriceStats answers the usual deal questions for a product: the lowest
+// and highest price in the last 30 days and when it was first and last seen.
+func (repo *DBRepository) GetPriceStats(sourceSite, asin string) (models.PriceStats, error) {
+	var stats models.PriceStats
+	var lowest, highest sql.NullFloat64
+	var firstSeen, lastSeen sql.NullTime
+
+	// MIN/MAX would return observed_at as plain text, so read the first and
+	// last rows directly to keep the column's DATETIME type.
+	err := repo.DB.QueryRow(`
+		SELECT COUNT(*),
+			(SELECT observed_at FROM price_history WHERE source_site = ?1 AND asin = ?2 ORDER BY observed_at LIMIT 1),
+			(SELECT observed_at FROM price_history WHERE source_site = ?1 AND asin = ?2 ORDER BY observed_at DESC LIMIT 1)
+		FROM price_history WHERE source_site = ?1 AND asin = ?2`, sourceSite, asin,
+	).Scan(&stats.Observations, &firstSeen, &lastSeen)
+	if err != nil {
+		return stats, err
+	}
+
+	err = repo.DB.QueryRow(`
+		SELECT MIN(price), MAX(price)
+		FROM price_history WHERE source_site = ? AND asin = ? AND price > 0 AND observed_at >= ?`,
+		sourceSite, asin, sqldb.Timestamp(time.Now().AddDate(0, 0, -30)),
+	).Scan(&lowest, &highest)
+	if err != nil {
+		return stats, err
+	}
+
+	stats.LowestPrice30d = lowest.Float64
+	stats.HighestPrice30d = highest.Float64
+	stats.FirstSeen = firstSeen.Time
+	stats.LastSeen = lastSeen.Time
+	return stats, nil
+}
@@ -0,0 +1,93 @@
+package database
+
+import (
+	"NovelScraper/internal/models"
+	"NovelScraper/internal/sqldb"
+	"database/sql"
+	"log"
+	"time"
+)
+
+// GetProductsForRefresh returns every product whose details have been
+// scraped, with the fields a refresh policy needs, least recently refreshed
+// first. Products still waiting for details are left to the detail scraper,
+// and products removed from the marketplace are not checked again.
+func (repo *DBRepository) GetProductsForRefresh() ([]models.Product, error) {
+	rows, err := repo.DB.Query(`
+		SELECT id, COALESCE(source_site, ''), product_url, COALESCE(asin, ''), status,
+			COALESCE(deal_type, ''), COALESCE(currency, ''), scraped_at, refreshed_at
+		FROM products
+		WHERE status NOT IN ('needs_details', 'dead_letter')
+			AND COALESCE(availability_state, '') != 'removed'
+		ORDER BY COALESCE(refreshed_at, scraped_at)`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var products []models.Product
+	for rows.Next() {
+		var p models.Product
+		var scrapedAt, refreshedAt sql.NullTime
+		if err := rows.Scan(&p.ID, &p.SourceSite, &p.ProductURL, &p.ASIN, &p.Status,
+			&p.DealType, &p.Currency, &scrapedAt, &refreshedAt); err != nil {
+			return nil, err
+		}
+		p.ScrapedAt = scrapedAt.Time
+		p.RefreshedAt = refreshedAt.Time
+		products = append(products, p)
+	}
+	return products, rows.Err()
+}
+
+// UpdateProductPrices saves the result of a refresh. Only the volatile
+// fields are written and the status is kept, so translated text stays
+// valid and published products stay published. The price snapshot is
+// recorded in the same transaction.
+func (repo *DBRepository) UpdateProductPrices(product models.Product) error {
+	now := time.Now()
+	state, stockCount := product.NormalizedAvailability()
+	tx, err := repo.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+	UPDATE products SET
+		availability = ?,
+		availability_state = ?,
+		stock_count = ?,
+		original_price = ?,
+		discount_price = ?,
+		discount_percent = ?,
+		currency = ?,
+		offers = ?,
+		effective_price = ?,
+		effective_discount_percent = ?,
+		refreshed_at = ?
+	WHERE id = ?`,
+		product.Availability, state, stockCount, product.OriginalPrice, product.DiscountPrice, product.DiscountPercent,
+		product.Currency, product.Offers, product.EffectivePrice, product.EffectiveDiscountPercent,
+		sqldb.Timestamp(now), product.ID)
+	if err == nil {
+		err = recordPriceSnapshot(tx, product.Snapshot(now))
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		log.Printf("Failed to update prices of product %d: %v", product.ID, err)
+		return err
+	}
+	return nil
+}
+
+// MarkProductRemoved records that the marketplace no longer has a page for
+// the product, so it is unpublished and no longer refreshed.
+func (repo *DBRepository) MarkProductRemoved(id int64) error {
+	_, err := repo.DB.Exec(`
+	UPDATE products SET availability_state = ?, stock_count = 0, refreshed_at = ?
+	WHERE id = ?`, models.AvailabilityRemoved, sqldb.Timestamp(time.Now()), id)
+	return err
+}
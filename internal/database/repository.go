@@ -0,0 +1,55 @@
+package database
+
+import (
+	"NovelScraper/internal/models"
+	"time"
+)
+
+// Repository is the products database as the app uses it. DBRepository
+// implements it on SQLite and PostgreSQL.
+type Repository interface {
+	Close()
+
+	// Products and the data scraped with them.
+	SaveProduct(product *models.Product) error
+	SaveProducts(products []models.Product) error
+	ProductExists(product models.Product) (bool, error)
+	UpdateProductDetails(product models.Product) error
+	UpdateProductPrices(product models.Product) error
+	UpdateProductTranslation(id int64, titleFarsi, descriptionFarsi string, newStatus models.ProductStatus, reason string) error
+	UpdateProductAPlusTranslation(id int64, aplusFarsi string) error
+	MarkProductRemoved(id int64) error
+	GetProductIDsByStatus(status models.ProductStatus) ([]int64, error)
+	GetProductsForDetailScrape(ids []int64) ([]models.Product, error)
+	GetProductsForTranslation(ids []int64) ([]models.Product, error)
+	GetCompletedProducts(ids []int64) ([]models.Product, error)
+	GetProductsForRefresh() ([]models.Product, error)
+	GetReviews(productID int64) ([]models.Review, error)
+	GetVariants(sourceSite, parentASIN string) ([]models.Variant, error)
+	GetPriceHistory(sourceSite, asin string, since time.Time) ([]models.PriceSnapshot, error)
+	GetPriceStats(sourceSite, asin string) (models.PriceStats, error)
+	EachFilteredProduct(filters models.ProductFilters, fn func(models.Product) error) error
+
+	// Status history and failures.
+	UpdateProductStatus(id int64, to models.ProductStatus, reason string) error
+	RequeueProducts(filter models.RequeueFilter, to models.ProductStatus, reason string) (moved, skipped int, err error)
+	RecordFailure(productID int64, reason string, cause error) error
+	GetFailureSummary() ([]models.FailureSummary, error)
+	GetFailedProducts(status models.ProductStatus) ([]models.FailedProduct, error)
+
+	// Job queue.
+	EnqueueJobs(stage string, status models.ProductStatus) (int64, error)
+	ClaimJobs(stage, owner string, limit int, lease time.Duration) ([]models.Job, error)
+	ClaimProductJob(stage string, productID int64, owner string, lease time.Duration) (models.Job, bool, error)
+	RenewJob(job models.Job, lease time.Duration) (models.Job, error)
+	CompleteJob(job models.Job) error
+	FailJob(job models.Job, cause error, backoff time.Duration) error
+
+	// Daemon schedules and list crawls.
+	StartScheduleRun(name, owner string, every, lockTimeout time.Duration) (bool, error)
+	FinishScheduleRun(name, owner string, runErr error) error
+	GetCrawlCheckpoint(url string) (models.CrawlState, bool, error)
+	SaveCrawlCheckpoint(state models.CrawlState) error
+}
+
+var _ Repository = (*DBRepository)(nil)
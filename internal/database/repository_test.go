@@ -0,0 +1,346 @@
+package database
+
+import (
+	"NovelScraper/internal/models"
+	"NovelScraper/internal/sqldb"
+	"NovelScraper/internal/sqldb/sqldbtest"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// runRepositoryTest runs test against a fresh products database of every
+// backend; see sqldbtest for how PostgreSQL is enabled.
+func runRepositoryTest(t *testing.T, test func(t *testing.T, repo *DBRepository)) {
+	sqldbtest.Run(t, NewMigrator, func(t *testing.T, db *sqldb.DB) {
+		test(t, &DBRepository{DB: db, RunID: "test"})
+	})
+}
+
+func saveTestProduct(t *testing.T, repo Repository, p models.Product) models.Product {
+	t.Helper()
+	if err := repo.SaveProduct(&p); err != nil {
+		t.Fatalf("SaveProduct(%s) error: %v", p.ProductURL, err)
+	}
+	return p
+}
+
+func TestSaveProductMatchesURLAndASIN(t *testing.T) {
+	runRepositoryTest(t, func(t *testing.T, repo *DBRepository) {
+		first := saveTestProduct(t, repo, models.Product{
+			SourceSite: "amazon.ae", ProductURL: "https://www.amazon.ae/dp/B000000001", ASIN: "B000000001",
+			TitleEnglish: "Kettle", DiscountPrice: 50, Currency: "AED", Availability: "In Stock",
+		})
+		if first.ID == 0 || first.Status != models.StatusNeedsDetails {
+			t.Fatalf("new product = id %d, status %q", first.ID, first.Status)
+		}
+
+		// The same ASIN under another URL updates the stored product.
+		again := saveTestProduct(t, repo, models.Product{
+			SourceSite: "amazon.ae", ProductURL: "https://www.amazon.ae/Kettle/dp/B000000001?ref=deal", ASIN: "B000000001",
+			TitleEnglish: "Kettle, 1.7L", DealType: "lightning",
+		})
+		if again.ID != first.ID {
+			t.Errorf("same ASIN saved as product %d, want %d", again.ID, first.ID)
+		}
+
+		// The same URL without an ASIN matches by URL.
+		byURL := saveTestProduct(t, repo, models.Product{SourceSite: "amazon.ae", ProductURL: first.ProductURL})
+		if byURL.ID != first.ID {
+			t.Errorf("same URL saved as product %d, want %d", byURL.ID, first.ID)
+		}
+
+		other := saveTestProduct(t, repo, models.Product{SourceSite: "noon.com", ProductURL: "https://www.noon.com/p/N1", ASIN: "B000000001"})
+		if other.ID == first.ID {
+			t.Error("the same ASIN on another site was merged")
+		}
+
+		exists, err := repo.ProductExists(models.Product{SourceSite: "amazon.ae", ProductURL: "https://elsewhere", ASIN: "B000000001"})
+		if err != nil || !exists {
+			t.Errorf("ProductExists() = %v, %v; want true", exists, err)
+		}
+		exists, err = repo.ProductExists(models.Product{SourceSite: "amazon.ae", ProductURL: "https://elsewhere"})
+		if err != nil || exists {
+			t.Errorf("ProductExists() of an unknown URL = %v, %v; want false", exists, err)
+		}
+
+		history, err := repo.GetPriceHistory("amazon.ae", "B000000001", time.Time{})
+		if err != nil || len(history) != 1 || history[0].Price != 50 {
+			t.Errorf("GetPriceHistory() = %+v, %v; want the first price", history, err)
+		}
+	})
+}
+
+func TestSaveProducts(t *testing.T) {
+	runRepositoryTest(t, func(t *testing.T, repo *DBRepository) {
+		known := saveTestProduct(t, repo, models.Product{SourceSite: "amazon.ae", ProductURL: "https://www.amazon.ae/dp/B000000001", ASIN: "B000000001"})
+		if err := repo.UpdateProductDetails(known); err != nil {
+			t.Fatal(err)
+		}
+
+		page := []models.Product{
+			{SourceSite: "amazon.ae", ProductURL: "https://www.amazon.ae/dp/B000000002", ASIN: "B000000002", DiscountPrice: 20},
+			{SourceSite: "amazon.ae", ProductURL: "https://www.amazon.ae/Kettle/dp/B000000001", ASIN: "B000000001", DiscountPrice: 30},
+			{SourceSite: "amazon.ae", ProductURL: "https://www.amazon.ae/dp/B000000003", ASIN: "B000000003"},
+		}
+		if err := repo.SaveProducts(page); err != nil {
+			t.Fatalf("SaveProducts() error: %v", err)
+		}
+		if page[0].ID == 0 || page[0].Status != models.StatusNeedsDetails || page[2].ID == 0 || page[2].ID == page[0].ID {
+			t.Errorf("new products saved as %+v and %+v", page[0], page[2])
+		}
+		if page[1].ID != known.ID || page[1].Status != models.StatusNeedsTranslation {
+			t.Errorf("known product saved as id %d, status %q; want id %d, status %q", page[1].ID, page[1].Status, known.ID, models.StatusNeedsTranslation)
+		}
+		history, err := repo.GetPriceHistory("amazon.ae", "B000000001", time.Time{})
+		if err != nil || len(history) != 1 || history[0].Price != 30 {
+			t.Errorf("GetPriceHistory() = %+v, %v; want the price from the page recorded", history, err)
+		}
+	})
+}
+
+func TestProductLifecycle(t *testing.T) {
+	runRepositoryTest(t, func(t *testing.T, repo *DBRepository) {
+		p := saveTestProduct(t, repo, models.Product{SourceSite: "amazon.ae", ProductURL: "https://www.amazon.ae/dp/B000000002", ASIN: "B000000002"})
+
+		due, err := repo.GetProductsForDetailScrape([]int64{p.ID})
+		if err != nil || len(due) != 1 {
+			t.Fatalf("GetProductsForDetailScrape() = %d, %v; want the product", len(due), err)
+		}
+
+		p.TitleEnglish = "Blender"
+		p.Brand = "Acme"
+		p.Availability = "Only 3 left in stock"
+		p.DiscountPrice, p.OriginalPrice, p.Currency = 80, 100, "AED"
+		p.SoldByAmazon, p.PrimeEligible = true, true
+		p.Specs = models.SpecList{{Key: "Power", Value: "500W"}}
+		p.Reviews = []models.Review{{Author: "a", Stars: 5, Body: "good", Verified: true, ReviewedAt: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)}}
+		if err := repo.UpdateProductDetails(p); err != nil {
+			t.Fatalf("UpdateProductDetails() error: %v", err)
+		}
+
+		translate, err := repo.GetProductsForTranslation(nil)
+		if err != nil || len(translate) != 1 || translate[0].TitleEnglish != "Blender" {
+			t.Fatalf("GetProductsForTranslation() = %+v, %v", translate, err)
+		}
+		if err := repo.UpdateProductTranslation(p.ID, "مخلوط کن", "توضیحات", models.StatusCompleted, "translated"); err != nil {
+			t.Fatalf("UpdateProductTranslation() error: %v", err)
+		}
+
+		completed, err := repo.GetCompletedProducts([]int64{p.ID})
+		if err != nil || len(completed) != 1 {
+			t.Fatalf("GetCompletedProducts() = %d, %v", len(completed), err)
+		}
+		c := completed[0]
+		if c.TitleFarsi != "مخلوط کن" || !c.SoldByAmazon || c.FulfilledByAmazon || len(c.Specs) != 1 ||
+			c.AvailabilityState != models.AvailabilityLowStock || c.StockCount != 3 {
+			t.Errorf("completed product = %+v", c)
+		}
+		reviews, err := repo.GetReviews(p.ID)
+		if err != nil || len(reviews) != 1 || !reviews[0].Verified || !reviews[0].ReviewedAt.Equal(p.Reviews[0].ReviewedAt) {
+			t.Errorf("GetReviews() = %+v, %v", reviews, err)
+		}
+
+		// A completed product cannot go back to detail scraping directly.
+		var transitionErr *models.TransitionError
+		if err := repo.UpdateProductStatus(p.ID, models.StatusDeadLetter, "test"); !errors.As(err, &transitionErr) {
+			t.Errorf("UpdateProductStatus() error = %v, want a TransitionError", err)
+		}
+		// The product was scraped just now, whatever zone the cutoff is given in.
+		hourAgo := time.Now().Add(-time.Hour).In(time.FixedZone("+14", 14*3600))
+		if moved, _, err := repo.RequeueProducts(models.RequeueFilter{ScrapedBefore: hourAgo}, models.StatusNeedsTranslation, "stale"); err != nil || moved != 0 {
+			t.Errorf("RequeueProducts(scraped before %v) = %d, %v; want none moved", hourAgo, moved, err)
+		}
+		moved, skipped, err := repo.RequeueProducts(models.RequeueFilter{IDs: []int64{p.ID}}, models.StatusNeedsTranslation, "retranslate")
+		if err != nil || moved != 1 || skipped != 0 {
+			t.Errorf("RequeueProducts() = %d, %d, %v; want 1 moved", moved, skipped, err)
+		}
+		events, err := repo.GetProductEvents(p.ID)
+		if err != nil || len(events) != 3 || events[2].Reason != "retranslate" || events[2].RunID != "test" {
+			t.Errorf("GetProductEvents() = %+v, %v", events, err)
+		}
+
+		if err := repo.MarkProductRemoved(p.ID); err != nil {
+			t.Fatal(err)
+		}
+		refresh, err := repo.GetProductsForRefresh()
+		if err != nil || len(refresh) != 0 {
+			t.Errorf("GetProductsForRefresh() = %d, %v; want the removed product skipped", len(refresh), err)
+		}
+	})
+}
+
+func TestPriceHistory(t *testing.T) {
+	runRepositoryTest(t, func(t *testing.T, repo *DBRepository) {
+		// Observations are written in UTC whatever zone they were read in,
+		// so time windows compare them correctly.
+		dubai := time.FixedZone("GST", 4*60*60)
+		now := time.Now().Truncate(time.Second)
+		observations := []models.PriceSnapshot{
+			{SourceSite: "amazon.ae", ASIN: "B000000009", Price: 120, Currency: "AED", ObservedAt: now.AddDate(0, 0, -40).In(dubai)},
+			{SourceSite: "amazon.ae", ASIN: "B000000009", Price: 90, Currency: "AED", ObservedAt: now.AddDate(0, 0, -10).UTC()},
+			{SourceSite: "amazon.ae", ASIN: "B000000009", Price: 100, Currency: "AED", ObservedAt: now.Add(-time.Hour).In(dubai)},
+			{SourceSite: "noon.com", ASIN: "B000000009", Price: 50, Currency: "AED", ObservedAt: now},
+		}
+		for _, s := range observations {
+			if err := repo.RecordPriceSnapshot(s); err != nil {
+				t.Fatalf("RecordPriceSnapshot() error: %v", err)
+			}
+		}
+
+		history, err := repo.GetPriceHistory("amazon.ae", "B000000009", time.Time{})
+		if err != nil || len(history) != 3 || history[0].Price != 120 || !history[2].ObservedAt.Equal(observations[2].ObservedAt) {
+			t.Fatalf("GetPriceHistory() = %+v, %v; want the 3 amazon.ae observations", history, err)
+		}
+		since := now.Add(-2 * time.Hour).In(dubai)
+		if recent, err := repo.GetPriceHistory("amazon.ae", "B000000009", since); err != nil || len(recent) != 1 || recent[0].Price != 100 {
+			t.Errorf("GetPriceHistory(since %v) = %+v, %v; want the last observation", since, recent, err)
+		}
+
+		stats, err := repo.GetPriceStats("amazon.ae", "B000000009")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stats.Observations != 3 || stats.LowestPrice30d != 90 || stats.HighestPrice30d != 100 ||
+			!stats.FirstSeen.Equal(observations[0].ObservedAt) || !stats.LastSeen.Equal(observations[2].ObservedAt) {
+			t.Errorf("GetPriceStats() = %+v", stats)
+		}
+		if stats, err := repo.GetPriceStats("noon.com", "B000000009"); err != nil || stats.Observations != 1 || stats.LowestPrice30d != 50 {
+			t.Errorf("GetPriceStats(noon.com) = %+v, %v", stats, err)
+		}
+	})
+}
+
+func TestFailures(t *testing.T) {
+	runRepositoryTest(t, func(t *testing.T, repo *DBRepository) {
+		a := saveTestProduct(t, repo, models.Product{SourceSite: "amazon.ae", ProductURL: "https://www.amazon.ae/dp/B000000003"})
+		b := saveTestProduct(t, repo, models.Product{SourceSite: "amazon.ae", ProductURL: "https://www.amazon.ae/dp/B000000004"})
+		for _, id := range []int64{a.ID, a.ID, b.ID} {
+			if err := repo.RecordFailure(id, "timeout", errors.New("page timed out")); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := repo.UpdateProductStatus(b.ID, models.StatusDeadLetter, "gave up"); err != nil {
+			t.Fatal(err)
+		}
+
+		summary, err := repo.GetFailureSummary()
+		if err != nil || len(summary) != 1 {
+			t.Fatalf("GetFailureSummary() = %+v, %v", summary, err)
+		}
+		if s := summary[0]; s.Reason != "timeout" || s.Products != 2 || s.DeadLetter != 1 || s.Attempts != 3 {
+			t.Errorf("summary = %+v", s)
+		}
+		failed, err := repo.GetFailedProducts(models.StatusDeadLetter)
+		if err != nil || len(failed) != 1 || failed[0].ID != b.ID || failed[0].FailedAt.IsZero() {
+			t.Errorf("GetFailedProducts() = %+v, %v", failed, err)
+		}
+	})
+}
+
+func TestScheduleRuns(t *testing.T) {
+	runRepositoryTest(t, func(t *testing.T, repo *DBRepository) {
+		ok, err := repo.StartScheduleRun("publish", "d1", time.Hour, time.Hour)
+		if err != nil || !ok {
+			t.Fatalf("StartScheduleRun(d1) = %v, %v; want true", ok, err)
+		}
+		if ok, err := repo.StartScheduleRun("publish", "d2", 0, time.Hour); err != nil || ok {
+			t.Errorf("StartScheduleRun(d2) while running = %v, %v; want false", ok, err)
+		}
+		if err := repo.FinishScheduleRun("publish", "d1", nil); err != nil {
+			t.Errorf("FinishScheduleRun() error: %v", err)
+		}
+		if ok, err := repo.StartScheduleRun("publish", "d2", time.Hour, time.Hour); err != nil || ok {
+			t.Errorf("StartScheduleRun(d2) before it is due = %v, %v; want false", ok, err)
+		}
+		if err := repo.FinishScheduleRun("publish", "d2", nil); !errors.Is(err, ErrLeaseLost) {
+			t.Errorf("FinishScheduleRun() without the lock = %v, want ErrLeaseLost", err)
+		}
+	})
+}
+
+func TestCrawlCheckpoints(t *testing.T) {
+	runRepositoryTest(t, func(t *testing.T, repo *DBRepository) {
+		if _, ok, err := repo.GetCrawlCheckpoint("https://deals"); err != nil || ok {
+			t.Fatalf("GetCrawlCheckpoint() of a new URL = %v, %v", ok, err)
+		}
+
+		state := models.NewCrawlState("https://deals", "amazon.ae")
+		state.Iterations = 4
+		state.Seen = map[string]bool{"amazon.ae:A": true, "amazon.ae:B": true}
+		state.Previous = map[string]bool{"amazon.ae:C": true}
+		state.Done = true
+		if err := repo.SaveCrawlCheckpoint(state); err != nil {
+			t.Fatal(err)
+		}
+		got, ok, err := repo.GetCrawlCheckpoint("https://deals")
+		if err != nil || !ok {
+			t.Fatalf("GetCrawlCheckpoint() = %v, %v", ok, err)
+		}
+		if got.Iterations != 4 || !got.Done || len(got.Seen) != 2 || !got.Previous["amazon.ae:C"] || got.SourceSite != "amazon.ae" {
+			t.Errorf("checkpoint = %+v", got)
+		}
+	})
+}
+
+func TestFilteredProducts(t *testing.T) {
+	runRepositoryTest(t, func(t *testing.T, repo *DBRepository) {
+		for i, p := range []models.Product{
+			{SourceSite: "amazon.ae", Category: "Kitchen", OriginalPrice: 100, DiscountPrice: 60, DiscountPercent: 40},
+			{SourceSite: "amazon.ae", Category: "Kitchen", OriginalPrice: 100, DiscountPrice: 90, DiscountPercent: 10},
+			{SourceSite: "noon.com", Category: "Kitchen", OriginalPrice: 300, DiscountPrice: 150, DiscountPercent: 50},
+			{SourceSite: "amazon.ae", Category: "Toys", OriginalPrice: 20, DiscountPrice: 15, DiscountPercent: 25},
+		} {
+			p.ASIN = fmt.Sprintf("B00000000%d", i)
+			p.ProductURL = "https://example.com/dp/" + p.ASIN
+			p.TitleEnglish = p.Category
+			saveTestProduct(t, repo, p)
+		}
+		if err := repo.UpdateProductStatus(4, models.StatusDeadLetter, "test"); err != nil {
+			t.Fatal(err)
+		}
+
+		tests := []struct {
+			name    string
+			filters models.ProductFilters
+			want    int
+		}{
+			{"all", models.ProductFilters{}, 4},
+			{"site and category", models.ProductFilters{SourceSite: "amazon.ae", Category: "Kitchen"}, 2},
+			{"statuses", models.ProductFilters{Statuses: []models.ProductStatus{models.StatusNeedsDetails, models.StatusCompleted}}, 3},
+			{"price range", models.ProductFilters{MinDiscountPrice: 50, MaxDiscountPrice: 100}, 2},
+			{"original price", models.ProductFilters{MaxOriginalPrice: 100}, 3},
+			{"discount range", models.ProductFilters{MinDiscountPercent: 20, MaxDiscountPercent: 45}, 2},
+			{"scraped since", models.ProductFilters{ScrapedAfter: time.Now().Add(-time.Hour)}, 4},
+			{"scraped before", models.ProductFilters{ScrapedBefore: time.Now().Add(-time.Hour)}, 0},
+			{"scraped since, far east", models.ProductFilters{ScrapedAfter: time.Now().Add(-time.Hour).In(time.FixedZone("+14", 14*3600))}, 4},
+			{"scraped before, far west", models.ProductFilters{ScrapedBefore: time.Now().Add(-time.Hour).In(time.FixedZone("-12", -12*3600))}, 0},
+			{"limit", models.ProductFilters{Limit: 3}, 3},
+		}
+		for _, tt := range tests {
+			products, err := repo.GetFilteredProducts(tt.filters)
+			if err != nil || len(products) != tt.want {
+				t.Errorf("%s: GetFilteredProducts() = %d products, %v; want %d", tt.name, len(products), err, tt.want)
+			}
+		}
+
+		// Products are read one at a time, newest first, and an error stops
+		// the iteration.
+		stop := errors.New("stop")
+		var seen []models.Product
+		err := repo.EachFilteredProduct(models.ProductFilters{}, func(p models.Product) error {
+			seen = append(seen, p)
+			if len(seen) == 2 {
+				return stop
+			}
+			return nil
+		})
+		if !errors.Is(err, stop) || len(seen) != 2 {
+			t.Fatalf("EachFilteredProduct() = %v after %d products; want stop after 2", err, len(seen))
+		}
+		if got := seen[0]; got.ID != 4 || got.Category != "Toys" || got.DiscountPrice != 15 || got.ScrapedAt.IsZero() {
+			t.Errorf("first product = %+v", got)
+		}
+	})
+}
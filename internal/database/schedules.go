@@ -0,0 +1,43 @@
+package database
+
+import "time"
+
+// StartScheduleRun takes the lock of a daemon schedule if it is due, i.e.
+// it last started at least every ago, and is not already running. It
+// reports whether the caller may run the schedule, in which case it must
+// call FinishScheduleRun afterwards.
+func (repo *DBRepository) StartScheduleRun(name, owner string, every, lockTimeout time.Duration) (bool, error) {
+	now := time.Now().UTC()
+	if _, err := repo.DB.Exec("INSERT INTO schedule_runs (name) VALUES (?) ON CONFLICT(name) DO NOTHING", name); err != nil {
+		return false, err
+	}
+	res, err := repo.DB.Exec(`
+		UPDATE schedule_runs SET lock_owner = ?, lock_expires_at = ?, last_started_at = ?
+		WHERE name = ?
+			AND (lock_expires_at IS NULL OR lock_expires_at <= ?)
+			AND (last_started_at IS NULL OR last_started_at <= ?)`,
+		owner, now.Add(lockTimeout), now, name, now, now.Add(-every))
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	return affected > 0, err
+}
+
+// FinishScheduleRun releases the lock of a schedule and records the outcome
+// of the run; runErr is nil when it succeeded. ErrLeaseLost means the run
+// took longer than its lock timeout and another process took the lock over.
+func (repo *DBRepository) FinishScheduleRun(name, owner string, runErr error) error {
+	lastError := ""
+	if runErr != nil {
+		lastError = runErr.Error()
+	}
+	res, err := repo.DB.Exec(`
+		UPDATE schedule_runs SET lock_owner = '', lock_expires_at = NULL, last_finished_at = ?, last_error = ?, runs = runs + 1
+		WHERE name = ? AND lock_owner = ?`,
+		time.Now().UTC(), lastError, name, owner)
+	if err != nil {
+		return err
+	}
+	return checkLease(res.RowsAffected())
+}
@@ -1,87 +1,102 @@
 package database
 
 import (
+	"NovelScraper/internal/migrate"
 	"NovelScraper/internal/models"
+	"NovelScraper/internal/sqldb"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"strings"
 	"time"
-
-	_ "modernc.org/sqlite" // FIX: Switched to the pure Go driver
 )
 
 // DBRepository یک لایه دور کانکشن دیتابیس است.
+// It implements Repository on SQLite and PostgreSQL; the queries are shared
+// and rebound for the backend by sqldb.
 type DBRepository struct {
-	DB *sql.DB
+	DB    *sqldb.DB
+	RunID string // recorded with every status change
 }
 
 // InitDB یک نمونه جدید از DBRepository را مقداردهی و برمی‌گرداند.
-// FIX: نوع خروجی تابع به *DBRepository تغییر کرده است.
-func InitDB(filepath string) *DBRepository {
-	db, err := sql.Open("sqlite", filepath)
+// The schema is managed by migrations (see migrations.go); InitDB refuses to
+// continue if the database is behind, since every query assumes the latest
+// schema. Run the "migrate" task to bring it up to date.
+func InitDB(cfg sqldb.Config) *DBRepository {
+	db, err := Open(cfg)
 	if err != nil {
 		log.Fatalf("Error opening database: %v", err)
 	}
-	if err = db.Ping(); err != nil {
-		log.Fatalf("Error pinging database: %v", err)
-	}
-
-	createProductsTableSQL := `
-	CREATE TABLE IF NOT EXISTS products (
-		"id" INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
-		"source_site" TEXT,
-		"product_url" TEXT UNIQUE,
-		"category" TEXT,
-		"status" TEXT DEFAULT 'new',
-		"title_english" TEXT,
-		"title_farsi" TEXT,
-		"description_english" TEXT,
-		"description_farsi" TEXT,
-		"brand" TEXT,
-		"availability" TEXT,
-		"original_price" REAL,
-		"discount_price" REAL,
-		"discount_percent" INTEGER,
-		"main_image_url" TEXT,
-		"gallery_image_urls" TEXT,
-		"specifications" TEXT,
-		"country_of_origin" TEXT,
-		"scraped_at" DATETIME,
-		"posted_to_wp" BOOLEAN DEFAULT 0,
-		"wp_post_id" INTEGER
-	);`
-
-	_, err = db.Exec(createProductsTableSQL)
-	if err != nil {
-		log.Fatalf("Error creating products table: %v", err)
-	}
-
-	// FIX: ایجاد جدول جدید برای دسته‌بندی‌ها
-	createCategoriesTableSQL := `
-	CREATE TABLE IF NOT EXISTS categories (
-		"id" INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
-		"name" TEXT,
-		"node" TEXT UNIQUE,
-		"source_site" TEXT
-	);`
-	_, err = db.Exec(createCategoriesTableSQL)
+
+	migrator, err := NewMigrator(db)
 	if err != nil {
-		log.Fatalf("Error creating categories table: %v", err)
+		log.Fatalf("Error loading migrations: %v", err)
+	}
+	if err := migrator.Check(); err != nil {
+		log.Fatalf("The %s products database is not up to date (%v). Run with -task=migrate first.", cfg.Dialect, err)
 	}
 
 	log.Println("Database and tables initialized successfully.")
 	return &DBRepository{DB: db}
 }
 
+// Open connects to a products database without checking its schema.
+func Open(cfg sqldb.Config) (*sqldb.DB, error) {
+	return sqldb.Open(cfg)
+}
+
 // Close کانکشن دیتابیس را می‌بندد.
 func (repo *DBRepository) Close() {
 	repo.DB.Close()
 }
 
 // SaveProduct یک محصول را در دیتابیس ذخیره یا به‌روزرسانی می‌کند.
-func (repo *DBRepository) SaveProduct(product models.Product) error {
+// The ID and status of the stored row are written back to product, so the
+// caller can tell a new product from one that is already further along.
+// The product and its price snapshot are written in one transaction.
+func (repo *DBRepository) SaveProduct(product *models.Product) error {
+	products := []models.Product{*product}
+	if err := repo.SaveProducts(products); err != nil {
+		return err
+	}
+	*product = products[0]
+	return nil
+}
+
+// SaveProducts saves the products found on one page of a list crawl like
+// SaveProduct, all in one transaction. The ID and status of each stored row
+// are written back to products.
+func (repo *DBRepository) SaveProducts(products []models.Product) error {
+	tx, err := repo.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	for i := range products {
+		if err := saveProduct(tx, &products[i], now); err != nil {
+			log.Printf("Failed to save product %s: %v", products[i].ProductURL, err)
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		log.Printf("Failed to save %d products: %v", len(products), err)
+		return err
+	}
+
+	// We can reduce log verbosity here
+	for _, p := range products {
+		log.Printf("Successfully saved product: %s", p.TitleEnglish)
+	}
+	return nil
+}
+
+// saveProduct inserts or updates one product and records its price.
+func saveProduct(tx *sqldb.Tx, product *models.Product, now time.Time) error {
 	galleryJSON, err := json.Marshal(product.GalleryImageURLs)
 	if err != nil {
 		return err
@@ -89,39 +104,60 @@ func (repo *DBRepository) SaveProduct(product models.Product) error {
 
 	query := `
 	INSERT INTO products (
-		source_site, product_url, category, status, title_english, brand, availability,
-		original_price, discount_price, discount_percent, main_image_url,
+		source_site, product_url, asin, category, status, deal_type, title_english, brand, availability,
+		original_price, discount_price, discount_percent, currency, main_image_url,
 		gallery_image_urls, specifications, description_english, scraped_at
-	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	ON CONFLICT(product_url) DO UPDATE SET
+		asin=COALESCE(NULLIF(excluded.asin, ''), products.asin),
+		deal_type=excluded.deal_type,
 		title_english=excluded.title_english,
 		discount_percent=excluded.discount_percent,
-		scraped_at=excluded.scraped_at;
+		scraped_at=excluded.scraped_at
+	RETURNING id, status;
 	`
 	// Note: We only update a few fields on conflict to avoid overwriting detailed data.
 	// The status is only set on the initial insert.
 
-	stmt, err := repo.DB.Prepare(query)
-	if err != nil {
-		return err
+	// A product already stored under another URL is matched by its ASIN
+	// first: an upsert can only resolve one unique key on PostgreSQL.
+	err = sql.ErrNoRows
+	if product.ASIN != "" {
+		var stmt *sql.Stmt
+		stmt, err = tx.Prepared(`
+		UPDATE products SET deal_type = ?, title_english = ?, discount_percent = ?, scraped_at = ?
+		WHERE source_site = ? AND asin = ?
+		RETURNING id, status`)
+		if err == nil {
+			err = stmt.QueryRow(
+				product.DealType, product.TitleEnglish, product.DiscountPercent, sqldb.Timestamp(now),
+				product.SourceSite, product.ASIN,
+			).Scan(&product.ID, &product.Status)
+		}
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		var stmt *sql.Stmt
+		stmt, err = tx.Prepared(query)
+		if err == nil {
+			err = stmt.QueryRow(
+				product.SourceSite, product.ProductURL, product.ASIN, product.Category, models.StatusNeedsDetails, product.DealType, // <-- Set category and initial status
+				product.TitleEnglish, product.Brand, product.Availability,
+				product.OriginalPrice, product.DiscountPrice, product.DiscountPercent, product.Currency, product.MainImageURL,
+				string(galleryJSON), product.Specifications, product.DescriptionEnglish, sqldb.Timestamp(now),
+			).Scan(&product.ID, &product.Status)
+		}
 	}
-	defer stmt.Close()
-
-	_, err = stmt.Exec(
-		product.SourceSite, product.ProductURL, product.Category, "needs_details", // <-- Set category and initial status
-		product.TitleEnglish, product.Brand, product.Availability,
-		product.OriginalPrice, product.DiscountPrice, product.DiscountPercent, product.MainImageURL,
-		string(galleryJSON), product.Specifications, product.DescriptionEnglish, time.Now(),
-	)
-
 	if err != nil {
-		log.Printf("Failed to save product %s: %v", product.ProductURL, err)
 		return err
 	}
+	return recordPriceSnapshot(tx, product.Snapshot(now))
+}
 
-	// We can reduce log verbosity here
-	log.Printf("Successfully saved product: %s", product.TitleEnglish)
-	return nil
+// normalizeProductTimes rewrites the scrape and refresh times written
+// before they were stored in UTC, in sqldb.TimeFormat, so the date filters
+// of exports and requeues select the right products.
+func normalizeProductTimes(tx *sql.Tx) error {
+	return migrate.NormalizeTimes(tx, "products", "scraped_at", "refreshed_at")
 }
 
 // GetAllProducts تمام محصولات ذخیره شده در دیتابیس را برمی‌گرداند.
@@ -208,65 +244,266 @@ func (repo *DBRepository) GetIncompleteProducts() ([]models.Product, error) {
 }
 
 // UpdateProductDetails updates an existing product record with fully scraped data.
+// The product, its variants, reviews and price snapshot are written in one
+// transaction, so a failure leaves none of them behind.
 func (repo *DBRepository) UpdateProductDetails(product models.Product) error {
 	galleryJSON, err := json.Marshal(product.GalleryImageURLs)
 	if err != nil {
 		return err
 	}
+	state, stockCount := product.NormalizedAvailability()
 
 	query := `
 	UPDATE products SET
 		title_english = ?,
 		brand = ?,
 		availability = ?,
+		availability_state = ?,
+		stock_count = ?,
 		original_price = ?,
 		discount_price = ?,
 		discount_percent = ?,
+		currency = ?,
+		offers = ?,
+		effective_price = ?,
+		effective_discount_percent = ?,
+		parent_asin = ?,
+		variation_dimensions = ?,
+		rating = ?,
+		rating_count = ?,
+		rating_histogram = ?,
 		main_image_url = ?,
 		gallery_image_urls = ?,
 		specifications = ?,
+		specs = ?,
+		country_of_origin = ?,
+		model_number = ?,
+		manufacturer = ?,
+		ean = ?,
+		upc = ?,
+		length_cm = ?,
+		width_cm = ?,
+		height_cm = ?,
+		weight_g = ?,
 		description_english = ?,
+		aplus_html = ?,
+		seller_name = ?,
+		seller_id = ?,
+		ships_from = ?,
+		sold_by_amazon = ?,
+		fulfilled_by_amazon = ?,
+		prime_eligible = ?,
+		delivery_estimate = ?,
 		scraped_at = ?,
-		status = ?       -- <-- ADD THIS LINE
+		failure_reason = '',
+		failure_count = 0,
+		last_error = ''
 	WHERE id = ?;
 	`
-	stmt, err := repo.DB.Prepare(query)
+	tx, err := repo.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepared(query)
 	if err != nil {
 		return err
 	}
-	defer stmt.Close()
 
+	now := time.Now()
 	_, err = stmt.Exec(
 		product.TitleEnglish,
 		product.Brand,
 		product.Availability,
+		state,
+		stockCount,
 		product.OriginalPrice,
 		product.DiscountPrice,
 		product.DiscountPercent, // Added discount percent
+		product.Currency,
+		product.Offers,
+		product.EffectivePrice,
+		product.EffectiveDiscountPercent,
+		product.ParentASIN,
+		product.VariationDimensions,
+		product.Rating,
+		product.RatingCount,
+		product.RatingHistogram,
 		product.MainImageURL,
 		string(galleryJSON),
 		product.Specifications,
+		product.Specs,
+		product.CountryOfOrigin,
+		product.ModelNumber,
+		product.Manufacturer,
+		product.EAN,
+		product.UPC,
+		product.LengthCM,
+		product.WidthCM,
+		product.HeightCM,
+		product.WeightGrams,
 		product.DescriptionEnglish,
-		time.Now(),
-		"needs_translation", // <-- Set status to the next step
+		product.APlusHTML,
+		product.SellerName,
+		product.SellerID,
+		product.ShipsFrom,
+		product.SoldByAmazon,
+		product.FulfilledByAmazon,
+		product.PrimeEligible,
+		product.DeliveryEstimate,
+		sqldb.Timestamp(now),
 		product.ID,
 	)
-
+	if err == nil {
+		err = repo.transition(tx, product.ID, models.StatusNeedsTranslation, "details scraped")
+	}
+	if err == nil && product.ParentASIN != "" && len(product.Variants) > 0 {
+		err = saveVariants(tx, product.SourceSite, product.ParentASIN, product.Variants, now)
+	}
+	if err == nil && len(product.Reviews) > 0 {
+		err = saveReviews(tx, product.ID, product.Reviews)
+	}
+	if err == nil {
+		err = recordPriceSnapshot(tx, product.Snapshot(now))
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
 	if err != nil {
 		log.Printf("Failed to update product %d: %v", product.ID, err)
 		return err
 	}
+	return nil
+}
+
+// SaveReviews replaces the stored top reviews of a product.
+func (repo *DBRepository) SaveReviews(productID int64, reviews []models.Review) error {
+	tx, err := repo.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
 
+	if err := saveReviews(tx, productID, reviews); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func saveReviews(tx *sqldb.Tx, productID int64, reviews []models.Review) error {
+	if _, err := tx.Exec("DELETE FROM reviews WHERE product_id = ?", productID); err != nil {
+		return err
+	}
+	stmt, err := tx.Prepared(`INSERT INTO reviews (product_id, review_id, author, stars, title, body, reviewed_at, verified, helpful_votes)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+
+	for _, r := range reviews {
+		if _, err := stmt.Exec(productID, r.ReviewID, r.Author, r.Stars, r.Title, r.Body, r.ReviewedAt, r.Verified, r.HelpfulVotes); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-// GetProductsForTranslation retrieves all products with the status 'needs_translation'.
-func (repo *DBRepository) GetProductsForTranslation() ([]models.Product, error) {
+// GetReviews returns the stored reviews of a product in page order.
+func (repo *DBRepository) GetReviews(productID int64) ([]models.Review, error) {
 	rows, err := repo.DB.Query(`
-		SELECT id, product_url, title_english, description_english, specifications
-		FROM products
-		WHERE status = 'needs_translation'
+		SELECT review_id, author, stars, title, body, reviewed_at, verified, helpful_votes
+		FROM reviews WHERE product_id = ? ORDER BY id`, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reviews []models.Review
+	for rows.Next() {
+		var r models.Review
+		var reviewedAt sql.NullTime
+		if err := rows.Scan(&r.ReviewID, &r.Author, &r.Stars, &r.Title, &r.Body, &reviewedAt, &r.Verified, &r.HelpfulVotes); err != nil {
+			log.Printf("Error scanning review row: %v", err)
+			continue
+		}
+		r.ReviewedAt = reviewedAt.Time
+		reviews = append(reviews, r)
+	}
+	return reviews, nil
+}
+
+// SaveVariants inserts or updates the child variations of a parent ASIN.
+func (repo *DBRepository) SaveVariants(sourceSite, parentASIN string, variants []models.Variant) error {
+	tx, err := repo.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := saveVariants(tx, sourceSite, parentASIN, variants, time.Now()); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func saveVariants(tx *sqldb.Tx, sourceSite, parentASIN string, variants []models.Variant, now time.Time) error {
+	stmt, err := tx.Prepared(`
+	INSERT INTO product_variants (source_site, parent_asin, asin, attributes, price, availability, url, updated_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	ON CONFLICT(source_site, parent_asin, asin) DO UPDATE SET
+		attributes=excluded.attributes,
+		price=CASE WHEN excluded.price > 0 THEN excluded.price ELSE product_variants.price END,
+		availability=CASE WHEN excluded.availability != '' THEN excluded.availability ELSE product_variants.availability END,
+		url=excluded.url,
+		updated_at=excluded.updated_at;
 	`)
+	if err != nil {
+		return err
+	}
+
+	// Each child page only shows prices for some swatches, so an empty price
+	// or availability keeps what an earlier scrape of a sibling found.
+	for _, v := range variants {
+		if _, err := stmt.Exec(sourceSite, parentASIN, v.ASIN, v.Attributes, v.Price, v.Availability, v.URL, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetVariants returns the stored child variations of a parent ASIN.
+func (repo *DBRepository) GetVariants(sourceSite, parentASIN string) ([]models.Variant, error) {
+	rows, err := repo.DB.Query(`
+		SELECT asin, attributes, price, availability, url
+		FROM product_variants
+		WHERE source_site = ? AND parent_asin = ?
+		ORDER BY id`, sourceSite, parentASIN)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var variants []models.Variant
+	for rows.Next() {
+		var v models.Variant
+		if err := rows.Scan(&v.ASIN, &v.Attributes, &v.Price, &v.Availability, &v.URL); err != nil {
+			log.Printf("Error scanning variant row: %v", err)
+			continue
+		}
+		variants = append(variants, v)
+	}
+	return variants, nil
+}
+
+// GetProductsForTranslation retrieves the products with the status
+// 'needs_translation' among ids, or all of them when ids is nil.
+func (repo *DBRepository) GetProductsForTranslation(ids []int64) ([]models.Product, error) {
+	filter, args := idFilter(ids)
+	rows, err := repo.DB.Query(`
+		SELECT id, product_url, title_english, description_english, specifications, aplus_html
+		FROM products
+		WHERE status = 'needs_translation'`+filter, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -275,7 +512,7 @@ func (repo *DBRepository) GetProductsForTranslation() ([]models.Product, error)
 	var products []models.Product
 	for rows.Next() {
 		var p models.Product
-		if err := rows.Scan(&p.ID, &p.ProductURL, &p.TitleEnglish, &p.DescriptionEnglish, &p.Specifications); err != nil {
+		if err := rows.Scan(&p.ID, &p.ProductURL, &p.TitleEnglish, &p.DescriptionEnglish, &p.Specifications, &p.APlusHTML); err != nil {
 			log.Printf("Error scanning product for translation: %v", err)
 			continue
 		}
@@ -284,57 +521,123 @@ func (repo *DBRepository) GetProductsForTranslation() ([]models.Product, error)
 	return products, nil
 }
 
-// UpdateProductTranslation saves the translated text and updates the status.
-func (repo *DBRepository) UpdateProductTranslation(id int64, titleFarsi, descriptionFarsi, newStatus string) error {
-	query := `UPDATE products SET title_farsi = ?, description_farsi = ?, status = ? WHERE id = ?`
-	stmt, err := repo.DB.Prepare(query)
+// UpdateProductTranslation saves the translated text and moves the product
+// to newStatus.
+func (repo *DBRepository) UpdateProductTranslation(id int64, titleFarsi, descriptionFarsi string, newStatus models.ProductStatus, reason string) error {
+	tx, err := repo.DB.Begin()
 	if err != nil {
 		return err
 	}
-	defer stmt.Close()
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`UPDATE products SET title_farsi = ?, description_farsi = ? WHERE id = ?`, titleFarsi, descriptionFarsi, id); err != nil {
+		return err
+	}
+	if err := repo.transition(tx, id, newStatus, reason); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
 
-	_, err = stmt.Exec(titleFarsi, descriptionFarsi, newStatus, id)
+// UpdateProductAPlusTranslation saves the translated A+ content.
+func (repo *DBRepository) UpdateProductAPlusTranslation(id int64, aplusFarsi string) error {
+	_, err := repo.DB.Exec("UPDATE products SET aplus_farsi = ? WHERE id = ?", aplusFarsi, id)
 	return err
 }
 
 // GetFilteredProducts retrieves products from the database based on a set of filters.
 func (repo *DBRepository) GetFilteredProducts(filters models.ProductFilters) ([]models.Product, error) {
+	var products []models.Product
+	err := repo.EachFilteredProduct(filters, func(p models.Product) error {
+		products = append(products, p)
+		return nil
+	})
+	return products, err
+}
+
+// filteredColumns are the products columns read by EachFilteredProduct:
+// the titles and descriptions in both languages and the plain fields, but
+// not the HTML and JSON ones such as specifications and images.
+const filteredColumns = `id, COALESCE(source_site, ''), product_url, COALESCE(asin, ''), COALESCE(category, ''),
+	COALESCE(status, ''), COALESCE(deal_type, ''), COALESCE(title_english, ''), COALESCE(title_farsi, ''),
+	COALESCE(description_english, ''), COALESCE(description_farsi, ''), COALESCE(brand, ''),
+	COALESCE(availability, ''), COALESCE(availability_state, ''), COALESCE(stock_count, 0),
+	COALESCE(original_price, 0), COALESCE(discount_price, 0), COALESCE(discount_percent, 0), COALESCE(currency, ''),
+	COALESCE(effective_price, 0), COALESCE(effective_discount_percent, 0), COALESCE(main_image_url, ''),
+	COALESCE(country_of_origin, ''), COALESCE(model_number, ''), COALESCE(manufacturer, ''), COALESCE(ean, ''), COALESCE(upc, ''),
+	COALESCE(seller_name, ''), COALESCE(sold_by_amazon, FALSE), COALESCE(fulfilled_by_amazon, FALSE), COALESCE(prime_eligible, FALSE),
+	COALESCE(parent_asin, ''), COALESCE(rating, 0), COALESCE(rating_count, 0), scraped_at, refreshed_at`
+
+// EachFilteredProduct calls fn with every product matching filters, newest
+// first, reading them one at a time so large exports aren't held in memory.
+// An error from fn stops the iteration and is returned.
+func (repo *DBRepository) EachFilteredProduct(filters models.ProductFilters, fn func(models.Product) error) error {
 	var args []interface{}
 	var conditions []string
+	where := func(condition string, arg interface{}) {
+		conditions = append(conditions, condition)
+		args = append(args, arg)
+	}
 
-	query := `SELECT id, source_site, product_url, category, status, title_farsi, 
-	                 brand, availability, original_price, discount_price, 
-	                 discount_percent, main_image_url 
-	          FROM products WHERE 1=1`
+	query := `SELECT ` + filteredColumns + ` FROM products WHERE 1=1`
 
 	if filters.SourceSite != "" {
-		conditions = append(conditions, "source_site = ?")
-		args = append(args, filters.SourceSite)
+		where("source_site = ?", filters.SourceSite)
 	}
 	if filters.Category != "" {
-		conditions = append(conditions, "category = ?")
-		args = append(args, filters.Category)
+		where("category = ?", filters.Category)
 	}
 	if filters.Availability != "" {
-		conditions = append(conditions, "availability = ?")
-		args = append(args, filters.Availability)
+		where("availability = ?", filters.Availability)
+	}
+	if len(filters.Statuses) > 0 {
+		placeholders := make([]string, len(filters.Statuses))
+		for i, status := range filters.Statuses {
+			placeholders[i] = "?"
+			args = append(args, status)
+		}
+		conditions = append(conditions, "status IN ("+strings.Join(placeholders, ", ")+")")
 	}
 	if filters.MinOriginalPrice > 0 {
-		conditions = append(conditions, "original_price >= ?")
-		args = append(args, filters.MinOriginalPrice)
+		where("original_price >= ?", filters.MinOriginalPrice)
 	}
 	if filters.MaxOriginalPrice > 0 {
-		conditions = append(conditions, "original_price <= ?")
-		args = append(args, filters.MaxOriginalPrice)
+		where("original_price <= ?", filters.MaxOriginalPrice)
+	}
+	if filters.MinDiscountPrice > 0 {
+		where("discount_price >= ?", filters.MinDiscountPrice)
+	}
+	if filters.MaxDiscountPrice > 0 {
+		where("discount_price <= ?", filters.MaxDiscountPrice)
+	}
+	if filters.MinDiscountPercent > 0 {
+		where("discount_percent >= ?", filters.MinDiscountPercent)
+	}
+	if filters.MaxDiscountPercent > 0 {
+		where("discount_percent <= ?", filters.MaxDiscountPercent)
+	}
+	if !filters.ScrapedAfter.IsZero() {
+		where("scraped_at >= ?", sqldb.Timestamp(filters.ScrapedAfter))
+	}
+	if !filters.ScrapedBefore.IsZero() {
+		where("scraped_at < ?", sqldb.Timestamp(filters.ScrapedBefore))
+	}
+	if filters.SoldByAmazon {
+		where("sold_by_amazon = ?", true)
+	}
+	if filters.FulfilledByAmazon {
+		where("fulfilled_by_amazon = ?", true)
+	}
+	if filters.PrimeEligible {
+		where("prime_eligible = ?", true)
 	}
-	// Add more conditions for DiscountPrice and DiscountPercent if needed...
 
 	if len(conditions) > 0 {
 		query += " AND " + strings.Join(conditions, " AND ")
 	}
 
 	// Add ordering and pagination
-	query += " ORDER BY scraped_at DESC"
+	query += " ORDER BY scraped_at DESC, id DESC"
 	if filters.Limit > 0 {
 		query += " LIMIT ?"
 		args = append(args, filters.Limit)
@@ -346,29 +649,60 @@ func (repo *DBRepository) GetFilteredProducts(filters models.ProductFilters) ([]
 
 	rows, err := repo.DB.Query(query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute filtered query: %w", err)
+		return fmt.Errorf("failed to execute filtered query: %w", err)
 	}
 	defer rows.Close()
 
-	var products []models.Product
 	for rows.Next() {
 		var p models.Product
+		var scrapedAt, refreshedAt sql.NullTime
 		if err := rows.Scan(
-			&p.ID, &p.SourceSite, &p.ProductURL, &p.Category, &p.Status, &p.TitleFarsi,
-			&p.Brand, &p.Availability, &p.OriginalPrice, &p.DiscountPrice,
-			&p.DiscountPercent, &p.MainImageURL,
+			&p.ID, &p.SourceSite, &p.ProductURL, &p.ASIN, &p.Category,
+			&p.Status, &p.DealType, &p.TitleEnglish, &p.TitleFarsi,
+			&p.DescriptionEnglish, &p.DescriptionFarsi, &p.Brand,
+			&p.Availability, &p.AvailabilityState, &p.StockCount,
+			&p.OriginalPrice, &p.DiscountPrice, &p.DiscountPercent, &p.Currency,
+			&p.EffectivePrice, &p.EffectiveDiscountPercent, &p.MainImageURL,
+			&p.CountryOfOrigin, &p.ModelNumber, &p.Manufacturer, &p.EAN, &p.UPC,
+			&p.SellerName, &p.SoldByAmazon, &p.FulfilledByAmazon, &p.PrimeEligible,
+			&p.ParentASIN, &p.Rating, &p.RatingCount, &scrapedAt, &refreshedAt,
 		); err != nil {
-			log.Printf("Error scanning filtered product row: %v", err)
-			continue
+			return err
+		}
+		p.ScrapedAt = scrapedAt.Time
+		p.RefreshedAt = refreshedAt.Time
+		if err := fn(p); err != nil {
+			return err
 		}
-		products = append(products, p)
 	}
-	return products, nil
+	return rows.Err()
 }
 
-// GetProductsForDetailScrape retrieves products with the status 'needs_details'.
-func (repo *DBRepository) GetProductsForDetailScrape() ([]models.Product, error) {
-	rows, err := repo.DB.Query("SELECT id, product_url FROM products WHERE status = 'needs_details'")
+// GetProductIDsByStatus returns the IDs of the products in a status, oldest
+// first.
+func (repo *DBRepository) GetProductIDsByStatus(status models.ProductStatus) ([]int64, error) {
+	rows, err := repo.DB.Query("SELECT id FROM products WHERE status = ? ORDER BY id", status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// GetProductsForDetailScrape retrieves the products with the status
+// 'needs_details' among ids, or all of them when ids is nil.
+func (repo *DBRepository) GetProductsForDetailScrape(ids []int64) ([]models.Product, error) {
+	filter, args := idFilter(ids)
+	rows, err := repo.DB.Query("SELECT id, COALESCE(source_site, ''), product_url, COALESCE(asin, '') FROM products WHERE status = 'needs_details'"+filter, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -377,7 +711,7 @@ func (repo *DBRepository) GetProductsForDetailScrape() ([]models.Product, error)
 	var products []models.Product
 	for rows.Next() {
 		var p models.Product
-		if err := rows.Scan(&p.ID, &p.ProductURL); err != nil {
+		if err := rows.Scan(&p.ID, &p.SourceSite, &p.ProductURL, &p.ASIN); err != nil {
 			log.Printf("Error scanning incomplete product row: %v", err)
 			continue
 		}
@@ -386,22 +720,23 @@ func (repo *DBRepository) GetProductsForDetailScrape() ([]models.Product, error)
 	return products, nil
 }
 
-// UpdateProductStatus changes the status of a product by its ID.
-func (repo *DBRepository) UpdateProductStatus(id int64, newStatus string) error {
-	_, err := repo.DB.Exec("UPDATE products SET status = ? WHERE id = ?", newStatus, id)
-	return err
-}
-
-// GetCompletedProducts retrieves all products with the status 'completed'.
-func (repo *DBRepository) GetCompletedProducts() ([]models.Product, error) {
+// GetCompletedProducts retrieves the products with the status 'completed'
+// among ids, or all of them when ids is nil.
+func (repo *DBRepository) GetCompletedProducts(ids []int64) ([]models.Product, error) {
+	filter, args := idFilter(ids)
 	// Select all fields needed for the clean database
 	rows, err := repo.DB.Query(`
-		SELECT id, product_url, title_farsi, title_english, main_image_url, 
+		SELECT id, COALESCE(source_site, ''), product_url, title_farsi, title_english, main_image_url, 
 		original_price, discount_price, discount_percent, brand, availability,
-		description_farsi, specifications
+		description_farsi, specifications, specs,
+		COALESCE(country_of_origin, ''), model_number, manufacturer, ean, upc,
+		length_cm, width_cm, height_cm, weight_g, aplus_html, aplus_farsi,
+		seller_name, ships_from, sold_by_amazon, fulfilled_by_amazon, prime_eligible, delivery_estimate,
+		offers, effective_price, effective_discount_percent, parent_asin, variation_dimensions,
+		rating, rating_count, rating_histogram, currency, COALESCE(asin, ''),
+		COALESCE(availability_state, 'unknown'), COALESCE(stock_count, 0)
 		FROM products
-		WHERE status = 'completed'
-	`)
+		WHERE status = 'completed'`+filter, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -410,9 +745,15 @@ func (repo *DBRepository) GetCompletedProducts() ([]models.Product, error) {
 	var products []models.Product
 	for rows.Next() {
 		var p models.Product
-		err := rows.Scan(&p.ID, &p.ProductURL, &p.TitleFarsi, &p.TitleEnglish, &p.MainImageURL,
+		err := rows.Scan(&p.ID, &p.SourceSite, &p.ProductURL, &p.TitleFarsi, &p.TitleEnglish, &p.MainImageURL,
 			&p.OriginalPrice, &p.DiscountPrice, &p.DiscountPercent, &p.Brand, &p.Availability,
-			&p.DescriptionFarsi, &p.Specifications)
+			&p.DescriptionFarsi, &p.Specifications, &p.Specs,
+			&p.CountryOfOrigin, &p.ModelNumber, &p.Manufacturer, &p.EAN, &p.UPC,
+			&p.LengthCM, &p.WidthCM, &p.HeightCM, &p.WeightGrams, &p.APlusHTML, &p.APlusFarsi,
+			&p.SellerName, &p.ShipsFrom, &p.SoldByAmazon, &p.FulfilledByAmazon, &p.PrimeEligible, &p.DeliveryEstimate,
+			&p.Offers, &p.EffectivePrice, &p.EffectiveDiscountPercent, &p.ParentASIN, &p.VariationDimensions,
+			&p.Rating, &p.RatingCount, &p.RatingHistogram, &p.Currency, &p.ASIN,
+			&p.AvailabilityState, &p.StockCount)
 		if err != nil {
 			continue
 		}
@@ -0,0 +1,139 @@
+package database
+
+import (
+	"NovelScraper/internal/models"
+	"NovelScraper/internal/sqldb"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// transition moves a product to a new status inside tx and records the
+// change in product_events. Moving to the current status does nothing.
+func (repo *DBRepository) transition(tx *sqldb.Tx, id int64, to models.ProductStatus, reason string) error {
+	var from models.ProductStatus
+	if err := tx.QueryRow("SELECT COALESCE(status, '') FROM products WHERE id = ?", id).Scan(&from); err != nil {
+		return err
+	}
+	if from == to {
+		return nil
+	}
+	if !from.CanTransitionTo(to) {
+		return &models.TransitionError{ProductID: id, From: from, To: to}
+	}
+
+	if _, err := tx.Exec("UPDATE products SET status = ? WHERE id = ?", to, id); err != nil {
+		return err
+	}
+	_, err := tx.Exec(`INSERT INTO product_events (product_id, from_status, to_status, reason, run_id, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)`, id, from, to, reason, repo.RunID, time.Now())
+	return err
+}
+
+// UpdateProductStatus moves a product to a new status if the state machine
+// allows it, recording why.
+func (repo *DBRepository) UpdateProductStatus(id int64, to models.ProductStatus, reason string) error {
+	tx, err := repo.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := repo.transition(tx, id, to, reason); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// RequeueProducts moves the products matching filter back to an earlier
+// queue status. Products for which that is not a valid transition are
+// skipped. It returns how many products were moved and skipped.
+func (repo *DBRepository) RequeueProducts(filter models.RequeueFilter, to models.ProductStatus, reason string) (moved, skipped int, err error) {
+	if !models.IsQueueStatus(to) {
+		return 0, 0, fmt.Errorf("cannot requeue to %q", to)
+	}
+
+	query := "SELECT id FROM products WHERE COALESCE(status, '') != ?"
+	args := []interface{}{to}
+	if len(filter.Statuses) > 0 {
+		query += " AND status IN (" + strings.TrimSuffix(strings.Repeat("?, ", len(filter.Statuses)), ", ") + ")"
+		for _, s := range filter.Statuses {
+			args = append(args, s)
+		}
+	}
+	if filter.SourceSite != "" {
+		query += " AND source_site = ?"
+		args = append(args, filter.SourceSite)
+	}
+	if filter.Category != "" {
+		query += " AND category = ?"
+		args = append(args, filter.Category)
+	}
+	if !filter.ScrapedBefore.IsZero() {
+		query += " AND scraped_at < ?"
+		args = append(args, sqldb.Timestamp(filter.ScrapedBefore))
+	}
+	if filter.IDs != nil {
+		idCondition, idArgs := idFilter(filter.IDs)
+		query += idCondition
+		args = append(args, idArgs...)
+	}
+
+	rows, err := repo.DB.Query(query, args...)
+	if err != nil {
+		return 0, 0, err
+	}
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, 0, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+
+	tx, err := repo.DB.Begin()
+	if err != nil {
+		return 0, 0, err
+	}
+	defer tx.Rollback()
+
+	for _, id := range ids {
+		err := repo.transition(tx, id, to, reason)
+		if _, invalid := err.(*models.TransitionError); invalid {
+			log.Printf("Skipping requeue: %v", err)
+			skipped++
+			continue
+		}
+		if err != nil {
+			return 0, 0, err
+		}
+		moved++
+	}
+	return moved, skipped, tx.Commit()
+}
+
+// GetProductEvents returns the status history of a product, oldest first.
+func (repo *DBRepository) GetProductEvents(productID int64) ([]models.ProductEvent, error) {
+	rows, err := repo.DB.Query(`
+		SELECT id, from_status, to_status, reason, run_id, created_at
+		FROM product_events WHERE product_id = ? ORDER BY id`, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []models.ProductEvent
+	for rows.Next() {
+		e := models.ProductEvent{ProductID: productID}
+		if err := rows.Scan(&e.ID, &e.From, &e.To, &e.Reason, &e.RunID, &e.CreatedAt); err != nil {
+			log.Printf("Error scanning product event row: %v", err)
+			continue
+		}
+		events = append(events, e)
+	}
+	return events, nil
+}
@@ -0,0 +1,445 @@
+// Package migrate applies versioned schema migrations to a SQLite or
+// PostgreSQL database.
+//
+// Migrations are either SQL files named NNN_description.sql, embedded in the
+// binary by the package that owns the database, or Go functions for changes
+// that SQL alone cannot express. Applied versions are recorded in the
+// schema_migrations table.
+package migrate
+
+import (
+	"NovelScraper/internal/sqldb"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Migration is a single schema change. Exactly one of SQL or Up is set.
+type Migration struct {
+	Version int
+	Name    string
+	SQL     string
+	Up      func(tx *sql.Tx) error
+}
+
+// Status is a migration together with when it was applied, if it was.
+type Status struct {
+	Migration
+	AppliedAt time.Time
+	Applied   bool
+}
+
+// ErrPending is returned by Check when the database is behind the binary.
+var ErrPending = errors.New("database has pending migrations")
+
+var fileNameRegex = regexp.MustCompile(`^(\d+)_(\w+)\.sql$`)
+
+// Load reads the NNN_description.sql files in dir.
+func Load(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var migrations []Migration
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		matches := fileNameRegex.FindStringSubmatch(entry.Name())
+		if matches == nil {
+			return nil, fmt.Errorf("migration file %s is not named NNN_description.sql", entry.Name())
+		}
+		version, _ := strconv.Atoi(matches[1])
+		content, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, Migration{Version: version, Name: matches[2], SQL: string(content)})
+	}
+	return migrations, nil
+}
+
+// Migrator applies a fixed, ordered set of migrations to one database.
+type Migrator struct {
+	db         *sqldb.DB
+	migrations []Migration
+}
+
+// New checks that versions are unique and returns a Migrator that applies the
+// migrations in version order.
+func New(db *sqldb.DB, migrations []Migration) (*Migrator, error) {
+	sorted := append([]Migration(nil), migrations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+	for i, m := range sorted {
+		if m.Version <= 0 {
+			return nil, fmt.Errorf("migration %s has invalid version %d", m.Name, m.Version)
+		}
+		if i > 0 && sorted[i-1].Version == m.Version {
+			return nil, fmt.Errorf("migrations %s and %s share version %d", sorted[i-1].Name, m.Name, m.Version)
+		}
+		if (m.SQL == "") == (m.Up == nil) {
+			return nil, fmt.Errorf("migration %03d_%s must have either SQL or a Go function", m.Version, m.Name)
+		}
+	}
+	return &Migrator{db: db, migrations: sorted}, nil
+}
+
+func (m *Migrator) ensureTable() error {
+	timestamp := "DATETIME"
+	if m.db.Dialect == sqldb.Postgres {
+		timestamp = "TIMESTAMPTZ"
+	}
+	_, err := m.db.Exec(`
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		"version" INTEGER NOT NULL PRIMARY KEY,
+		"name" TEXT NOT NULL,
+		"applied_at" ` + timestamp + ` NOT NULL
+	);`)
+	return err
+}
+
+// Status lists every known migration and whether it has been applied. It
+// does not modify the database, so it is safe for status and dry-run output.
+func (m *Migrator) Status() ([]Status, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, len(m.migrations))
+	for i, migration := range m.migrations {
+		at, ok := applied[migration.Version]
+		statuses[i] = Status{Migration: migration, AppliedAt: at, Applied: ok}
+	}
+	return statuses, nil
+}
+
+// applied returns the applied versions; none if schema_migrations is missing.
+func (m *Migrator) applied() (map[int]time.Time, error) {
+	applied := make(map[int]time.Time)
+	query := "SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'schema_migrations'"
+	if m.db.Dialect == sqldb.Postgres {
+		query = "SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = current_schema() AND table_name = 'schema_migrations'"
+	}
+	var count int
+	if err := m.db.QueryRow(query).Scan(&count); err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return applied, nil
+	}
+
+	rows, err := m.db.Query("SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+	return applied, rows.Err()
+}
+
+// Pending returns the migrations that have not been applied yet, in order.
+func (m *Migrator) Pending() ([]Migration, error) {
+	statuses, err := m.Status()
+	if err != nil {
+		return nil, err
+	}
+	var pending []Migration
+	for _, s := range statuses {
+		if !s.Applied {
+			pending = append(pending, s.Migration)
+		}
+	}
+	return pending, nil
+}
+
+// Check returns an error wrapping ErrPending if any migration is pending.
+func (m *Migrator) Check() error {
+	pending, err := m.Pending()
+	if err != nil {
+		return err
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("%w: %d to apply, starting with %03d_%s", ErrPending, len(pending), pending[0].Version, pending[0].Name)
+	}
+	return nil
+}
+
+// Up applies all pending migrations, each in its own transaction, and
+// returns the ones it applied. It stops at the first failure.
+func (m *Migrator) Up() ([]Migration, error) {
+	if err := m.ensureTable(); err != nil {
+		return nil, err
+	}
+	pending, err := m.Pending()
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []Migration
+	for _, migration := range pending {
+		if err := m.apply(migration); err != nil {
+			return applied, fmt.Errorf("migration %03d_%s failed: %w", migration.Version, migration.Name, err)
+		}
+		applied = append(applied, migration)
+	}
+	return applied, nil
+}
+
+func (m *Migrator) apply(migration Migration) error {
+	tx, err := m.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if migration.Up != nil {
+		err = migration.Up(tx.Tx)
+	} else {
+		err = execScript(tx, migration.SQL)
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)",
+		migration.Version, migration.Name, time.Now()); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+var addColumnRegex = regexp.MustCompile(`(?is)^ALTER\s+TABLE\s+"?(\w+)"?\s+ADD\s+(?:COLUMN\s+)?"?(\w+)"?`)
+
+// execScript runs the statements of a SQL migration one by one. SQLite has no
+// ADD COLUMN IF NOT EXISTS, so columns that already exist are skipped; this
+// lets databases created before migrations existed, which already have some
+// of the columns, be brought under version control.
+func execScript(tx *sqldb.Tx, script string) error {
+	for _, stmt := range SplitStatements(script) {
+		if matches := addColumnRegex.FindStringSubmatch(stmt); matches != nil {
+			exists, err := ColumnExists(tx, matches[1], matches[2])
+			if err != nil {
+				return err
+			}
+			if exists {
+				continue
+			}
+		}
+		if _, err := tx.Exec(stmt); err != nil {
+			return fmt.Errorf("%w\n%s", err, stmt)
+		}
+	}
+	return nil
+}
+
+// ColumnExists reports whether table has the named column.
+func ColumnExists(tx *sqldb.Tx, table, column string) (bool, error) {
+	if tx.Dialect == sqldb.Postgres {
+		var exists bool
+		err := tx.QueryRow(`SELECT EXISTS(SELECT 1 FROM information_schema.columns
+			WHERE table_schema = current_schema() AND table_name = ? AND column_name = ?)`,
+			strings.ToLower(table), strings.ToLower(column)).Scan(&exists)
+		return exists, err
+	}
+
+	rows, err := tx.Query(fmt.Sprintf(`PRAGMA table_info("%s")`, table))
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, colType string
+		var defaultValue sql.NullString
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &defaultValue, &pk); err != nil {
+			return false, err
+		}
+		if strings.EqualFold(name, column) {
+			return true, nil
+		}
+	}
+	return false, rows.Err()
+}
+
+var triggerRegex = regexp.MustCompile(`(?is)^CREATE\s+(?:TEMP\s+|TEMPORARY\s+)?TRIGGER\b`)
+var triggerEndRegex = regexp.MustCompile(`(?is)\bEND$`)
+
+// SplitStatements splits a SQL script on semicolons, ignoring those inside
+// quotes, comments and CREATE TRIGGER ... END bodies. Comment-only
+// statements are dropped.
+func SplitStatements(script string) []string {
+	var statements []string
+	var current strings.Builder
+	var quote rune
+	inLineComment := false
+
+	flush := func() {
+		stmt := strings.TrimSpace(stripComments(current.String()))
+		if stmt != "" {
+			statements = append(statements, stmt)
+		}
+		current.Reset()
+	}
+
+	runes := []rune(script)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case inLineComment:
+			current.WriteRune(r)
+			if r == '\n' {
+				inLineComment = false
+			}
+			continue
+		case quote != 0:
+			current.WriteRune(r)
+			if r == quote {
+				quote = 0
+			}
+			continue
+		case r == '-' && i+1 < len(runes) && runes[i+1] == '-':
+			inLineComment = true
+		case r == '\'' || r == '"' || r == '`':
+			quote = r
+		case r == ';':
+			stmt := strings.TrimSpace(stripComments(current.String()))
+			if triggerRegex.MatchString(stmt) && !triggerEndRegex.MatchString(stmt) {
+				current.WriteRune(r)
+				continue
+			}
+			flush()
+			continue
+		}
+		current.WriteRune(r)
+	}
+	flush()
+	return statements
+}
+
+// stripComments removes -- comments outside of quotes.
+func stripComments(stmt string) string {
+	var b strings.Builder
+	var quote rune
+	runes := []rune(stmt)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		if quote == 0 && r == '-' && i+1 < len(runes) && runes[i+1] == '-' {
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+			if i < len(runes) {
+				b.WriteRune('\n')
+			}
+			continue
+		}
+		if quote != 0 && r == quote {
+			quote = 0
+		} else if quote == 0 && (r == '\'' || r == '"' || r == '`') {
+			quote = r
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// storedTimeFormats are the layouts SQLite DATETIME columns were written in
+// before sqldb.TimeFormat: the driver's default, time.Time.String without
+// its monotonic clock reading, and CURRENT_TIMESTAMP.
+var storedTimeFormats = []string{
+	"2006-01-02 15:04:05.999999999 -0700 MST",
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02T15:04:05.999999999-07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+}
+
+// parseStoredTime reads a time from a DATETIME column, as the driver
+// returned it.
+func parseStoredTime(value interface{}) (time.Time, bool) {
+	var s string
+	switch v := value.(type) {
+	case time.Time:
+		return v, true
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	default:
+		return time.Time{}, false
+	}
+	if i := strings.Index(s, " m="); i > 0 {
+		s = s[:i]
+	}
+	s = strings.TrimSuffix(strings.TrimSpace(s), "Z")
+	for _, layout := range storedTimeFormats {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// NormalizeTimes rewrites the times in columns of a SQLite table in
+// sqldb.TimeFormat, so queries comparing them as text see them in time
+// order. A row whose rewritten time collides with another under a unique
+// key replaces it, as both record the same moment. Values that are not
+// times are left alone.
+func NormalizeTimes(tx *sql.Tx, table string, columns ...string) error {
+	rows, err := tx.Query(fmt.Sprintf(`SELECT id, "%s" FROM "%s"`, strings.Join(columns, `", "`), table))
+	if err != nil {
+		return err
+	}
+	type update struct {
+		id     int64
+		column string
+		value  string
+	}
+	var updates []update
+	for rows.Next() {
+		var id int64
+		values := make([]interface{}, len(columns))
+		dest := []interface{}{&id}
+		for i := range values {
+			dest = append(dest, &values[i])
+		}
+		if err := rows.Scan(dest...); err != nil {
+			rows.Close()
+			return err
+		}
+		for i, value := range values {
+			if t, ok := parseStoredTime(value); ok {
+				updates = append(updates, update{id, columns[i], sqldb.Timestamp(t)})
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return err
+	}
+	rows.Close()
+
+	for _, u := range updates {
+		if _, err := tx.Exec(fmt.Sprintf(`UPDATE OR REPLACE "%s" SET "%s" = ? WHERE id = ?`, table, u.column), u.value, u.id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
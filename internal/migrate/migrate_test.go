@@ -0,0 +1,137 @@
+package migrate
+
+import (
+	"NovelScraper/internal/sqldb"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func openTestDB(t *testing.T) *sqldb.DB {
+	t.Helper()
+	db, err := sqldb.Open(sqldb.Config{Dialect: sqldb.SQLite, DSN: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatalf("failed to open test db: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestSplitStatements(t *testing.T) {
+	script := `-- leading comment; with a semicolon
+CREATE TABLE a (x TEXT DEFAULT ';');
+INSERT INTO a VALUES ('it''s; fine'); -- trailing comment
+CREATE TRIGGER a_ai AFTER INSERT ON a BEGIN
+	UPDATE a SET x = 'y';
+	DELETE FROM a WHERE x = 'z';
+END;
+-- only a comment;
+`
+	expected := []string{
+		"CREATE TABLE a (x TEXT DEFAULT ';')",
+		"INSERT INTO a VALUES ('it''s; fine')",
+		"CREATE TRIGGER a_ai AFTER INSERT ON a BEGIN\n\tUPDATE a SET x = 'y';\n\tDELETE FROM a WHERE x = 'z';\nEND",
+	}
+	if result := SplitStatements(script); !reflect.DeepEqual(result, expected) {
+		t.Errorf("SplitStatements() =\n%q\nwant\n%q", result, expected)
+	}
+}
+
+func TestMigratorUp(t *testing.T) {
+	db := openTestDB(t)
+
+	// A table created before migrations existed already has the new column.
+	if _, err := db.Exec(`CREATE TABLE items (id INTEGER PRIMARY KEY, name TEXT, price REAL)`); err != nil {
+		t.Fatal(err)
+	}
+
+	fsys := fstest.MapFS{
+		"migrations/001_baseline.sql": {Data: []byte("CREATE TABLE IF NOT EXISTS items (id INTEGER PRIMARY KEY, name TEXT);")},
+		"migrations/002_price.sql":    {Data: []byte(`ALTER TABLE items ADD COLUMN "price" REAL; ALTER TABLE items ADD COLUMN "currency" TEXT DEFAULT '';`)},
+	}
+	migrations, err := Load(fsys, "migrations")
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	goRan := 0
+	migrations = append(migrations, Migration{Version: 3, Name: "backfill", Up: func(tx *sql.Tx) error {
+		goRan++
+		_, err := tx.Exec("UPDATE items SET currency = 'AED'")
+		return err
+	}})
+
+	migrator, err := New(db, migrations)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	if err := migrator.Check(); !errors.Is(err, ErrPending) {
+		t.Errorf("Check() before Up = %v; want ErrPending", err)
+	}
+
+	applied, err := migrator.Up()
+	if err != nil {
+		t.Fatalf("Up() error: %v", err)
+	}
+	if len(applied) != 3 || goRan != 1 {
+		t.Errorf("Up() applied %d migrations and ran the Go migration %d times; want 3 and 1", len(applied), goRan)
+	}
+	if err := migrator.Check(); err != nil {
+		t.Errorf("Check() after Up = %v; want nil", err)
+	}
+
+	// Running again applies nothing.
+	if applied, err := migrator.Up(); err != nil || len(applied) != 0 {
+		t.Errorf("second Up() = %d, %v; want 0, nil", len(applied), err)
+	}
+
+	statuses, err := migrator.Status()
+	if err != nil {
+		t.Fatalf("Status() error: %v", err)
+	}
+	for _, s := range statuses {
+		if !s.Applied || s.AppliedAt.IsZero() {
+			t.Errorf("migration %03d_%s not recorded as applied", s.Version, s.Name)
+		}
+	}
+}
+
+func TestMigratorStopsOnFailure(t *testing.T) {
+	db := openTestDB(t)
+	migrator, err := New(db, []Migration{
+		{Version: 1, Name: "ok", SQL: "CREATE TABLE a (x TEXT);"},
+		{Version: 2, Name: "broken", SQL: "CREATE TABLE b (x TEXT); INSERT INTO missing VALUES (1);"},
+		{Version: 3, Name: "later", SQL: "CREATE TABLE c (x TEXT);"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	applied, err := migrator.Up()
+	if err == nil || len(applied) != 1 {
+		t.Fatalf("Up() = %d, %v; want 1 applied and an error", len(applied), err)
+	}
+
+	// The failed migration is rolled back as a whole.
+	var count int
+	db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE name = 'b'").Scan(&count)
+	if count != 0 {
+		t.Error("table b from the failed migration was not rolled back")
+	}
+	pending, _ := migrator.Pending()
+	if len(pending) != 2 || pending[0].Version != 2 {
+		t.Errorf("Pending() = %+v; want versions 2 and 3", pending)
+	}
+}
+
+func TestNewRejectsDuplicateVersions(t *testing.T) {
+	_, err := New(nil, []Migration{
+		{Version: 1, Name: "a", SQL: "SELECT 1"},
+		{Version: 1, Name: "b", SQL: "SELECT 1"},
+	})
+	if err == nil {
+		t.Error("New() accepted two migrations with the same version")
+	}
+}
@@ -0,0 +1,73 @@
+package models
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// AvailabilityState is the normalized form of a product's availability text.
+type AvailabilityState string
+
+const (
+	AvailabilityUnknown     AvailabilityState = "unknown"
+	AvailabilityInStock     AvailabilityState = "in_stock"
+	AvailabilityLowStock    AvailabilityState = "low_stock" // only a few left; see StockCount
+	AvailabilityOutOfStock  AvailabilityState = "out_of_stock"
+	AvailabilityUnavailable AvailabilityState = "unavailable" // listed but not sold, e.g. "Currently unavailable"
+	AvailabilityRemoved     AvailabilityState = "removed"     // the product page no longer exists
+)
+
+// Visibility of a published product, derived from its availability.
+const (
+	VisibilityVisible     = "visible"     // listed by the API
+	VisibilityHidden      = "hidden"      // kept but not listed until it is back in stock
+	VisibilityUnpublished = "unpublished" // taken off the site
+)
+
+// Visibility returns how a published product in this state is shown.
+func (s AvailabilityState) Visibility() string {
+	switch s {
+	case AvailabilityOutOfStock:
+		return VisibilityHidden
+	case AvailabilityUnavailable, AvailabilityRemoved:
+		return VisibilityUnpublished
+	default:
+		return VisibilityVisible
+	}
+}
+
+var lowStockRegexes = []*regexp.Regexp{
+	regexp.MustCompile(`only (\d+) left`),
+	regexp.MustCompile(`(\d+) left in stock`),
+	regexp.MustCompile(`تبقى (\d+)`),
+}
+
+// ParseAvailability normalizes the availability text of a product page,
+// returning the stock count for low-stock products.
+func ParseAvailability(text string) (AvailabilityState, int) {
+	text = strings.ToLower(strings.Join(strings.Fields(text), " "))
+	// "Currently unavailable ... back in stock" mentions stock and "not
+	// available" contains "available", so the unavailable and out-of-stock
+	// checks come first.
+	switch {
+	case text == "" || text == "unknown":
+		return AvailabilityUnknown, 0
+	case strings.Contains(text, "unavailable"), strings.Contains(text, "not available"),
+		strings.Contains(text, "no longer available"), strings.Contains(text, "غير متاح"):
+		return AvailabilityUnavailable, 0
+	case strings.Contains(text, "out of stock"), strings.Contains(text, "sold out"),
+		strings.Contains(text, "نفدت"), strings.Contains(text, "غير متوفر"):
+		return AvailabilityOutOfStock, 0
+	}
+	for _, re := range lowStockRegexes {
+		if m := re.FindStringSubmatch(text); m != nil {
+			count, _ := strconv.Atoi(m[1])
+			return AvailabilityLowStock, count
+		}
+	}
+	if strings.Contains(text, "in stock") || strings.Contains(text, "available") || strings.Contains(text, "متوفر") {
+		return AvailabilityInStock, 0
+	}
+	return AvailabilityUnknown, 0
+}
@@ -0,0 +1,33 @@
+package models
+
+import "testing"
+
+func TestParseAvailability(t *testing.T) {
+	testCases := []struct {
+		text          string
+		expected      AvailabilityState
+		expectedCount int
+	}{
+		{"In Stock", AvailabilityInStock, 0},
+		{"  In stock\n ", AvailabilityInStock, 0},
+		{"Only 3 left in stock - order soon.", AvailabilityLowStock, 3},
+		{"Only 3 left in stock", AvailabilityLowStock, 3},
+		{"12 left in stock", AvailabilityLowStock, 12},
+		{"Temporarily out of stock.", AvailabilityOutOfStock, 0},
+		{"Currently unavailable. We don't know when or if this item will be back in stock.", AvailabilityUnavailable, 0},
+		{"This item is no longer available", AvailabilityUnavailable, 0},
+		{"Temporarily unavailable", AvailabilityUnavailable, 0},
+		{"Not available", AvailabilityUnavailable, 0},
+		{"Available", AvailabilityInStock, 0},
+		{"متوفر", AvailabilityInStock, 0},
+		{"غير متوفر حاليًا", AvailabilityOutOfStock, 0},
+		{"Unknown", AvailabilityUnknown, 0},
+		{"", AvailabilityUnknown, 0},
+	}
+	for _, tc := range testCases {
+		state, count := ParseAvailability(tc.text)
+		if state != tc.expected || count != tc.expectedCount {
+			t.Errorf("ParseAvailability(%q) = %q, %d; want %q, %d", tc.text, state, count, tc.expected, tc.expectedCount)
+		}
+	}
+}
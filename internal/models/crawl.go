@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// CrawlState is the progress of a product list crawl, checkpointed after
+// every page or load-more round.
+type CrawlState struct {
+	URL        string // listing that is crawled; identifies the crawl
+	SourceSite string
+	Iterations int             // pages or load-more rounds done
+	Seen       map[string]bool // keys (see CrawlKey) of the products found so far
+	Previous   map[string]bool // keys found by the previous complete crawl
+	Done       bool
+	StartedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// NewCrawlState returns the state of a crawl that has not started yet.
+func NewCrawlState(url, sourceSite string) CrawlState {
+	return CrawlState{URL: url, SourceSite: sourceSite, Seen: make(map[string]bool), Previous: make(map[string]bool), StartedAt: time.Now()}
+}
+
+// CrawlKey identifies a product within the crawls of a listing: its ASIN
+// (or Noon SKU), or its URL when it has none.
+func CrawlKey(p Product) string {
+	if p.ASIN != "" {
+		return p.ASIN
+	}
+	return p.ProductURL
+}
+
+// Resumed reports whether the state continues an interrupted crawl.
+func (s CrawlState) Resumed() bool {
+	return s.Iterations > 0 && !s.Done
+}
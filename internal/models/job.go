@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// Pipeline stages that claim products through the job queue.
+const (
+	StageDetails   = "details"
+	StageTranslate = "translate"
+	StagePublish   = "publish"
+)
+
+// Job is one product waiting for one pipeline stage.
+type Job struct {
+	ID             int64
+	Stage          string
+	ProductID      int64
+	LeaseOwner     string
+	LeaseExpiresAt time.Time
+	Attempts       int // claims so far, including the current one
+	NextRunAt      time.Time
+	LastError      string
+}
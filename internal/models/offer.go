@@ -0,0 +1,114 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"math"
+)
+
+// OfferType is the kind of extra discount shown next to the price.
+type OfferType string
+
+const (
+	OfferCoupon        OfferType = "coupon"         // "Apply 10% coupon" checkbox
+	OfferSubscribeSave OfferType = "subscribe_save" // Subscribe & Save discount
+	OfferBank          OfferType = "bank_offer"     // bank or card instant discount
+	OfferPromotion     OfferType = "promotion"      // other promotions, e.g. "Save AED 20 on 2 items"
+)
+
+// Offer is a single coupon or promotion found on a product page.
+type Offer struct {
+	Type        OfferType `json:"type"`
+	Value       float64   `json:"value"` // a percentage if IsPercent, otherwise an amount
+	IsPercent   bool      `json:"is_percent"`
+	MaxDiscount float64   `json:"max_discount,omitempty"` // cap for percentage offers ("up to AED 100")
+	MinSpend    float64   `json:"min_spend,omitempty"`    // minimum purchase for the offer to apply
+	Conditions  string    `json:"conditions,omitempty"`   // the offer text as shown
+	ExpiresAt   string    `json:"expires_at,omitempty"`
+}
+
+// Discount returns how much the offer takes off the given price, or 0 if the
+// price does not reach the minimum spend.
+func (o Offer) Discount(price float64) float64 {
+	if price <= 0 || price < o.MinSpend {
+		return 0
+	}
+	discount := o.Value
+	if o.IsPercent {
+		discount = price * o.Value / 100
+		if o.MaxDiscount > 0 && discount > o.MaxDiscount {
+			discount = o.MaxDiscount
+		}
+	}
+	return math.Min(discount, price)
+}
+
+// OfferList is a list of offers, stored as a JSON array.
+type OfferList []Offer
+
+// EffectivePrice returns what a shopper actually pays after offers: the best
+// coupon is applied first, then the best bank offer or promotion on the
+// couponed price, the way Amazon stacks them at checkout. Subscribe & Save is
+// left out because it only applies to a repeat subscription.
+func (l OfferList) EffectivePrice(price float64) float64 {
+	best := func(price float64, types ...OfferType) float64 {
+		var max float64
+		for _, o := range l {
+			for _, t := range types {
+				if o.Type == t {
+					max = math.Max(max, o.Discount(price))
+				}
+			}
+		}
+		return max
+	}
+
+	price -= best(price, OfferCoupon)
+	price -= best(price, OfferBank, OfferPromotion)
+	return math.Round(price*100) / 100
+}
+
+// Value implements the driver.Valuer interface to store the list as JSON
+func (l OfferList) Value() (driver.Value, error) {
+	if l == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(l)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Scan implements the sql.Scanner interface to read the JSON list back
+func (l *OfferList) Scan(value interface{}) error {
+	if value == nil {
+		*l = nil
+		return nil
+	}
+	var bytes []byte
+	switch v := value.(type) {
+	case []byte:
+		bytes = v
+	case string:
+		bytes = []byte(v)
+	default:
+		return errors.New("unsupported type for OfferList")
+	}
+	if len(bytes) == 0 {
+		*l = nil
+		return nil
+	}
+	return json.Unmarshal(bytes, l)
+}
+
+// UpdateEffectivePrice recomputes EffectivePrice and EffectiveDiscountPercent
+// from the current prices and offers.
+func (p *Product) UpdateEffectivePrice() {
+	p.EffectivePrice = p.Offers.EffectivePrice(p.DiscountPrice)
+	p.EffectiveDiscountPercent = p.DiscountPercent
+	if p.OriginalPrice > 0 && p.EffectivePrice > 0 && p.EffectivePrice < p.OriginalPrice {
+		p.EffectiveDiscountPercent = int(((p.OriginalPrice - p.EffectivePrice) / p.OriginalPrice) * 100)
+	}
+}
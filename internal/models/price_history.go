@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// PriceSnapshot is one observation of a product's price and availability.
+type PriceSnapshot struct {
+	ProductID      int64     `json:"-"`
+	SourceSite     string    `json:"-"`
+	ASIN           string    `json:"-"`
+	Price          float64   `json:"price"` // what the customer pays (DiscountPrice)
+	OriginalPrice  float64   `json:"original_price"`
+	EffectivePrice float64   `json:"effective_price,omitempty"`
+	Currency       string    `json:"currency"`
+	Availability   string    `json:"availability,omitempty"`
+	ObservedAt     time.Time `json:"observed_at"`
+}
+
+// PriceStats summarizes the price history of a product.
+type PriceStats struct {
+	LowestPrice30d  float64   `json:"lowest_price_30d"`
+	HighestPrice30d float64   `json:"highest_price_30d"`
+	FirstSeen       time.Time `json:"first_seen"`
+	LastSeen        time.Time `json:"last_seen"`
+	Observations    int       `json:"observations"`
+}
+
+// Snapshot returns the product's current price observation.
+func (p *Product) Snapshot(observedAt time.Time) PriceSnapshot {
+	return PriceSnapshot{
+		ProductID:      p.ID,
+		SourceSite:     p.SourceSite,
+		ASIN:           p.ASIN,
+		Price:          p.DiscountPrice,
+		OriginalPrice:  p.OriginalPrice,
+		EffectivePrice: p.EffectivePrice,
+		Currency:       p.Currency,
+		Availability:   p.Availability,
+		ObservedAt:     observedAt,
+	}
+}
@@ -9,27 +9,92 @@ import (
 
 // Product تمام اطلاعات استخراج شده برای یک محصول را نگهداری می‌کند.
 type Product struct {
-	ID                 int64           `db:"id"`
-	SourceSite         string          `db:"source_site"`
-	ProductURL         string          `db:"product_url"`
-	Category           string          `db:"category"` // <-- ADD THIS LINE
-	Status             string          `db:"status"`   // <-- ADD THIS LINE
-	TitleEnglish       string          `db:"title_english"`
-	TitleFarsi         string          `db:"title_farsi"`
-	DescriptionEnglish string          `db:"description_english"`
-	DescriptionFarsi   string          `db:"description_farsi"`
-	Brand              string          `db:"brand"`
-	Availability       string          `db:"availability"`
-	OriginalPrice      float64         `db:"original_price"`
-	DiscountPrice      float64         `db:"discount_price"`
-	DiscountPercent    int             `db:"discount_percent"`
-	MainImageURL       string          `db:"main_image_url"`
-	GalleryImageURLs   JSONStringSlice `db:"gallery_image_urls"`
-	Specifications     string          `db:"specifications"`
-	CountryOfOrigin    string          `db:"country_of_origin"`
-	ScrapedAt          time.Time       `db:"scraped_at"`
-	PostedToWP         bool            `db:"posted_to_wp"`
-	WPPostID           int             `db:"wp_post_id"`
+	ID                       int64             `db:"id"`
+	SourceSite               string            `db:"source_site"`
+	ProductURL               string            `db:"product_url"` // canonical, e.g. https://www.amazon.ae/dp/{ASIN}
+	ASIN                     string            `db:"asin"`        // marketplace item ID; the Noon SKU for noon.com
+	Category                 string            `db:"category"`    // <-- ADD THIS LINE
+	Status                   ProductStatus     `db:"status"`      // <-- ADD THIS LINE
+	DealType                 string            `db:"deal_type"`   // one of the DealType constants, empty for regular deals
+	TitleEnglish             string            `db:"title_english"`
+	TitleFarsi               string            `db:"title_farsi"`
+	DescriptionEnglish       string            `db:"description_english"`
+	DescriptionFarsi         string            `db:"description_farsi"`
+	APlusHTML                string            `db:"aplus_html"`  // sanitized A+ / brand content
+	APlusFarsi               string            `db:"aplus_farsi"` // translated A+ content
+	Brand                    string            `db:"brand"`
+	Availability             string            `db:"availability"`       // as shown on the page
+	AvailabilityState        AvailabilityState `db:"availability_state"` // normalized from Availability
+	StockCount               int               `db:"stock_count"`        // items left, for low_stock only
+	OriginalPrice            float64           `db:"original_price"`
+	DiscountPrice            float64           `db:"discount_price"`
+	DiscountPercent          int               `db:"discount_percent"`
+	Currency                 string            `db:"currency"` // ISO 4217 code of all prices, e.g. AED
+	Offers                   OfferList         `db:"offers"`
+	EffectivePrice           float64           `db:"effective_price"` // DiscountPrice after coupons and offers
+	EffectiveDiscountPercent int               `db:"effective_discount_percent"`
+	MainImageURL             string            `db:"main_image_url"`
+	GalleryImageURLs         JSONStringSlice   `db:"gallery_image_urls"`
+	Specifications           string            `db:"specifications"` // rendered from Specs
+	Specs                    SpecList          `db:"specs"`
+	CountryOfOrigin          string            `db:"country_of_origin"`
+	ModelNumber              string            `db:"model_number"`
+	Manufacturer             string            `db:"manufacturer"`
+	EAN                      string            `db:"ean"`
+	UPC                      string            `db:"upc"`
+	LengthCM                 float64           `db:"length_cm"`
+	WidthCM                  float64           `db:"width_cm"`
+	HeightCM                 float64           `db:"height_cm"`
+	WeightGrams              float64           `db:"weight_g"`
+	SellerName               string            `db:"seller_name"`
+	SellerID                 string            `db:"seller_id"`
+	ShipsFrom                string            `db:"ships_from"`
+	SoldByAmazon             bool              `db:"sold_by_amazon"`
+	FulfilledByAmazon        bool              `db:"fulfilled_by_amazon"`
+	PrimeEligible            bool              `db:"prime_eligible"`
+	DeliveryEstimate         string            `db:"delivery_estimate"`
+	ParentASIN               string            `db:"parent_asin"`
+	VariationDimensions      JSONStringSlice   `db:"variation_dimensions"` // e.g. ["Colour", "Size"]
+	Variants                 []Variant         // stored in product_variants
+	Rating                   float64           `db:"rating"`
+	RatingCount              int               `db:"rating_count"`
+	RatingHistogram          RatingHistogram   `db:"rating_histogram"`
+	Reviews                  []Review          // stored in reviews
+	ScrapedAt                time.Time         `db:"scraped_at"`
+	RefreshedAt              time.Time         `db:"refreshed_at"` // last price/availability refresh
+	PostedToWP               bool              `db:"posted_to_wp"`
+	WPPostID                 int               `db:"wp_post_id"`
+}
+
+// Deal types shown on deal listings. Short-lived deals are refreshed more often.
+const (
+	DealTypeLightning    = "lightning"
+	DealTypeDealOfTheDay = "deal_of_the_day"
+	DealTypeLimitedTime  = "limited_time"
+)
+
+// LastRefreshedAt returns when the price and availability were last read.
+func (p Product) LastRefreshedAt() time.Time {
+	if p.RefreshedAt.After(p.ScrapedAt) {
+		return p.RefreshedAt
+	}
+	return p.ScrapedAt
+}
+
+// SetAvailability stores the availability text of the product page together
+// with its normalized state.
+func (p *Product) SetAvailability(text string) {
+	p.Availability = text
+	p.AvailabilityState, p.StockCount = ParseAvailability(text)
+}
+
+// NormalizedAvailability returns the availability state, parsing the text
+// for products that were built without SetAvailability.
+func (p Product) NormalizedAvailability() (AvailabilityState, int) {
+	if p.AvailabilityState != "" {
+		return p.AvailabilityState, p.StockCount
+	}
+	return ParseAvailability(p.Availability)
 }
 
 // JSONStringSlice is a custom type to handle JSON serialization/deserialization for []string
@@ -95,6 +160,18 @@ type ProductFilters struct {
 	MaxDiscountPrice   float64
 	MinDiscountPercent int
 	MaxDiscountPercent int
+	Statuses           []ProductStatus // any of these statuses, all when empty
+	ScrapedAfter       time.Time       // scraped at or after, when set
+	ScrapedBefore      time.Time       // scraped before, when set
+	// Only products matching these flags are returned when they are set.
+	SoldByAmazon      bool
+	FulfilledByAmazon bool
+	PrimeEligible     bool
+	// SortBy selects the ordering; "discount" ranks by effective discount
+	SortBy string
+	// Query is a full-text search over titles, brands and descriptions.
+	// Search results are ranked by relevance.
+	Query string
 	// For Pagination
 	Limit  int
 	Offset int
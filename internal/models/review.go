@@ -0,0 +1,55 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Review is a single customer review shown on a product page.
+type Review struct {
+	ReviewID     string    `json:"review_id,omitempty"`
+	Author       string    `json:"author"`
+	Stars        float64   `json:"stars"`
+	Title        string    `json:"title"`
+	Body         string    `json:"body"`
+	ReviewedAt   time.Time `json:"reviewed_at"`
+	Verified     bool      `json:"verified"`
+	HelpfulVotes int       `json:"helpful_votes"`
+}
+
+// RatingHistogram holds the percentage of ratings for each star level;
+// index 0 is 1 star and index 4 is 5 stars.
+type RatingHistogram [5]int
+
+// Value implements the driver.Valuer interface to store the histogram as JSON
+func (h RatingHistogram) Value() (driver.Value, error) {
+	b, err := json.Marshal(h)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Scan implements the sql.Scanner interface to read the JSON histogram back
+func (h *RatingHistogram) Scan(value interface{}) error {
+	if value == nil {
+		*h = RatingHistogram{}
+		return nil
+	}
+	var bytes []byte
+	switch v := value.(type) {
+	case []byte:
+		bytes = v
+	case string:
+		bytes = []byte(v)
+	default:
+		return errors.New("unsupported type for RatingHistogram")
+	}
+	if len(bytes) == 0 {
+		*h = RatingHistogram{}
+		return nil
+	}
+	return json.Unmarshal(bytes, h)
+}
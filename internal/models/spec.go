@@ -0,0 +1,76 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"html"
+	"strings"
+)
+
+// Spec is a single specification attribute, e.g. {"Material", "Cotton"}.
+type Spec struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// SpecList is an ordered list of specifications, stored as a JSON array.
+type SpecList []Spec
+
+// Get returns the value of the first spec whose key matches (case-insensitive).
+func (s SpecList) Get(key string) (string, bool) {
+	for _, spec := range s {
+		if strings.EqualFold(spec.Key, key) {
+			return spec.Value, true
+		}
+	}
+	return "", false
+}
+
+// HTML renders the specifications as a two-column table for publishing.
+func (s SpecList) HTML() string {
+	if len(s) == 0 {
+		return ""
+	}
+	var builder strings.Builder
+	builder.WriteString("<h2>Specifications</h2>\n<table>")
+	for _, spec := range s {
+		builder.WriteString("<tr><th>" + html.EscapeString(spec.Key) + "</th><td>" + html.EscapeString(spec.Value) + "</td></tr>")
+	}
+	builder.WriteString("</table>")
+	return builder.String()
+}
+
+// Value implements the driver.Valuer interface to store the list as JSON
+func (s SpecList) Value() (driver.Value, error) {
+	if s == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Scan implements the sql.Scanner interface to read the JSON list back
+func (s *SpecList) Scan(value interface{}) error {
+	if value == nil {
+		*s = nil
+		return nil
+	}
+	var bytes []byte
+	switch v := value.(type) {
+	case []byte:
+		bytes = v
+	case string:
+		bytes = []byte(v)
+	default:
+		return errors.New("unsupported type for SpecList")
+	}
+	if len(bytes) == 0 {
+		*s = nil
+		return nil
+	}
+	return json.Unmarshal(bytes, s)
+}
@@ -0,0 +1,116 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// ProductStatus is the pipeline stage a product is in.
+type ProductStatus string
+
+const (
+	StatusNeedsDetails      ProductStatus = "needs_details"
+	StatusNeedsTranslation  ProductStatus = "needs_translation"
+	StatusTranslationFailed ProductStatus = "translation_failed"
+	StatusCompleted         ProductStatus = "completed"
+	StatusPublishFailed     ProductStatus = "publish_failed" // could not be published; needs a requeue
+	StatusPublished         ProductStatus = "published"
+	StatusDeadLetter        ProductStatus = "dead_letter" // details could not be scraped; needs a requeue
+)
+
+// allowedTransitions lists the statuses each status may move to. Moving
+// forward follows the pipeline; moving back to an earlier queue status is a
+// requeue.
+var allowedTransitions = map[ProductStatus][]ProductStatus{
+	StatusNeedsDetails:      {StatusNeedsTranslation, StatusDeadLetter},
+	StatusNeedsTranslation:  {StatusCompleted, StatusTranslationFailed, StatusNeedsDetails},
+	StatusTranslationFailed: {StatusNeedsTranslation, StatusNeedsDetails},
+	StatusCompleted:         {StatusPublished, StatusPublishFailed, StatusNeedsTranslation, StatusNeedsDetails},
+	StatusPublishFailed:     {StatusCompleted, StatusNeedsTranslation, StatusNeedsDetails},
+	StatusPublished:         {StatusCompleted, StatusNeedsTranslation, StatusNeedsDetails},
+	StatusDeadLetter:        {StatusNeedsDetails},
+}
+
+// queueStatuses are the statuses a product can be requeued to.
+var queueStatuses = []ProductStatus{StatusNeedsDetails, StatusNeedsTranslation, StatusCompleted}
+
+// Known reports whether s is one of the statuses of the state machine.
+func (s ProductStatus) Known() bool {
+	_, ok := allowedTransitions[s]
+	return ok
+}
+
+// CanTransitionTo reports whether a product may move from s to next.
+// Products with a status from before the state machine existed may only be
+// requeued.
+func (s ProductStatus) CanTransitionTo(next ProductStatus) bool {
+	if !s.Known() {
+		return IsQueueStatus(next)
+	}
+	for _, allowed := range allowedTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// IsQueueStatus reports whether s is a status products can be requeued to.
+func IsQueueStatus(s ProductStatus) bool {
+	for _, q := range queueStatuses {
+		if q == s {
+			return true
+		}
+	}
+	return false
+}
+
+// TransitionError is returned when a status change is not allowed.
+type TransitionError struct {
+	ProductID int64
+	From, To  ProductStatus
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("product %d cannot move from %q to %q", e.ProductID, e.From, e.To)
+}
+
+// ProductEvent is one recorded status change of a product.
+type ProductEvent struct {
+	ID        int64
+	ProductID int64
+	From      ProductStatus
+	To        ProductStatus
+	Reason    string
+	RunID     string // the run that made the change
+	CreatedAt time.Time
+}
+
+// RequeueFilter selects the products a requeue moves. Empty fields match
+// every product.
+type RequeueFilter struct {
+	Statuses      []ProductStatus
+	SourceSite    string
+	Category      string
+	ScrapedBefore time.Time
+	IDs           []int64
+}
+
+// FailureSummary counts the products whose details failed for one reason.
+type FailureSummary struct {
+	Reason     string
+	Products   int // products whose last scrape failed for this reason
+	DeadLetter int // of which have been given up on
+	Attempts   int // failed attempts across those products
+}
+
+// FailedProduct is a product whose last detail scrape failed.
+type FailedProduct struct {
+	ID         int64
+	ProductURL string
+	Status     ProductStatus
+	Reason     string
+	Attempts   int
+	LastError  string
+	FailedAt   time.Time
+}
@@ -0,0 +1,29 @@
+package models
+
+import "testing"
+
+func TestCanTransitionTo(t *testing.T) {
+	testCases := []struct {
+		from, to ProductStatus
+		expected bool
+	}{
+		{StatusNeedsDetails, StatusNeedsTranslation, true},
+		{StatusNeedsDetails, StatusPublished, false},
+		{StatusNeedsTranslation, StatusCompleted, true},
+		{StatusNeedsTranslation, StatusTranslationFailed, true},
+		{StatusTranslationFailed, StatusCompleted, false},
+		{StatusCompleted, StatusPublished, true},
+		{StatusPublished, StatusNeedsDetails, true},
+		{StatusPublished, StatusTranslationFailed, false},
+		{StatusNeedsDetails, StatusDeadLetter, true},
+		{StatusDeadLetter, StatusNeedsDetails, true},
+		{StatusDeadLetter, StatusNeedsTranslation, false},
+		{"legacy", StatusNeedsDetails, true},
+		{"legacy", StatusPublished, false},
+	}
+	for _, tc := range testCases {
+		if got := tc.from.CanTransitionTo(tc.to); got != tc.expected {
+			t.Errorf("%q.CanTransitionTo(%q) = %v, want %v", tc.from, tc.to, got, tc.expected)
+		}
+	}
+}
@@ -0,0 +1,11 @@
+package models
+
+// Variant is one child of a product with variations (e.g. a size/colour
+// combination), identified by its own ASIN.
+type Variant struct {
+	ASIN         string   `json:"asin"`
+	Attributes   SpecList `json:"attributes"` // e.g. Colour: White, Size: UK 8
+	Price        float64  `json:"price,omitempty"`
+	Availability string   `json:"availability,omitempty"`
+	URL          string   `json:"url,omitempty"`
+}
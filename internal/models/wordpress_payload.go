@@ -7,13 +7,66 @@ type WordpressResponse struct {
 }
 
 type WordpressProduct struct {
-	ID              string  `json:"id"` // ASIN محصول به عنوان ID
-	Title           string  `json:"title"`
-	Slug            string  `json:"slug"`
-	ImageURL        string  `json:"image_url"`
-	RealPrice       float64 `json:"real_price"`
-	DiscountedPrice float64 `json:"discounted_price"`
-	LinkOfProduct   string  `json:"link_of_product"`
+	ID                       string            `json:"id"` // ASIN محصول به عنوان ID
+	SourceSite               string            `json:"source_site"`
+	Title                    string            `json:"title"`
+	Slug                     string            `json:"slug"`
+	ImageURL                 string            `json:"image_url"`
+	RealPrice                float64           `json:"real_price"`
+	DiscountedPrice          float64           `json:"discounted_price"`
+	Currency                 string            `json:"currency"` // کد ISO 4217 برای همه‌ی قیمت‌ها
+	LinkOfProduct            string            `json:"link_of_product"`
+	Attributes               SpecList          `json:"attributes,omitempty"`
+	ModelNumber              string            `json:"model_number,omitempty"`
+	EAN                      string            `json:"ean,omitempty"`
+	UPC                      string            `json:"upc,omitempty"`
+	CountryOfOrigin          string            `json:"country_of_origin,omitempty"`
+	LengthCM                 float64           `json:"length_cm,omitempty"`
+	WidthCM                  float64           `json:"width_cm,omitempty"`
+	HeightCM                 float64           `json:"height_cm,omitempty"`
+	WeightGrams              float64           `json:"weight_g,omitempty"`
+	APlusHTML                string            `json:"aplus_html,omitempty"`
+	SellerName               string            `json:"seller_name,omitempty"`
+	SoldByAmazon             bool              `json:"sold_by_amazon"`
+	FulfilledByAmazon        bool              `json:"fulfilled_by_amazon"`
+	PrimeEligible            bool              `json:"prime_eligible"`
+	DeliveryEstimate         string            `json:"delivery_estimate,omitempty"`
+	Offers                   OfferList         `json:"offers,omitempty"`
+	EffectivePrice           float64           `json:"effective_price"`
+	EffectiveDiscountPercent int               `json:"effective_discount_percent"`
+	ProductType              string            `json:"product_type"` // simple یا variable
+	ParentASIN               string            `json:"parent_asin,omitempty"`
+	VariationDimensions      JSONStringSlice   `json:"variation_dimensions,omitempty"`
+	Variants                 []Variant         `json:"variants,omitempty"`
+	Rating                   float64           `json:"rating"`
+	RatingCount              int               `json:"rating_count"`
+	RatingHistogram          RatingHistogram   `json:"rating_histogram"`
+	LowestPrice30d           float64           `json:"lowest_price_30d"`
+	AvailabilityState        AvailabilityState `json:"availability_state"`
+	StockCount               int               `json:"stock_count,omitempty"`
+	Match                    *SearchMatch      `json:"match,omitempty"` // only set in search results
+}
+
+// SearchMatch is how a product matched a q= search. The marked text is
+// the normalized text of the search index, escaped for HTML, with matches
+// in <mark> tags.
+type SearchMatch struct {
+	Score   float64 `json:"score"`   // higher is more relevant
+	Title   string  `json:"title"`   // the Persian title with the matches marked
+	Snippet string  `json:"snippet"` // the passage that matched best
+}
+
+// PriceHistoryResponse is returned by the /price-history endpoint.
+type PriceHistoryResponse struct {
+	ASIN    string          `json:"asin"`
+	Stats   PriceStats      `json:"stats"`
+	History []PriceSnapshot `json:"history"`
+}
+
+// ReviewsResponse is returned by the /reviews endpoint.
+type ReviewsResponse struct {
+	ASIN    string   `json:"asin"`
+	Reviews []Review `json:"reviews"`
 }
 
 type Pagination struct {
@@ -2,6 +2,7 @@ package processor
 
 import (
 	"NovelScraper/internal/models"
+	"NovelScraper/utils"
 	"bytes"
 	"encoding/json"
 	"errors"
@@ -26,6 +27,7 @@ type WordpressPostPayload struct {
 	EnglishTitle  string   `json:"english_title"`
 	Content       string   `json:"content"`
 	DetailsHTML   string   `json:"details_html"`
+	APlusHTML     string   `json:"aplus_html"`
 	OriginalPrice float64  `json:"original_price"`
 	SalePrice     float64  `json:"sale_price"`
 	Brand         string   `json:"brand"`
@@ -54,6 +56,7 @@ func (c *WordpressClient) CreatePost(product models.Product) (string, error) {
 		EnglishTitle:  product.TitleEnglish,
 		Content:       product.DescriptionFarsi,
 		DetailsHTML:   product.Specifications,
+		APlusHTML:     utils.SanitizeHTML(product.APlusFarsi),
 		OriginalPrice: product.OriginalPrice,
 		SalePrice:     product.DiscountPrice,
 		Brand:         product.Brand,
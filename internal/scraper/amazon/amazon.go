@@ -7,6 +7,9 @@ import (
 	"github.com/go-rod/rod"
 )
 
+// SourceSite is the value stored in products.source_site for Amazon products.
+const SourceSite = "amazon.ae"
+
 // AmazonScraper now holds the correct, named config structs.
 type AmazonScraper struct {
 	Browser     *rod.Browser
@@ -23,7 +26,19 @@ func New(browser *rod.Browser, scraperConf config.ScraperConfig, amazonConf conf
 	}
 }
 
+// defaultMaxReviews is used when amazon.max_reviews is not set.
+const defaultMaxReviews = 10
+
 // ScrapeProductDetails remains the same.
 func (s *AmazonScraper) ScrapeProductDetails(product *models.Product) error {
-	return ScrapeProductDetails(s.Browser, product)
+	maxReviews := s.AmazonConf.MaxReviews
+	if maxReviews <= 0 {
+		maxReviews = defaultMaxReviews
+	}
+	return ScrapeProductDetails(s.Browser, product, maxReviews)
+}
+
+// RefreshProduct updates the price, offers and availability of a product.
+func (s *AmazonScraper) RefreshProduct(product *models.Product) error {
+	return RefreshProduct(s.Browser, product)
 }
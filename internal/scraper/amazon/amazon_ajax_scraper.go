@@ -78,7 +78,7 @@ func parseCategoriesFromHTML(htmlContent string) []models.Category {
 						categories = append(categories, models.Category{
 							Name:       categoryName,
 							Node:       nodeID,
-							SourceSite: "amazon.ae",
+							SourceSite: SourceSite,
 						})
 					}
 					break
@@ -2,7 +2,10 @@ package amazon
 
 import (
 	"NovelScraper/internal/models"
+	"NovelScraper/internal/scraper"
+	"NovelScraper/utils"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"math/rand"
@@ -157,38 +160,63 @@ func humanlikeScroll(page *rod.Page) error {
 	return nil
 }
 
+// maxDealsRounds caps the load-more rounds of one deals grid crawl.
+const maxDealsRounds = 100
+
 // ScrapeDealsGrid navigates to the encoded URL, scrolls/clicks load more until completion, and collects product links/titles/discounts.
-func (s *AmazonDealsScraper) ScrapeDealsGrid(targetURL string) ([]models.Product, error) {
+// Every product is handed to crawl as soon as it is found and progress is
+// checkpointed after each round. The grid always loads from the top, so a
+// resumed crawl goes through the rounds it already did again, skipping the
+// products it has seen, before it finds new ones.
+func (s *AmazonDealsScraper) ScrapeDealsGrid(targetURL string, crawl scraper.Crawl) error {
+	state, err := crawl.Resume(targetURL)
+	if err != nil {
+		return fmt.Errorf("failed to load crawl checkpoint: %w", err)
+	}
+	state.SourceSite = SourceSite
+	resumeRounds := 0
+	if state.Resumed() {
+		resumeRounds = state.Iterations
+	}
+
 	page, err := stealth.Page(s.Browser)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer page.MustClose()
 
 	if err := page.Timeout(40 * time.Second).Navigate(targetURL); err != nil {
-		return nil, err
+		return err
 	}
 	page.MustWaitLoad()
 	log.Println("Successfully navigated to the filtered deals page.")
 
-	seenProducts := make(map[string]bool)
-	var products []models.Product
+	seenProducts := state.Seen
 	discountRe := regexp.MustCompile(`(\d+)%`)
 
 	stuckCounter := 0
+	// A crawl that ends on a page error is left unfinished, so the next
+	// crawl of the URL resumes it.
+	interrupted := false
+	stopped := false
+
+	for i := 0; i < maxDealsRounds; i++ {
+		if i == resumeRounds && resumeRounds > 0 {
+			log.Printf("Reached the checkpoint after %d rounds, collecting new products.", resumeRounds)
+		}
 
-	for i := 0; i < 100; i++ {
 		// Use JavaScript to get the page's content height.
 		previousHeightRes, err := page.Eval(`() => document.documentElement.scrollHeight`)
 		if err != nil {
 			log.Printf("Warning: could not get page height: %v", err)
+			interrupted = true
 			break
 		}
 		previousHeight := previousHeightRes.Value.Num()
 
 		// Collect currently visible products
 		cards, _ := page.Elements("div[data-testid='product-card']")
-		newlyFoundCount := 0
+		var found []models.Product
 		for _, card := range cards {
 			linkEl, err := card.Element("a[data-testid='product-card-link']")
 			if err != nil {
@@ -200,19 +228,20 @@ func (s *AmazonDealsScraper) ScrapeDealsGrid(targetURL string) ([]models.Product
 			}
 
 			href := *hrefAttr
-			if seenProducts[href] {
-				continue
-			}
-
-			seenProducts[href] = true
-			newlyFoundCount++
-
 			fullURL := href
 			if !strings.HasPrefix(fullURL, "http") {
 				fullURL = s.BaseURL + strings.TrimPrefix(fullURL, "/")
 			}
 
-			p := models.Product{ProductURL: fullURL, SourceSite: "amazon.ae"}
+			// The same deal is linked with different ref= parameters, so
+			// products are told apart by ASIN where the link has one.
+			p := models.Product{ProductURL: utils.CanonicalAmazonURL(fullURL), ASIN: utils.ExtractASIN(fullURL), SourceSite: SourceSite}
+			key := models.CrawlKey(p)
+			if seenProducts[key] {
+				continue
+			}
+
+			seenProducts[key] = true
 
 			if tEl, err := card.Element("p[id^='title-']"); err == nil {
 				p.TitleEnglish = strings.TrimSpace(tEl.MustText())
@@ -226,11 +255,29 @@ func (s *AmazonDealsScraper) ScrapeDealsGrid(targetURL string) ([]models.Product
 					}
 				}
 			}
-			products = append(products, p)
+			if cardText, err := card.Text(); err == nil {
+				p.DealType = dealTypeFromBadge(cardText)
+			}
+			found = append(found, p)
+		}
+		if err := crawl.Found(found); errors.Is(err, scraper.ErrStopCrawl) {
+			stopped = true
+		} else if err != nil {
+			return err
 		}
 
-		if newlyFoundCount > 0 {
-			log.Printf("Found %d new products. Total collected: %d", newlyFoundCount, len(products))
+		if len(found) > 0 {
+			log.Printf("Found %d new products. Total collected: %d", len(found), len(seenProducts))
+		}
+		if i+1 > state.Iterations {
+			state.Iterations = i + 1
+		}
+		if err := crawl.Checkpoint(state); err != nil {
+			log.Printf("Warning: could not save crawl checkpoint: %v", err)
+		}
+		if stopped {
+			log.Println("Only known products are left. Ending scrape.")
+			break
 		}
 
 		// Check the footer state to decide what to do next
@@ -277,6 +324,7 @@ func (s *AmazonDealsScraper) ScrapeDealsGrid(targetURL string) ([]models.Product
 		newHeightRes, err := page.Eval(`() => document.documentElement.scrollHeight`)
 		if err != nil {
 			log.Printf("Warning: could not get page height after scroll: %v", err)
+			interrupted = true
 			break
 		}
 		newHeight := newHeightRes.Value.Num()
@@ -293,5 +341,25 @@ func (s *AmazonDealsScraper) ScrapeDealsGrid(targetURL string) ([]models.Product
 		}
 	}
 
-	return products, nil
+	state.Done = !interrupted
+	if err := crawl.Checkpoint(state); err != nil {
+		log.Printf("Warning: could not save crawl checkpoint: %v", err)
+	}
+	return nil
+}
+
+// dealTypeFromBadge returns the deal type named by a deal card's badge text,
+// or "" for a regular discount.
+func dealTypeFromBadge(text string) string {
+	text = strings.ToLower(text)
+	switch {
+	case strings.Contains(text, "lightning deal"):
+		return models.DealTypeLightning
+	case strings.Contains(text, "deal of the day"):
+		return models.DealTypeDealOfTheDay
+	case strings.Contains(text, "limited time deal"):
+		return models.DealTypeLimitedTime
+	default:
+		return ""
+	}
 }
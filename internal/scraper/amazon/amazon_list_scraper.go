@@ -2,6 +2,7 @@ package amazon
 
 import (
 	"NovelScraper/internal/models"
+	"NovelScraper/internal/scraper"
 	"bufio"
 	"fmt"
 	"log"
@@ -14,23 +15,29 @@ import (
 )
 
 // ScrapeProductList handles the interactive process of scraping the Amazon deals page.
-func (s *AmazonScraper) ScrapeProductList() ([]models.Product, error) {
+func (s *AmazonScraper) ScrapeProductList(crawl scraper.Crawl) error {
 	log.Println("Starting Amazon DEALS page scraping...")
 
 	reader := bufio.NewReader(os.Stdin)
 
-	tempLauncher := launcher.New().Headless(s.ScraperConf.Headless).MustLaunch()
-	tempBrowser := rod.New().ControlURL(tempLauncher).MustConnect()
+	tempLauncher, err := launcher.New().Headless(s.ScraperConf.Headless).Launch()
+	if err != nil {
+		return fmt.Errorf("failed to launch browser: %w", err)
+	}
+	tempBrowser := rod.New().ControlURL(tempLauncher)
+	if err := tempBrowser.Connect(); err != nil {
+		return fmt.Errorf("failed to connect to browser: %w", err)
+	}
 
 	dealsScraper := NewAmazonDealsScraper(tempBrowser, s.AmazonConf.BaseURL)
 	departments, err := dealsScraper.CollectDepartments()
 	tempBrowser.MustClose()
 
 	if err != nil {
-		return nil, fmt.Errorf("failed to collect departments: %w", err)
+		return fmt.Errorf("failed to collect departments: %w", err)
 	}
 	if len(departments) == 0 {
-		return nil, fmt.Errorf("no departments found on deals page")
+		return fmt.Errorf("no departments found on deals page")
 	}
 
 	fmt.Println("Available departments:")
@@ -61,19 +68,25 @@ func (s *AmazonScraper) ScrapeProductList() ([]models.Product, error) {
 	dealsScraperForGrid := NewAmazonDealsScraper(s.Browser, s.AmazonConf.BaseURL)
 	encodedURL, err := dealsScraperForGrid.BuildDoubleEncodedDealsURL(chosenDept.Value, minPrice, maxPrice, minOff, maxOff)
 	if err != nil {
-		return nil, fmt.Errorf("failed to build deals URL: %w", err)
+		return fmt.Errorf("failed to build deals URL: %w", err)
 	}
 	log.Println("Constructed Target URL:", encodedURL)
 
-	products, err := dealsScraperForGrid.ScrapeDealsGrid(encodedURL)
-	if err != nil {
-		return nil, fmt.Errorf("failed to scrape deals grid: %w", err)
+	if err := dealsScraperForGrid.ScrapeDealsGrid(encodedURL, categoryCrawl{crawl, chosenDept.Label}); err != nil {
+		return fmt.Errorf("failed to scrape deals grid: %w", err)
 	}
+	return nil
+}
+
+// categoryCrawl files every product found in a department under it.
+type categoryCrawl struct {
+	scraper.Crawl
+	category string
+}
 
+func (c categoryCrawl) Found(products []models.Product) error {
 	for i := range products {
-		products[i].Category = chosenDept.Label
-		products[i].SourceSite = "amazon.ae"
+		products[i].Category = c.category
 	}
-
-	return products, nil
+	return c.Crawl.Found(products)
 }
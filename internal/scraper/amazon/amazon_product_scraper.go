@@ -2,6 +2,7 @@ package amazon
 
 import (
 	"NovelScraper/internal/models"
+	"NovelScraper/internal/scraper"
 	"NovelScraper/utils"
 	"encoding/json"
 	"fmt"
@@ -25,24 +26,128 @@ type ImageInfo struct {
 	Main  map[string][]int `json:"main"`
 }
 
-// ScrapeProductDetails extracts all details from a single product page.
-func ScrapeProductDetails(browser *rod.Browser, product *models.Product) error {
-	if product.TitleEnglish != "" || !product.ScrapedAt.IsZero() {
-		log.Printf("Product %s already scraped, skipping", product.ProductURL)
-		return nil
+// ScrapeProductDetails extracts all details from a single product page,
+// keeping at most maxReviews of the top reviews.
+func ScrapeProductDetails(browser *rod.Browser, product *models.Product, maxReviews int) error {
+	if product.ASIN == "" {
+		product.ASIN = utils.ExtractASIN(product.ProductURL)
 	}
 
 	log.Printf("Starting to scrape %s", product.ProductURL)
-	page := browser.MustPage(product.ProductURL)
+	page, err := openProductPage(browser, product.ProductURL)
+	if err != nil {
+		return err
+	}
+	defer page.MustClose()
+
+	// Scrape all data points using robust helper functions
+	log.Println("Starting title extraction")
+	product.TitleEnglish = extractTitle(page)
+	log.Printf("Title extraction completed: %s", product.TitleEnglish)
+
+	log.Println("Starting brand extraction")
+	product.Brand = extractBrand(page)
+	log.Printf("Brand extraction completed: %s", product.Brand)
+
+	log.Println("Starting availability extraction")
+	product.SetAvailability(extractAvailability(page))
+	log.Printf("Availability extraction completed: %s", product.Availability)
+
+	log.Println("Starting price extraction")
+	locale := utils.LocaleForSite(product.ProductURL)
+	product.OriginalPrice, product.DiscountPrice, product.DiscountPercent, product.Currency = extractPrices(page, locale)
+	log.Printf("Price extraction completed: Original=%f, Discount=%f, Percent=%d, Currency=%s", product.OriginalPrice, product.DiscountPrice, product.DiscountPercent, product.Currency)
+
+	log.Println("Starting image extraction")
+	product.MainImageURL, product.GalleryImageURLs = extractGallery(page)
+	log.Printf("Image extraction completed: Main=%s, Gallery=%d images", product.MainImageURL, len(product.GalleryImageURLs))
+
+	log.Println("Starting details extraction")
+	doc, err := pageDocument(page)
+	if err != nil {
+		return scraper.Fail(scraper.FailureParse, "failed to read page html for %s: %v", product.ProductURL, err)
+	}
+	product.Specs = extractSpecifications(doc)
+	product.Specifications = product.Specs.HTML()
+	scraper.FillIdentifiers(product)
+	product.DescriptionEnglish = extractDescription(page)
+	product.APlusHTML = extractAPlus(doc)
+	extractFulfillment(doc, product)
+	product.Offers = extractOffers(doc, locale)
+	product.UpdateEffectivePrice()
+	product.ParentASIN, product.VariationDimensions, product.Variants = extractVariations(doc, product.ProductURL)
+	extractRatings(doc, product)
+	product.Reviews = extractReviews(doc, maxReviews)
+	log.Printf("Rating extraction completed: %.1f stars from %d ratings, %d reviews", product.Rating, product.RatingCount, len(product.Reviews))
+	log.Printf("Offer extraction completed: %d offers, Effective=%.2f (%d%%)", len(product.Offers), product.EffectivePrice, product.EffectiveDiscountPercent)
+	log.Printf("Seller extraction completed: Seller=%s, ShipsFrom=%s, Prime=%t, Delivery=%s", product.SellerName, product.ShipsFrom, product.PrimeEligible, product.DeliveryEstimate)
+	log.Printf("Details extraction completed: Specs=%d attributes, Desc=%d chars, A+=%d chars", len(product.Specs), len(product.DescriptionEnglish), len(product.APlusHTML))
+
+	product.ScrapedAt = time.Now()
+
+	if product.TitleEnglish == "" {
+		log.Println("No title extracted, scraping likely failed")
+		return scraper.Fail(scraper.FailureParse, "failed to extract a title, scraping likely failed for %s", product.ProductURL)
+	}
+
+	log.Printf("Successfully scraped details for: %s", product.TitleEnglish)
+	return nil
+}
+
+// RefreshProduct re-reads only the fields that change while a deal runs:
+// availability, prices and offers. Text and images are left untouched, so a
+// refreshed product does not need to be translated again.
+func RefreshProduct(browser *rod.Browser, product *models.Product) error {
+	log.Printf("Refreshing %s", product.ProductURL)
+	page, err := openProductPage(browser, product.ProductURL)
+	if err != nil {
+		return err
+	}
 	defer page.MustClose()
 
+	locale := utils.LocaleForSite(product.ProductURL)
+	product.SetAvailability(extractAvailability(page))
+	product.OriginalPrice, product.DiscountPrice, product.DiscountPercent, product.Currency = extractPrices(page, locale)
+
+	doc, err := pageDocument(page)
+	if err != nil {
+		return scraper.Fail(scraper.FailureParse, "failed to read page html for %s: %v", product.ProductURL, err)
+	}
+	product.Offers = extractOffers(doc, locale)
+	product.UpdateEffectivePrice()
+
+	if product.DiscountPrice == 0 && product.Availability == "Unknown" {
+		return scraper.Fail(scraper.FailureParse, "no price or availability found, refresh likely failed for %s", product.ProductURL)
+	}
+	log.Printf("Refreshed %s: Price=%.2f, Availability=%s", product.ProductURL, product.DiscountPrice, product.Availability)
+	return nil
+}
+
+// --- Helper Functions ---
+
+// openProductPage opens a product page and waits until the product container
+// is visible, getting past Amazon's robot check where possible. The caller
+// closes the page.
+func openProductPage(browser *rod.Browser, productURL string) (*rod.Page, error) {
+	page, err := browser.Page(proto.TargetCreateTarget{URL: productURL})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open page %s: %w", productURL, err)
+	}
+	if err := waitForProduct(page, productURL); err != nil {
+		page.MustClose()
+		return nil, err
+	}
+	return page, nil
+}
+
+func waitForProduct(page *rod.Page, productURL string) error {
 	// Add random delay to avoid rate-limiting (1-3 seconds)
 	time.Sleep(time.Duration(1000+rand.Intn(2000)) * time.Millisecond)
 
 	// Wait for page to load with a 60-second timeout
 	if err := page.Timeout(60 * time.Second).WaitLoad(); err != nil {
 		log.Printf("Failed to wait for load: %v", err)
-		return fmt.Errorf("failed to load page %s: %v", product.ProductURL, err)
+		return scraper.Fail(scraper.FailureTimeout, "failed to load page %s: %v", productURL, err)
 	}
 	log.Println("Page loaded successfully")
 
@@ -50,18 +155,28 @@ func ScrapeProductDetails(browser *rod.Browser, product *models.Product) error {
 	if has, _, err := page.Has("title"); has && err == nil {
 		if title, err := page.Element("title"); err == nil {
 			if titleText, err := title.Text(); err == nil {
+				if scraper.IsNotFoundTitle(titleText) {
+					log.Printf("Not-found page detected in title: %s", titleText)
+					return scraper.Fail(scraper.FailureNotFound, "product page %s no longer exists", productURL)
+				}
 				if strings.Contains(strings.ToLower(titleText), "robot check") || strings.Contains(strings.ToLower(titleText), "captcha") {
 					log.Printf("Robot check or CAPTCHA detected in title: %s", titleText)
-					return fmt.Errorf("robot check detected for %s", product.ProductURL)
+					return scraper.Fail(scraper.FailureCaptcha, "robot check detected for %s", productURL)
 				}
 			}
 		}
 	}
 	log.Println("No robot check in title")
 
+	// Amazon's 404 page shows its "Dogs of Amazon" instead of a product.
+	if dogPage, _, err := page.Has(`img[alt*="Dogs of Amazon"], a[href*="/ref=cs_404_logo"]`); err == nil && dogPage {
+		log.Printf("Dog page detected for %s", productURL)
+		return scraper.Fail(scraper.FailureNotFound, "product page %s no longer exists", productURL)
+	}
+
 	if err := handleCaptcha(page); err != nil {
 		log.Printf("Captcha handling failed: %v", err)
-		return fmt.Errorf("captcha handling failed for %s: %v", product.ProductURL, err)
+		return scraper.Fail(scraper.FailureCaptcha, "captcha handling failed for %s: %v", productURL, err)
 	}
 	log.Println("Captcha handling completed")
 
@@ -81,55 +196,19 @@ func ScrapeProductDetails(browser *rod.Browser, product *models.Product) error {
 		if err != nil {
 			// Capture page HTML for debugging
 			log.Printf("All container selectors failed: %v", err)
-			return fmt.Errorf("no product container found for %s: %v", product.ProductURL, err)
+			return scraper.Fail(scraper.FailureNoContainer, "no product container found for %s: %v", productURL, err)
 		}
 	}
 	log.Println("Product container found")
 
 	if err := el.WaitVisible(); err != nil {
 		log.Printf("Product container not visible: %v", err)
-		return fmt.Errorf("product container not visible for %s: %v", product.ProductURL, err)
+		return scraper.Fail(scraper.FailureNoContainer, "product container not visible for %s: %v", productURL, err)
 	}
 	log.Println("Product container is visible")
-
-	// Scrape all data points using robust helper functions
-	log.Println("Starting title extraction")
-	product.TitleEnglish = extractTitle(page)
-	log.Printf("Title extraction completed: %s", product.TitleEnglish)
-
-	log.Println("Starting brand extraction")
-	product.Brand = extractBrand(page)
-	log.Printf("Brand extraction completed: %s", product.Brand)
-
-	log.Println("Starting availability extraction")
-	product.Availability = extractAvailability(page)
-	log.Printf("Availability extraction completed: %s", product.Availability)
-
-	log.Println("Starting price extraction")
-	product.OriginalPrice, product.DiscountPrice, product.DiscountPercent = extractPrices(page)
-	log.Printf("Price extraction completed: Original=%f, Discount=%f, Percent=%d", product.OriginalPrice, product.DiscountPrice, product.DiscountPercent)
-
-	log.Println("Starting image extraction")
-	product.MainImageURL, product.GalleryImageURLs = extractGallery(page)
-	log.Printf("Image extraction completed: Main=%s, Gallery=%d images", product.MainImageURL, len(product.GalleryImageURLs))
-
-	log.Println("Starting details extraction")
-	product.Specifications, product.DescriptionEnglish = extractAllDetailsAsHTML(page)
-	log.Printf("Details extraction completed: Specs=%d chars, Desc=%d chars", len(product.Specifications), len(product.DescriptionEnglish))
-
-	product.ScrapedAt = time.Now()
-
-	if product.TitleEnglish == "" {
-		log.Println("No title extracted, scraping likely failed")
-		return fmt.Errorf("failed to extract a title, scraping likely failed for %s", product.ProductURL)
-	}
-
-	log.Printf("Successfully scraped details for: %s", product.TitleEnglish)
 	return nil
 }
 
-// --- Helper Functions ---
-
 func handleCaptcha(page *rod.Page) error {
 	hasCaptcha, _, err := page.Timeout(5 * time.Second).Has(`form[action="/errors/validateCaptcha"]`)
 	if err != nil {
@@ -227,14 +306,19 @@ func extractAvailability(page *rod.Page) string {
 	return "Unknown"
 }
 
-func extractPrices(page *rod.Page) (original float64, discount float64, percent int) {
+// extractPrices reads the prices using the marketplace's number format. The
+// currency is taken from the price text, falling back to the locale's.
+func extractPrices(page *rod.Page, locale utils.Locale) (original float64, discount float64, percent int, currency string) {
+	currency = locale.Currency
+
 	// --- STRATEGY 1: Find the most common and reliable elements ---
 
 	// Discount Price (the price the customer pays)
 	// The most reliable element is usually the one with the class 'priceToPay'.
 	if el, err := page.Element(".priceToPay .a-offscreen"); err == nil {
 		if text, err := el.Text(); err == nil {
-			discount = utils.ParsePrice(text)
+			price := utils.ParseMoney(text, locale)
+			discount, currency = price.Amount, price.Currency
 		}
 	}
 
@@ -242,7 +326,7 @@ func extractPrices(page *rod.Page) (original float64, discount float64, percent
 	// The strikethrough price is the most reliable indicator of the original price.
 	if el, err := page.Element("span[data-a-strike='true'] .a-offscreen"); err == nil {
 		if text, err := el.Text(); err == nil {
-			original = utils.ParsePrice(text)
+			original = utils.ParseMoney(text, locale).Amount
 		}
 	}
 
@@ -266,7 +350,7 @@ func extractPrices(page *rod.Page) (original float64, discount float64, percent
 	if original == 0.0 {
 		if el, err := page.Element(`span.aok-offscreen:contains("List Price:")`); err == nil {
 			if text, err := el.Text(); err == nil {
-				original = utils.ParsePrice(text)
+				original = utils.ParseMoney(text, locale).Amount
 			}
 		}
 	}
@@ -276,7 +360,7 @@ func extractPrices(page *rod.Page) (original float64, discount float64, percent
 		if text, err := savingsEl.Text(); err == nil {
 			// If we haven't found the discount price yet, try to get it from this text
 			if discount == 0.0 {
-				discount = utils.ParsePrice(text)
+				discount = utils.ParseMoney(text, locale).Amount
 			}
 			// If we haven't found the percentage yet, try to get it from this text
 			if percent == 0 {
@@ -306,7 +390,7 @@ func extractPrices(page *rod.Page) (original float64, discount float64, percent
 	}
 
 	log.Printf("Price extraction completed: Original=%.2f, Discount=%.2f, Percent=%d", original, discount, percent)
-	return original, discount, percent
+	return original, discount, percent, currency
 }
 
 func extractGallery(page *rod.Page) (mainImage string, gallery []string) {
@@ -424,45 +508,75 @@ endLoop:
 	return mainImage, gallery
 }
 
-func extractAllDetailsAsHTML(page *rod.Page) (specifications string, description string) {
-	var specBuilder, descBuilder strings.Builder
+// pageDocument snapshots the rendered page into a goquery document, so whole
+// sections can be parsed without a browser round-trip per element.
+func pageDocument(page *rod.Page) (*goquery.Document, error) {
+	html, err := page.HTML()
+	if err != nil {
+		return nil, err
+	}
+	return goquery.NewDocumentFromReader(strings.NewReader(html))
+}
 
-	// Product Overview (table - store as cleaned HTML)
-	if el, err := page.Timeout(10 * time.Second).Element("#productOverview_feature_div table"); err == nil {
-		if html, err := el.HTML(); err == nil {
-			doc, _ := goquery.NewDocumentFromReader(strings.NewReader(html))
-			doc.Find("*").Each(func(i int, s *goquery.Selection) {
-				for _, attr := range s.Nodes[0].Attr {
-					s.RemoveAttr(attr.Key)
-				}
-			})
-			cleanedHTML, _ := doc.Html()
-			specBuilder.WriteString("<h2>Product Overview</h2>\n" + cleanedHTML)
-			log.Println("Extracted and cleaned product overview table as HTML")
+// selectionText returns the visible text of a selection with scripts, styles
+// and Amazon's invisible direction marks removed.
+func selectionText(s *goquery.Selection) string {
+	clone := s.Clone()
+	clone.Find("script, style").Remove()
+	return utils.CleanText(clone.Text())
+}
+
+// specKeysToSkip are rows that hold widgets rather than product attributes.
+var specKeysToSkip = map[string]bool{
+	"customer reviews": true,
+}
+
+// extractSpecifications collects key/value pairs from the product overview
+// table, the detail bullets and the product information tables, in that order.
+// A key seen in an earlier section wins over the same key in a later one.
+func extractSpecifications(doc *goquery.Document) models.SpecList {
+	var specs models.SpecList
+	seen := make(map[string]bool)
+	add := func(key, value string) {
+		key = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(key), ":"))
+		value = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(value), ":"))
+		lower := strings.ToLower(key)
+		if key == "" || value == "" || seen[lower] || specKeysToSkip[lower] {
+			return
 		}
-	} else {
-		log.Printf("Could not find product overview table: %v", err)
+		seen[lower] = true
+		specs = append(specs, models.Spec{Key: key, Value: value})
 	}
 
-	// Product Details (ul - store as raw text)
-	if el, err := page.Timeout(10 * time.Second).Element("#detailBullets_feature_div"); err == nil {
-		if text, err := el.Text(); err == nil {
-			specBuilder.WriteString("<h2>Product Details</h2>\n" + strings.TrimSpace(text))
-			log.Println("Extracted product details as raw text")
+	// Product Overview: two-column table
+	doc.Find("#productOverview_feature_div table tr").Each(func(i int, row *goquery.Selection) {
+		cells := row.Find("td")
+		if cells.Length() >= 2 {
+			add(selectionText(cells.Eq(0)), selectionText(cells.Eq(1)))
 		}
-	} else {
-		log.Printf("Could not find product details list: %v", err)
-	}
+	})
 
-	// Product Information (expand and store table as cleaned HTML or text)
-	if el, err := page.Timeout(10 * time.Second).Element("#prodDetails"); err == nil {
-		if text, err := el.Text(); err == nil {
-			specBuilder.WriteString("<h2>Product Information</h2>\n" + strings.TrimSpace(text))
-			log.Println("Extracted product information as raw text")
+	// Product Details: "Key : Value" bullets, the key in bold
+	doc.Find("#detailBullets_feature_div li").Each(func(i int, item *goquery.Selection) {
+		bold := item.Find("span.a-text-bold").First()
+		if bold.Length() == 0 {
+			return
 		}
-	} else {
-		log.Printf("Could not find product information section: %v", err)
-	}
+		key := selectionText(bold)
+		add(key, strings.TrimPrefix(selectionText(item), key))
+	})
+
+	// Product Information: th/td rows in one or more tables
+	doc.Find("#prodDetails table tr").Each(func(i int, row *goquery.Selection) {
+		add(selectionText(row.Find("th").First()), selectionText(row.Find("td").First()))
+	})
+
+	log.Printf("Extracted %d specification attributes", len(specs))
+	return specs
+}
+
+func extractDescription(page *rod.Page) string {
+	var descBuilder strings.Builder
 
 	// About this item (extract plain text)
 	if el, err := page.Timeout(10 * time.Second).Element("#feature-bullets"); err == nil {
@@ -484,5 +598,211 @@ func extractAllDetailsAsHTML(page *rod.Page) (specifications string, description
 		log.Printf("Could not find product description: %v", err)
 	}
 
-	return strings.TrimSpace(specBuilder.String()), strings.TrimSpace(descBuilder.String())
+	return strings.TrimSpace(descBuilder.String())
+}
+
+// extractAPlus returns the A+ (enhanced brand content) modules as sanitized
+// HTML, one <section> per module, keeping their headings, images and
+// comparison tables.
+func extractAPlus(doc *goquery.Document) string {
+	root := doc.Find("#aplus_feature_div, #aplus").First()
+	if root.Length() == 0 {
+		log.Println("Could not find A+ content")
+		return ""
+	}
+
+	var builder strings.Builder
+	root.Find(".aplus-module").Each(func(i int, module *goquery.Selection) {
+		// Some layouts nest modules; the outer one already includes the inner.
+		if module.ParentsFiltered(".aplus-module").Length() > 0 {
+			return
+		}
+		moduleHTML, err := goquery.OuterHtml(module)
+		if err != nil {
+			return
+		}
+		if sanitized := utils.SanitizeHTML(moduleHTML); sanitized != "" {
+			builder.WriteString("<section>" + sanitized + "</section>\n")
+		}
+	})
+
+	// Older A+ pages have no module wrappers; keep the whole block instead.
+	if builder.Len() == 0 {
+		if rootHTML, err := root.Html(); err == nil {
+			builder.WriteString(utils.SanitizeHTML(rootHTML))
+		}
+	}
+
+	log.Printf("Extracted A+ content: %d chars", builder.Len())
+	return strings.TrimSpace(builder.String())
+}
+
+// sellerIDRegex pulls the merchant ID out of a seller profile link.
+var sellerIDRegex = regexp.MustCompile(`[?&](?:seller|merchant)=([A-Z0-9]+)`)
+
+// amazonSellerNames are the names, lower-cased, under which Amazon itself
+// sells and ships on each marketplace, besides a plain "Amazon". Third-party
+// sellers may have names starting with "Amazon" too, so only these match.
+var amazonSellerNames = map[string][]string{
+	"amazon.ae":    {"amazon.ae"},
+	"amazon.sa":    {"amazon.sa"},
+	"amazon.eg":    {"amazon.eg"},
+	"amazon.com":   {"amazon.com", "amazon.com services llc", "amazon export sales llc"},
+	"amazon.co.uk": {"amazon.co.uk", "amazon eu s.a.r.l.", "amazon eu s.à r.l."},
+	"amazon.de":    {"amazon.de", "amazon eu s.a.r.l.", "amazon eu s.à r.l."},
+	"amazon.fr":    {"amazon.fr", "amazon eu s.a.r.l.", "amazon eu s.à r.l."},
+	"amazon.it":    {"amazon.it", "amazon eu s.a.r.l.", "amazon eu s.à r.l."},
+	"amazon.es":    {"amazon.es", "amazon eu s.a.r.l.", "amazon eu s.à r.l."},
+	"amazon.nl":    {"amazon.nl", "amazon eu s.a.r.l.", "amazon eu s.à r.l."},
+}
+
+// isAmazonSeller reports whether a seller or shipper name is Amazon itself on
+// the marketplace of productURL.
+func isAmazonSeller(name, productURL string) bool {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "amazon" {
+		return true
+	}
+	for _, known := range amazonSellerNames[utils.MarketplaceHost(productURL)] {
+		// "Ships from and sold by Amazon.ae." ends its sentence with the name.
+		if name == known || name == known+"." {
+			return true
+		}
+	}
+	return false
+}
+
+// extractFulfillment fills in who sells and ships the product, Prime
+// eligibility and the delivery promise. It understands both the tabular
+// buybox ("Ships from" / "Sold by" rows) and the older #merchant-info sentence.
+func extractFulfillment(doc *goquery.Document, product *models.Product) {
+	// Tabular buybox, the current layout
+	product.ShipsFrom = selectionText(doc.Find("#fulfillerInfoFeature_feature_div .offer-display-feature-text-message").First())
+	product.SellerName = selectionText(doc.Find("#merchantInfoFeature_feature_div .offer-display-feature-text-message").First())
+	if product.ShipsFrom == "" {
+		product.ShipsFrom = selectionText(doc.Find(".tabular-buybox-text[tabular-attribute-name='Ships from']").First())
+	}
+	if product.SellerName == "" {
+		product.SellerName = selectionText(doc.Find(".tabular-buybox-text[tabular-attribute-name='Sold by']").First())
+	}
+
+	// Older layout: "Ships from and sold by Amazon.ae." or
+	// "Sold by XYZ and Fulfilled by Amazon."
+	merchantInfo := selectionText(doc.Find("#merchant-info").First())
+	lowerInfo := strings.ToLower(merchantInfo)
+	if product.SellerName == "" {
+		if link := doc.Find("#merchant-info a#sellerProfileTriggerId").First(); link.Length() > 0 {
+			product.SellerName = selectionText(link)
+		} else if strings.Contains(lowerInfo, "sold by amazon") {
+			product.SellerName = "Amazon"
+		}
+	}
+	if product.ShipsFrom == "" && (strings.Contains(lowerInfo, "ships from and sold by amazon") || strings.Contains(lowerInfo, "fulfilled by amazon")) {
+		product.ShipsFrom = "Amazon"
+	}
+
+	if href, ok := doc.Find("#sellerProfileTriggerId").First().Attr("href"); ok {
+		if matches := sellerIDRegex.FindStringSubmatch(href); len(matches) > 1 {
+			product.SellerID = matches[1]
+		}
+	}
+
+	product.SoldByAmazon = isAmazonSeller(product.SellerName, product.ProductURL)
+	product.FulfilledByAmazon = isAmazonSeller(product.ShipsFrom, product.ProductURL)
+	product.PrimeEligible = doc.Find("#desktop_buybox i.a-icon-prime, #buybox i.a-icon-prime, #deliveryBlockMessage i.a-icon-prime").Length() > 0
+
+	// The delivery promise carries its date in a data attribute; the visible
+	// text also includes "FREE delivery" and cut-off countdowns.
+	delivery := doc.Find("#deliveryBlockMessage [data-csa-c-delivery-time]").First()
+	if when, ok := delivery.Attr("data-csa-c-delivery-time"); ok && strings.TrimSpace(when) != "" {
+		product.DeliveryEstimate = utils.CleanText(when)
+	} else {
+		product.DeliveryEstimate = selectionText(doc.Find("#deliveryBlockMessage").First())
+	}
+}
+
+// offerMoney matches an amount with its currency on either side, "AED 20"
+// or "10,00 €"; utils.ParseMoney reads its separators for the locale.
+const (
+	offerCurrency = `(?:AED|SAR|EGP|USD|EUR|GBP|€|£|\$)`
+	offerNumber   = `\d(?:[\d.,'\x{a0}\x{202f}]*\d)?`
+	offerMoney    = `(?:` + offerCurrency + `[\s\x{a0}\x{202f}]*` + offerNumber + `|` + offerNumber + `[\s\x{a0}\x{202f}]*` + offerCurrency + `)`
+)
+
+var (
+	offerPercentRegex = regexp.MustCompile(`(\d+(?:\.\d+)?)\s*%`)
+	offerAmountRegex  = regexp.MustCompile(`(?i)` + offerMoney)
+	offerCapRegex     = regexp.MustCompile(`(?i)up\s+to\s+(` + offerMoney + `)`)
+	offerMinRegex     = regexp.MustCompile(`(?i)(?:min(?:imum|\.)?\s+(?:purchase|spend|order)(?:\s+value)?(?:\s+of)?|(?:on\s+)?orders?\s+(?:above|over))\s*:?\s*(` + offerMoney + `)`)
+	offerExpiryRegex  = regexp.MustCompile(`(?i)(?:expires|valid till|valid until|ends)\s*(?:on|in)?\s*:?\s*([^.|]+)`)
+)
+
+// extractOffers collects the coupon checkbox, Subscribe & Save and the offer
+// cards (bank offers, cashback, partner promotions) shown next to the price.
+func extractOffers(doc *goquery.Document, locale utils.Locale) models.OfferList {
+	var offers models.OfferList
+	add := func(offerType models.OfferType, text string) {
+		if offer, ok := parseOfferText(offerType, text, locale); ok {
+			offers = append(offers, offer)
+		}
+	}
+
+	// "Apply 10% coupon" / "Apply AED 20 coupon". The selectors are in order
+	// of preference; the later ones wrap the same coupon with extra links.
+	for _, selector := range []string{"label[id^='couponText']", "#couponBadgeRegularVpc", "#promoPriceBlockMessage_feature_div .promoPriceBlockMessage"} {
+		text := strings.TrimSpace(strings.TrimSuffix(selectionText(doc.Find(selector).First()), "Terms"))
+		if strings.Contains(strings.ToLower(text), "coupon") {
+			add(models.OfferCoupon, text)
+			break
+		}
+	}
+
+	if text := selectionText(doc.Find("#snsAccordionRowMiddle, #sns-base-price").First()); text != "" {
+		add(models.OfferSubscribeSave, text)
+	}
+
+	// Offer cards: a heading ("Bank Offer", "Cashback", "Partner Offers") and one or more descriptions
+	doc.Find("#vsxoffers_feature_div .vsx-offers-desktop-lv__item, #itembox-InstantBankDiscount, #itembox-Partner").Each(func(i int, card *goquery.Selection) {
+		heading := strings.ToLower(selectionText(card.Find("h6, .a-truncate-full").First()))
+		offerType := models.OfferPromotion
+		if strings.Contains(heading, "bank") || strings.Contains(heading, "card") {
+			offerType = models.OfferBank
+		}
+		card.Find("p, .a-truncate-full, .vsx-offers-desktop-lv__item-description").Each(func(j int, desc *goquery.Selection) {
+			if text := selectionText(desc); text != "" && !strings.EqualFold(text, heading) {
+				add(offerType, text)
+			}
+		})
+	})
+
+	return offers
+}
+
+// parseOfferText turns an offer sentence like "10% Instant Discount up to AED
+// 100 on XYZ Bank Credit Cards. Min purchase value AED 500" into an Offer.
+func parseOfferText(offerType models.OfferType, text string, locale utils.Locale) (models.Offer, bool) {
+	offer := models.Offer{Type: offerType, Conditions: text}
+
+	// Take the cap and minimum out first so their amounts aren't read as the value.
+	rest := text
+	if m := offerCapRegex.FindStringSubmatch(rest); len(m) > 1 {
+		offer.MaxDiscount = utils.ParseMoney(m[1], locale).Amount
+		rest = strings.Replace(rest, m[0], "", 1)
+	}
+	if m := offerMinRegex.FindStringSubmatch(rest); len(m) > 1 {
+		offer.MinSpend = utils.ParseMoney(m[1], locale).Amount
+		rest = strings.Replace(rest, m[0], "", 1)
+	}
+	if m := offerExpiryRegex.FindStringSubmatch(rest); len(m) > 1 {
+		offer.ExpiresAt = strings.TrimSpace(m[1])
+	}
+
+	if m := offerPercentRegex.FindStringSubmatch(rest); len(m) > 1 {
+		offer.Value, _ = strconv.ParseFloat(m[1], 64)
+		offer.IsPercent = true
+	} else if amount := offerAmountRegex.FindString(rest); amount != "" {
+		offer.Value = utils.ParseMoney(amount, locale).Amount
+	}
+
+	return offer, offer.Value > 0
 }
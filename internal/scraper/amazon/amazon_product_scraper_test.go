@@ -0,0 +1,226 @@
+package amazon
+
+import (
+	"NovelScraper/internal/models"
+	"NovelScraper/internal/scraper/scrapertest"
+	"NovelScraper/utils"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func TestExtractSpecifications(t *testing.T) {
+	specs := extractSpecifications(scrapertest.LoadFixture(t, "product.html"))
+
+	// Overview rows come first, later sections only add keys not seen before,
+	// and review widgets are dropped.
+	expected := models.SpecList{
+		{Key: "Brand", Value: "Urban Step"},
+		{Key: "Material", Value: "Mesh & Synthetic"},
+		{Key: "Package Dimensions", Value: "32 x 20 x 12 cm; 850 g"},
+		{Key: "Item model number", Value: "US-2231-WHT"},
+		{Key: "Item Weight", Value: "850 g"},
+		{Key: "Country of Origin", Value: "China"},
+	}
+
+	if !reflect.DeepEqual(specs, expected) {
+		t.Errorf("extractSpecifications() =\n%+v\nwant\n%+v", specs, expected)
+	}
+}
+
+func TestExtractAPlus(t *testing.T) {
+	result := extractAPlus(scrapertest.LoadFixture(t, "product.html"))
+
+	expected := "<section><h3>All-Day Comfort</h3>" +
+		`<img src="https://m.media-amazon.com/images/S/aplus-media/sc/insole.jpg" alt="Cushioned insole">` +
+		"<p>A memory-foam insole that adapts to your foot.</p></section>\n" +
+		"<section><table><tbody><tr><th></th><th>Urban Step Classic</th><th>Urban Step Runner</th></tr>" +
+		"<tr><td>Upper</td><td>Mesh</td><td>Knit</td></tr></tbody></table></section>"
+
+	if result != expected {
+		t.Errorf("extractAPlus() =\n%s\nwant\n%s", result, expected)
+	}
+}
+
+func TestExtractFulfillment(t *testing.T) {
+	var p models.Product
+	extractFulfillment(scrapertest.LoadFixture(t, "product.html"), &p)
+
+	if p.SellerName != "Step Up Trading LLC" || p.SellerID != "A2XYZ123TRADE" || p.ShipsFrom != "Amazon" {
+		t.Errorf("seller = %q (%q), ships from %q", p.SellerName, p.SellerID, p.ShipsFrom)
+	}
+	if p.SoldByAmazon || !p.FulfilledByAmazon || !p.PrimeEligible {
+		t.Errorf("flags = sold by Amazon %t, fulfilled by Amazon %t, prime %t; want false, true, true",
+			p.SoldByAmazon, p.FulfilledByAmazon, p.PrimeEligible)
+	}
+	if p.DeliveryEstimate != "Tuesday, 21 October" {
+		t.Errorf("DeliveryEstimate = %q", p.DeliveryEstimate)
+	}
+}
+
+func TestExtractFulfillmentMerchantInfo(t *testing.T) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(
+		`<div id="merchant-info">Ships from and sold by Amazon.ae.</div>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var p models.Product
+	extractFulfillment(doc, &p)
+
+	if p.SellerName != "Amazon" || !p.SoldByAmazon || !p.FulfilledByAmazon {
+		t.Errorf("got seller %q, sold by Amazon %t, fulfilled by Amazon %t", p.SellerName, p.SoldByAmazon, p.FulfilledByAmazon)
+	}
+}
+
+func TestIsAmazonSeller(t *testing.T) {
+	testCases := []struct {
+		name, productURL string
+		expected         bool
+	}{
+		{"Amazon", "", true},
+		{"Amazon.ae", "https://www.amazon.ae/dp/B0CXYZ1234", true},
+		{"Amazon.ae.", "https://www.amazon.ae/dp/B0CXYZ1234", true},
+		{"Amazon EU S.a.r.L.", "https://www.amazon.de/dp/B0CXYZ1234", true},
+		{"Amazon.com Services LLC", "https://www.amazon.com/dp/B0CXYZ1234", true},
+		{"Amazon.com", "https://www.amazon.ae/dp/B0CXYZ1234", false},
+		{"AmazonBasics Trading LLC", "https://www.amazon.ae/dp/B0CXYZ1234", false},
+		{"Amazon Deals Shop", "https://www.amazon.ae/dp/B0CXYZ1234", false},
+		{"TechZone Trading", "https://www.amazon.ae/dp/B0CXYZ1234", false},
+		{"", "https://www.amazon.ae/dp/B0CXYZ1234", false},
+	}
+
+	for _, tc := range testCases {
+		if result := isAmazonSeller(tc.name, tc.productURL); result != tc.expected {
+			t.Errorf("isAmazonSeller(%q, %q) = %t; want %t", tc.name, tc.productURL, result, tc.expected)
+		}
+	}
+}
+
+func TestExtractOffers(t *testing.T) {
+	offers := extractOffers(scrapertest.LoadFixture(t, "product.html"), utils.DefaultLocale)
+
+	expected := models.OfferList{
+		{Type: models.OfferCoupon, Value: 10, IsPercent: true, Conditions: "Apply 10% coupon"},
+		{Type: models.OfferSubscribeSave, Value: 5, IsPercent: true, Conditions: "Save 5% with Subscribe & Save"},
+		{Type: models.OfferBank, Value: 15, IsPercent: true, MaxDiscount: 30, MinSpend: 100, ExpiresAt: "31 October",
+			Conditions: "15% Instant Discount up to AED 30 on ADCB Credit Cards. Min purchase value AED 100. Valid till 31 October"},
+		{Type: models.OfferPromotion, Value: 25, MinSpend: 500, Conditions: "Get AED 25 off on orders above AED 500 with code STEP25"},
+	}
+
+	if !reflect.DeepEqual(offers, expected) {
+		t.Errorf("extractOffers() =\n%+v\nwant\n%+v", offers, expected)
+	}
+
+	// 200 - 10% coupon = 180, then 15% bank offer = 153; the AED 25
+	// promotion needs a AED 500 order so it does not apply.
+	if price := offers.EffectivePrice(200); price != 153 {
+		t.Errorf("EffectivePrice(200) = %v; want 153", price)
+	}
+	// 1000 - 10% coupon = 900, the bank offer is capped at AED 30, so the AED 25
+	// promotion is close but the bank offer still wins.
+	if price := offers.EffectivePrice(1000); price != 870 {
+		t.Errorf("EffectivePrice(1000) = %v; want 870", price)
+	}
+}
+
+func TestParseOfferTextLocales(t *testing.T) {
+	de := utils.LocaleForSite("amazon.de")
+	testCases := []struct {
+		text     string
+		locale   utils.Locale
+		expected models.Offer
+	}{
+		{"Spare 10,00 € mit Coupon", de, models.Offer{Value: 10}},
+		{"20 € Rabatt", de, models.Offer{Value: 20}},
+		{"Spare 1.250,50 €", de, models.Offer{Value: 1250.5}},
+		{"10% off up to 15,00 € on orders over 50 €", de, models.Offer{Value: 10, IsPercent: true, MaxDiscount: 15, MinSpend: 50}},
+		{"Save AED 1,250.50", utils.DefaultLocale, models.Offer{Value: 1250.5}},
+	}
+	for _, tc := range testCases {
+		offer, ok := parseOfferText(models.OfferCoupon, tc.text, tc.locale)
+		tc.expected.Type, tc.expected.Conditions = models.OfferCoupon, tc.text
+		if !ok || !reflect.DeepEqual(offer, tc.expected) {
+			t.Errorf("parseOfferText(%q) = %+v, %v; want %+v", tc.text, offer, ok, tc.expected)
+		}
+	}
+}
+
+func TestExtractVariations(t *testing.T) {
+	parent, dimensions, variants := extractVariations(scrapertest.LoadFixture(t, "product.html"), "https://www.amazon.ae/dp/B0CXYZ1234?ref=x")
+
+	if parent != "B0CPARENT1" {
+		t.Errorf("parent = %q; want B0CPARENT1", parent)
+	}
+	if !reflect.DeepEqual(dimensions, []string{"Colour", "Size"}) {
+		t.Errorf("dimensions = %v", dimensions)
+	}
+
+	expected := []models.Variant{
+		{ASIN: "B0CXYZ1234", Attributes: models.SpecList{{Key: "Colour", Value: "White"}, {Key: "Size", Value: "UK 8"}},
+			Price: 89, Availability: "available", URL: "https://www.amazon.ae/dp/B0CXYZ1234"},
+		{ASIN: "B0CXYZ1235", Attributes: models.SpecList{{Key: "Colour", Value: "White"}, {Key: "Size", Value: "UK 9"}},
+			Price: 94.5, Availability: "available", URL: "https://www.amazon.ae/dp/B0CXYZ1235"},
+		{ASIN: "B0CXYZ1236", Attributes: models.SpecList{{Key: "Colour", Value: "White"}, {Key: "Size", Value: "UK 10"}},
+			Availability: "unavailable", URL: "https://www.amazon.ae/dp/B0CXYZ1236"},
+		// Not shown as a swatch on this page, so price and availability are unknown.
+		{ASIN: "B0CXYZ1237", Attributes: models.SpecList{{Key: "Colour", Value: `Black "Night"`}, {Key: "Size", Value: "UK 8"}},
+			URL: "https://www.amazon.ae/dp/B0CXYZ1237"},
+	}
+	if !reflect.DeepEqual(variants, expected) {
+		t.Errorf("variants =\n%+v\nwant\n%+v", variants, expected)
+	}
+}
+
+func TestExtractRatings(t *testing.T) {
+	var product models.Product
+	extractRatings(scrapertest.LoadFixture(t, "product.html"), &product)
+
+	if product.Rating != 4.3 {
+		t.Errorf("Rating = %v; want 4.3", product.Rating)
+	}
+	if product.RatingCount != 1284 {
+		t.Errorf("RatingCount = %d; want 1284", product.RatingCount)
+	}
+	// The 2-star row has no aria-label and is read from its text.
+	expected := models.RatingHistogram{5, 3, 7, 17, 68}
+	if product.RatingHistogram != expected {
+		t.Errorf("RatingHistogram = %v; want %v", product.RatingHistogram, expected)
+	}
+}
+
+func TestExtractReviews(t *testing.T) {
+	reviews := extractReviews(scrapertest.LoadFixture(t, "product.html"), 2)
+
+	expected := []models.Review{
+		{ReviewID: "R2ABCDEF01", Author: "Omar K.", Stars: 5, Title: "Comfortable from day one",
+			Body: "True to size and very light. Wearing them daily.", ReviewedAt: time.Date(2024, 3, 3, 0, 0, 0, 0, time.UTC),
+			Verified: true, HelpfulVotes: 12},
+		{ReviewID: "R2ABCDEF02", Author: "Sara", Stars: 2, Title: "Sole wore out",
+			Body: "The sole started peeling after a month.", ReviewedAt: time.Date(2024, 1, 18, 0, 0, 0, 0, time.UTC),
+			HelpfulVotes: 1},
+	}
+	if !reflect.DeepEqual(reviews, expected) {
+		t.Errorf("extractReviews() =\n%+v\nwant\n%+v", reviews, expected)
+	}
+
+	if all := extractReviews(scrapertest.LoadFixture(t, "product.html"), 0); len(all) != 3 {
+		t.Errorf("extractReviews() without a limit returned %d reviews; want 3", len(all))
+	}
+}
+
+func TestDealTypeFromBadge(t *testing.T) {
+	testCases := map[string]string{
+		"45% off Lightning Deal":    models.DealTypeLightning,
+		"Deal of the Day":           models.DealTypeDealOfTheDay,
+		"30% off Limited time deal": models.DealTypeLimitedTime,
+		"25% off":                   "",
+	}
+	for text, expected := range testCases {
+		if got := dealTypeFromBadge(text); got != expected {
+			t.Errorf("dealTypeFromBadge(%q) = %q, want %q", text, got, expected)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package amazon
+
+import (
+	"NovelScraper/internal/models"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+var (
+	starsRegex     = regexp.MustCompile(`(\d+(?:[.,]\d+)?) out of 5`)
+	histogramRegex = regexp.MustCompile(`(?i)(\d+)\s*percent of reviews have (\d) star`)
+	histogramText  = regexp.MustCompile(`(?i)(\d)\s*star.*?(\d+)\s*%`)
+	digitsRegex    = regexp.MustCompile(`\d+`)
+)
+
+// reviewDateLayouts covers the date formats of the English marketplaces,
+// e.g. "3 March 2024" (amazon.ae/.co.uk) and "March 3, 2024" (amazon.com).
+var reviewDateLayouts = []string{"2 January 2006", "January 2, 2006"}
+
+// extractRatings fills the average star rating, the number of ratings and
+// the star histogram.
+func extractRatings(doc *goquery.Document, product *models.Product) {
+	ratingText, _ := doc.Find("#acrPopover").First().Attr("title")
+	if ratingText == "" {
+		ratingText = selectionText(doc.Find("#acrPopover .a-icon-alt, [data-hook='rating-out-of-text']").First())
+	}
+	product.Rating = parseStars(ratingText)
+
+	countText := selectionText(doc.Find("#acrCustomerReviewText, [data-hook='total-review-count']").First())
+	product.RatingCount = parseCount(countText)
+
+	var histogram models.RatingHistogram
+	doc.Find("#histogramTable li, #histogramTable tr").Each(func(i int, s *goquery.Selection) {
+		label, _ := s.Find("[aria-label]").First().Attr("aria-label")
+		if matches := histogramRegex.FindStringSubmatch(label); len(matches) == 3 {
+			setHistogram(&histogram, matches[2], matches[1])
+			return
+		}
+		if matches := histogramText.FindStringSubmatch(selectionText(s)); len(matches) == 3 {
+			setHistogram(&histogram, matches[1], matches[2])
+		}
+	})
+	product.RatingHistogram = histogram
+}
+
+func setHistogram(h *models.RatingHistogram, star, percent string) {
+	s, _ := strconv.Atoi(star)
+	p, _ := strconv.Atoi(percent)
+	if s >= 1 && s <= 5 {
+		h[s-1] = p
+	}
+}
+
+// extractReviews returns up to limit of the top reviews shown on the
+// product page, in the order Amazon ranks them.
+func extractReviews(doc *goquery.Document, limit int) []models.Review {
+	var reviews []models.Review
+	doc.Find("#cm-cr-dp-review-list [data-hook='review'], #cm-cr-global-review-list [data-hook='review']").EachWithBreak(func(i int, s *goquery.Selection) bool {
+		if limit > 0 && len(reviews) >= limit {
+			return false
+		}
+
+		review := models.Review{
+			Author:   selectionText(s.Find(".a-profile-name").First()),
+			Stars:    parseStars(selectionText(s.Find("[data-hook='review-star-rating'] .a-icon-alt, [data-hook='cmps-review-star-rating'] .a-icon-alt").First())),
+			Body:     selectionText(s.Find("[data-hook='review-body']").First()),
+			Verified: s.Find("[data-hook='avp-badge']").Length() > 0,
+		}
+		review.ReviewID, _ = s.Attr("id")
+
+		// The title link also holds the star icon's alt text.
+		title := s.Find("[data-hook='review-title']").First().Clone()
+		title.Find("i, .a-icon-alt").Remove()
+		review.Title = selectionText(title)
+
+		review.ReviewedAt = parseReviewDate(selectionText(s.Find("[data-hook='review-date']").First()))
+
+		helpful := strings.ToLower(selectionText(s.Find("[data-hook='helpful-vote-statement']").First()))
+		if strings.HasPrefix(helpful, "one person") {
+			review.HelpfulVotes = 1
+		} else {
+			review.HelpfulVotes = parseCount(helpful)
+		}
+
+		if review.Body != "" {
+			reviews = append(reviews, review)
+		}
+		return true
+	})
+	return reviews
+}
+
+// parseStars reads the rating from text like "4.3 out of 5 stars".
+func parseStars(text string) float64 {
+	matches := starsRegex.FindStringSubmatch(text)
+	if len(matches) < 2 {
+		return 0
+	}
+	stars, _ := strconv.ParseFloat(strings.Replace(matches[1], ",", ".", 1), 64)
+	return stars
+}
+
+// parseCount reads the number from text like "1,284 global ratings".
+func parseCount(text string) int {
+	count, _ := strconv.Atoi(strings.Join(digitsRegex.FindAllString(text, -1), ""))
+	return count
+}
+
+// parseReviewDate reads "Reviewed in the United Arab Emirates on 3 March 2024".
+func parseReviewDate(text string) time.Time {
+	if idx := strings.LastIndex(text, " on "); idx >= 0 {
+		text = text[idx+len(" on "):]
+	}
+	for _, layout := range reviewDateLayouts {
+		if t, err := time.Parse(layout, strings.TrimSpace(text)); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,160 @@
+package amazon
+
+import (
+	"NovelScraper/internal/models"
+	"NovelScraper/utils"
+	"encoding/json"
+	"log"
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// extractVariations reads the "twister" data Amazon embeds in products with
+// size/colour variations: the parent ASIN, the dimension names and every
+// child ASIN with its dimension values. Per-variant price and availability
+// are added from the swatches where the page shows them.
+func extractVariations(doc *goquery.Document, productURL string) (parentASIN string, dimensions []string, variants []models.Variant) {
+	var script string
+	doc.Find("script").EachWithBreak(func(i int, s *goquery.Selection) bool {
+		if text := s.Text(); strings.Contains(text, "dimensionValuesDisplayData") {
+			script = text
+			return false
+		}
+		return true
+	})
+	if script == "" {
+		return "", nil, nil
+	}
+
+	if raw := scriptValue(script, "parentAsin"); raw != "" {
+		_ = json.Unmarshal([]byte(raw), &parentASIN)
+	}
+	if raw := scriptValue(script, "dimensionsDisplay"); raw != "" {
+		_ = json.Unmarshal([]byte(raw), &dimensions)
+	}
+
+	baseURL := ""
+	if u, err := url.Parse(productURL); err == nil && u.Host != "" {
+		baseURL = u.Scheme + "://" + u.Host
+	}
+	swatches := extractSwatches(doc, utils.LocaleForSite(productURL))
+
+	// Decode the ASIN -> values object token by token to keep Amazon's order.
+	raw := scriptValue(script, "dimensionValuesDisplayData")
+	decoder := json.NewDecoder(strings.NewReader(raw))
+	if tok, err := decoder.Token(); err != nil || tok != json.Delim('{') {
+		log.Printf("Could not parse twister data: %v", err)
+		return parentASIN, dimensions, nil
+	}
+	for decoder.More() {
+		tok, err := decoder.Token()
+		if err != nil {
+			break
+		}
+		asin, _ := tok.(string)
+		var values []string
+		if err := decoder.Decode(&values); err != nil {
+			break
+		}
+
+		variant := models.Variant{ASIN: asin}
+		for i, value := range values {
+			name := ""
+			if i < len(dimensions) {
+				name = dimensions[i]
+			}
+			variant.Attributes = append(variant.Attributes, models.Spec{Key: name, Value: utils.CleanText(value)})
+		}
+		if baseURL != "" {
+			variant.URL = baseURL + "/dp/" + asin
+		}
+		if swatch, ok := swatches[asin]; ok {
+			variant.Price = swatch.Price
+			variant.Availability = swatch.Availability
+		}
+		variants = append(variants, variant)
+	}
+
+	log.Printf("Extracted %d variations of parent %s (%s)", len(variants), parentASIN, strings.Join(dimensions, ", "))
+	return parentASIN, dimensions, variants
+}
+
+// extractSwatches returns the price and availability shown on the twister
+// swatches, keyed by ASIN.
+func extractSwatches(doc *goquery.Document, locale utils.Locale) map[string]models.Variant {
+	swatches := make(map[string]models.Variant)
+	doc.Find("#twister_feature_div li, #twister li, [id^='inline-twister'] li").Each(func(i int, li *goquery.Selection) {
+		asin, _ := li.Attr("data-defaultasin")
+		if asin == "" {
+			asin, _ = li.Attr("data-asin")
+		}
+		if asin == "" {
+			return
+		}
+
+		var swatch models.Variant
+		swatch.Price = utils.ParseMoney(selectionText(li.Find(".twisterSwatchPrice, .a-price .a-offscreen").First()), locale).Amount
+		class, _ := li.Attr("class")
+		switch {
+		case strings.Contains(class, "Unavailable"):
+			swatch.Availability = "unavailable"
+		case strings.Contains(class, "swatchAvailable"), strings.Contains(class, "swatchSelect"):
+			swatch.Availability = "available"
+		}
+		swatches[asin] = swatch
+	})
+	return swatches
+}
+
+// scriptValue returns the raw JSON value that follows "key" : in a script,
+// matching brackets and skipping over strings, or "" if the key is missing.
+func scriptValue(script, key string) string {
+	idx := strings.Index(script, `"`+key+`"`)
+	if idx == -1 {
+		return ""
+	}
+	rest := script[idx+len(key)+2:]
+	colon := strings.Index(rest, ":")
+	if colon == -1 {
+		return ""
+	}
+	rest = strings.TrimLeft(rest[colon+1:], " \t\r\n")
+	if rest == "" {
+		return ""
+	}
+
+	depth := 0
+	inString := false
+	for i := 0; i < len(rest); i++ {
+		c := rest[i]
+		if inString {
+			if c == '\\' {
+				i++
+			} else if c == '"' {
+				inString = false
+				if depth == 0 {
+					return rest[:i+1]
+				}
+			}
+			continue
+		}
+		switch c {
+		case '"':
+			inString = true
+		case '{', '[':
+			depth++
+		case '}', ']':
+			depth--
+			if depth == 0 {
+				return rest[:i+1]
+			}
+		case ',':
+			if depth == 0 {
+				return strings.TrimSpace(rest[:i])
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,29 @@
+package scraper
+
+import (
+	"NovelScraper/internal/models"
+	"errors"
+)
+
+// ErrStopCrawl is returned by Crawl.Found to end a crawl early, e.g. once
+// an incremental crawl only finds products it already knows. The crawl
+// then counts as complete.
+var ErrStopCrawl = errors.New("crawl stopped")
+
+// Crawl receives the products of a product list crawl as soon as they are
+// discovered and stores the crawl's progress, so that a crawl interrupted
+// half-way resumes where it stopped instead of starting again.
+type Crawl interface {
+	// Found is called with the new products of every page or round of the
+	// crawl, which are saved together. An error stops the crawl and is
+	// returned by ScrapeProductList, except for ErrStopCrawl.
+	Found(products []models.Product) error
+
+	// Resume returns the progress of an unfinished crawl of url, or a fresh
+	// state when there is none.
+	Resume(url string) (models.CrawlState, error)
+
+	// Checkpoint stores the progress of the crawl. The last checkpoint of a
+	// crawl that ran to the end has Done set.
+	Checkpoint(state models.CrawlState) error
+}
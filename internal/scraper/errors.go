@@ -0,0 +1,68 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// FailureReason classifies why scraping a product page failed.
+type FailureReason string
+
+const (
+	FailureTimeout     FailureReason = "timeout"      // the page did not load in time
+	FailureCaptcha     FailureReason = "captcha"      // a robot check could not be passed
+	FailureNoContainer FailureReason = "no_container" // the page loaded without a product on it
+	FailureNotFound    FailureReason = "not_found"    // the product page no longer exists (404 / dog page)
+	FailureParse       FailureReason = "parse_failure"
+	FailureUnknown     FailureReason = "unknown"
+)
+
+// Permanent reports whether retrying cannot help.
+func (r FailureReason) Permanent() bool {
+	return r == FailureNotFound
+}
+
+// Error is a scraping error with its classified reason.
+type Error struct {
+	Reason FailureReason
+	Err    error
+}
+
+func (e *Error) Error() string { return e.Err.Error() }
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// Fail returns an error classified as reason, formatted like fmt.Errorf.
+func Fail(reason FailureReason, format string, args ...interface{}) error {
+	return &Error{Reason: reason, Err: fmt.Errorf(format, args...)}
+}
+
+// Classify returns the reason of a scraping error. Errors that were not
+// created with Fail are classified from their text where possible.
+func Classify(err error) FailureReason {
+	var scrapeErr *Error
+	if errors.As(err, &scrapeErr) {
+		return scrapeErr.Reason
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return FailureTimeout
+	}
+	if err != nil {
+		msg := strings.ToLower(err.Error())
+		switch {
+		case strings.Contains(msg, "timeout"), strings.Contains(msg, "deadline exceeded"):
+			return FailureTimeout
+		case strings.Contains(msg, "captcha"), strings.Contains(msg, "robot check"):
+			return FailureCaptcha
+		}
+	}
+	return FailureUnknown
+}
+
+// IsNotFoundTitle reports whether a page title is that of a missing page.
+func IsNotFoundTitle(title string) bool {
+	title = strings.ToLower(title)
+	return strings.Contains(title, "page not found") || strings.Contains(title, "404")
+}
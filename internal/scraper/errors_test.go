@@ -0,0 +1,44 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestClassify(t *testing.T) {
+	testCases := []struct {
+		name     string
+		err      error
+		expected FailureReason
+	}{
+		{"Classified", Fail(FailureNotFound, "page %s is gone", "x"), FailureNotFound},
+		{"Wrapped Classified", fmt.Errorf("attempt 3: %w", Fail(FailureCaptcha, "robot check")), FailureCaptcha},
+		{"Deadline", fmt.Errorf("wait: %w", context.DeadlineExceeded), FailureTimeout},
+		{"Timeout Text", errors.New("navigation timeout"), FailureTimeout},
+		{"Robot Check Text", errors.New("robot check detected for x"), FailureCaptcha},
+		{"Other", errors.New("boom"), FailureUnknown},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Classify(tc.err); got != tc.expected {
+				t.Errorf("Classify() = %q, want %q", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestIsNotFoundTitle(t *testing.T) {
+	testCases := map[string]bool{
+		"Amazon.ae: Page Not Found":        true,
+		"404 | noon":                       true,
+		"Amazon.ae : Robot Check":          false,
+		"Apple iPhone 15 (128 GB) - Black": false,
+	}
+	for title, expected := range testCases {
+		if got := IsNotFoundTitle(title); got != expected {
+			t.Errorf("IsNotFoundTitle(%q) = %v, want %v", title, got, expected)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package scraper
+
+import (
+	"NovelScraper/internal/models"
+	"NovelScraper/utils"
+	"regexp"
+)
+
+// Spec keys that carry each identifier, in order of preference. Amazon and
+// Noon label the same attribute differently, and lookups ignore case.
+var (
+	modelNumberKeys  = []string{"Item model number", "Model Number", "Part Number", "Manufacturer Part Number"}
+	manufacturerKeys = []string{"Manufacturer"}
+	countryKeys      = []string{"Country of Origin", "Country of Manufacture"}
+	eanKeys          = []string{"EAN", "EAN-13", "GTIN"}
+	upcKeys          = []string{"UPC"}
+	weightKeys       = []string{"Item Weight", "Weight", "Product Weight", "Package Weight"}
+	dimensionKeys    = []string{"Product Dimensions", "Item Dimensions LxWxH", "Item Dimensions", "Package Dimensions", "Dimensions"}
+)
+
+// barcodeRegex finds a 12 to 14 digit barcode in a value like "0194253397892, 0194253397908".
+var barcodeRegex = regexp.MustCompile(`\b\d{12,14}\b`)
+
+// FillIdentifiers copies model number, manufacturer, country of origin,
+// barcodes, dimensions and weight from the parsed specifications into their
+// typed product fields. Dimensions are stored in cm and weight in grams.
+func FillIdentifiers(product *models.Product) {
+	specs := product.Specs
+
+	product.ModelNumber = firstSpec(specs, modelNumberKeys)
+	product.Manufacturer = firstSpec(specs, manufacturerKeys)
+	product.CountryOfOrigin = firstSpec(specs, countryKeys)
+	product.EAN = barcodeRegex.FindString(firstSpec(specs, eanKeys))
+	product.UPC = barcodeRegex.FindString(firstSpec(specs, upcKeys))
+
+	for _, key := range dimensionKeys {
+		value, ok := specs.Get(key)
+		if !ok {
+			continue
+		}
+		if l, w, h, ok := utils.ParseDimensionsCM(value); ok {
+			product.LengthCM, product.WidthCM, product.HeightCM = l, w, h
+			break
+		}
+	}
+
+	// A dedicated weight row wins; otherwise Amazon often appends the weight
+	// to the dimensions ("32 x 20 x 12 cm; 850 g").
+	for _, key := range append(weightKeys, dimensionKeys...) {
+		if value, ok := specs.Get(key); ok {
+			if grams := utils.ParseWeightGrams(value); grams > 0 {
+				product.WeightGrams = grams
+				break
+			}
+		}
+	}
+}
+
+// firstSpec returns the value of the first key present in specs.
+func firstSpec(specs models.SpecList, keys []string) string {
+	for _, key := range keys {
+		if value, ok := specs.Get(key); ok {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,34 @@
+package scraper
+
+import (
+	"NovelScraper/internal/models"
+	"testing"
+)
+
+func TestFillIdentifiers(t *testing.T) {
+	p := models.Product{Specs: models.SpecList{
+		{Key: "Package Dimensions", Value: "32 x 20 x 12 cm; 850 g"},
+		{Key: "Item model number", Value: "US-2231-WHT"},
+		{Key: "Manufacturer", Value: "Urban Step Trading LLC"},
+		{Key: "Country of Origin", Value: "China"},
+		{Key: "EAN", Value: "6291234567890, 6291234567906"},
+		{Key: "UPC", Value: "n/a"},
+		{Key: "Item Weight", Value: "1.2 Kilograms"},
+	}}
+
+	FillIdentifiers(&p)
+
+	if p.ModelNumber != "US-2231-WHT" || p.Manufacturer != "Urban Step Trading LLC" || p.CountryOfOrigin != "China" {
+		t.Errorf("text identifiers = %q, %q, %q", p.ModelNumber, p.Manufacturer, p.CountryOfOrigin)
+	}
+	if p.EAN != "6291234567890" || p.UPC != "" {
+		t.Errorf("barcodes = EAN %q, UPC %q; want 6291234567890 and empty", p.EAN, p.UPC)
+	}
+	if p.LengthCM != 32 || p.WidthCM != 20 || p.HeightCM != 12 {
+		t.Errorf("dimensions = %v x %v x %v; want 32 x 20 x 12", p.LengthCM, p.WidthCM, p.HeightCM)
+	}
+	// The dedicated weight row wins over the weight in the dimensions.
+	if p.WeightGrams != 1200 {
+		t.Errorf("WeightGrams = %v; want 1200", p.WeightGrams)
+	}
+}
@@ -0,0 +1,71 @@
+package noon
+
+import (
+	"NovelScraper/internal/models"
+	"NovelScraper/pkg/config"
+	"NovelScraper/utils"
+	"net/url"
+	"strings"
+
+	"github.com/go-rod/rod"
+)
+
+// SourceSite is the value stored in products.source_site for Noon products.
+const SourceSite = "noon.com"
+
+// NoonScraper implements scraper.Scraper for noon.com.
+type NoonScraper struct {
+	Browser     *rod.Browser
+	ScraperConf config.ScraperConfig
+	NoonConf    config.NoonConfig
+}
+
+// New creates a Noon scraper with the config structs it needs.
+func New(browser *rod.Browser, scraperConf config.ScraperConfig, noonConf config.NoonConfig) *NoonScraper {
+	if noonConf.BaseURL == "" {
+		noonConf.BaseURL = "https://www.noon.com"
+	}
+	if noonConf.Locale == "" {
+		noonConf.Locale = "uae-en"
+	}
+	noonConf.BaseURL = strings.TrimRight(noonConf.BaseURL, "/")
+	return &NoonScraper{
+		Browser:     browser,
+		ScraperConf: scraperConf,
+		NoonConf:    noonConf,
+	}
+}
+
+// ScrapeProductDetails fills in the details of a single Noon product.
+func (s *NoonScraper) ScrapeProductDetails(product *models.Product) error {
+	return ScrapeProductDetails(s.Browser, product)
+}
+
+// RefreshProduct updates the price and availability of a Noon product.
+func (s *NoonScraper) RefreshProduct(product *models.Product) error {
+	return RefreshProduct(s.Browser, product)
+}
+
+// countryCurrencies maps the country part of Noon's locale path segment
+// ("uae-en", "saudi-ar", ...) to the currency its prices are in.
+var countryCurrencies = map[string]string{
+	"uae":   "AED",
+	"saudi": "SAR",
+	"egypt": "EGP",
+}
+
+// localeFromURL returns the price format of the Noon store a URL belongs to.
+// All stores use the same separators; only the currency differs.
+func localeFromURL(productURL string) utils.Locale {
+	locale := utils.LocaleForSite(SourceSite)
+	u, err := url.Parse(productURL)
+	if err != nil {
+		return locale
+	}
+	segment := strings.SplitN(strings.TrimPrefix(u.Path, "/"), "/", 2)[0]
+	country := strings.SplitN(segment, "-", 2)[0]
+	if currency, ok := countryCurrencies[country]; ok {
+		locale.Currency = currency
+	}
+	return locale
+}
@@ -0,0 +1,205 @@
+package noon
+
+import (
+	"NovelScraper/internal/models"
+	"NovelScraper/internal/scraper"
+	"NovelScraper/utils"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/go-rod/stealth"
+)
+
+// discountRe finds the "55% Off" style badge on listing cards and product pages.
+var discountRe = regexp.MustCompile(`(\d+)\s*%`)
+
+// ScrapeProductList walks the configured Noon deals page (or search query)
+// page by page and hands the products found to crawl with their listing
+// data. An interrupted crawl resumes at the page after its last checkpoint.
+func (s *NoonScraper) ScrapeProductList(crawl scraper.Crawl) error {
+	log.Println("Starting Noon listing scraping...")
+
+	maxPages := s.NoonConf.MaxPages
+	if maxPages < 1 {
+		maxPages = 1
+	}
+
+	state, err := crawl.Resume(s.listingURL(1))
+	if err != nil {
+		return fmt.Errorf("failed to load crawl checkpoint: %w", err)
+	}
+	state.SourceSite = SourceSite
+
+	for pageNum := state.Iterations + 1; pageNum <= maxPages; pageNum++ {
+		targetURL := s.listingURL(pageNum)
+		log.Printf("Scraping Noon listing page %d: %s", pageNum, targetURL)
+
+		doc, err := s.fetchListingPage(targetURL)
+		if err != nil {
+			if pageNum == 1 {
+				return fmt.Errorf("failed to load noon listing: %w", err)
+			}
+			// Left unfinished, so the next crawl retries this page.
+			log.Printf("Stopping at page %d: %v", pageNum, err)
+			return nil
+		}
+
+		var found []models.Product
+		for _, p := range parseProductList(doc, s.NoonConf.BaseURL) {
+			key := models.CrawlKey(p)
+			if state.Seen[key] {
+				continue
+			}
+			state.Seen[key] = true
+			found = append(found, p)
+		}
+		newlyFound := len(found)
+		stopped := false
+		if err := crawl.Found(found); errors.Is(err, scraper.ErrStopCrawl) {
+			stopped = true
+		} else if err != nil {
+			return err
+		}
+		log.Printf("Found %d new products. Total collected: %d", newlyFound, len(state.Seen))
+
+		state.Iterations = pageNum
+		if err := crawl.Checkpoint(state); err != nil {
+			log.Printf("Warning: could not save crawl checkpoint: %v", err)
+		}
+		if stopped {
+			log.Println("Only known products are left. Listing scrape complete.")
+			break
+		}
+		if newlyFound == 0 {
+			log.Println("No new products on this page. Listing scrape complete.")
+			break
+		}
+	}
+
+	state.Done = true
+	if err := crawl.Checkpoint(state); err != nil {
+		log.Printf("Warning: could not save crawl checkpoint: %v", err)
+	}
+	return nil
+}
+
+// listingURL builds the URL of one page of the configured listing.
+func (s *NoonScraper) listingURL(pageNum int) string {
+	base := s.NoonConf.BaseURL + "/" + s.NoonConf.Locale
+	query := url.Values{}
+	query.Set("page", strconv.Itoa(pageNum))
+
+	if s.NoonConf.SearchQuery != "" {
+		query.Set("q", s.NoonConf.SearchQuery)
+		return base + "/search/?" + query.Encode()
+	}
+
+	path := s.NoonConf.DealsPath
+	if path == "" {
+		path = "/deals/"
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return base + path + "?" + query.Encode()
+}
+
+// fetchListingPage loads a listing page, scrolls it so lazy cards render and
+// returns the resulting HTML as a goquery document.
+func (s *NoonScraper) fetchListingPage(targetURL string) (*goquery.Document, error) {
+	page, err := stealth.Page(s.Browser)
+	if err != nil {
+		return nil, err
+	}
+	defer page.MustClose()
+
+	if err := page.Timeout(40 * time.Second).Navigate(targetURL); err != nil {
+		return nil, err
+	}
+	if err := page.Timeout(40 * time.Second).WaitLoad(); err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < 8; i++ {
+		if _, err := page.Eval(`() => window.scrollBy(0, window.innerHeight)`); err != nil {
+			break
+		}
+		time.Sleep(300 * time.Millisecond)
+	}
+	page.Timeout(10 * time.Second).WaitStable(time.Second)
+
+	html, err := page.HTML()
+	if err != nil {
+		return nil, err
+	}
+	return goquery.NewDocumentFromReader(strings.NewReader(html))
+}
+
+// parseProductList extracts the product cards of a Noon listing page.
+func parseProductList(doc *goquery.Document, baseURL string) []models.Product {
+	seen := make(map[string]bool)
+	var products []models.Product
+
+	doc.Find("a[href*='/p/']").Each(func(i int, link *goquery.Selection) {
+		href, _ := link.Attr("href")
+		productURL := canonicalProductURL(href, baseURL)
+		if productURL == "" || seen[productURL] {
+			return
+		}
+		seen[productURL] = true
+
+		p := models.Product{ProductURL: productURL, ASIN: utils.ExtractNoonSKU(productURL), SourceSite: SourceSite}
+
+		nameEl := link.Find("[data-qa='product-name']").First()
+		if title, ok := nameEl.Attr("title"); ok && strings.TrimSpace(title) != "" {
+			p.TitleEnglish = strings.TrimSpace(title)
+		} else {
+			p.TitleEnglish = strings.TrimSpace(nameEl.Text())
+		}
+
+		locale := localeFromURL(productURL)
+		price := utils.ParseMoney(link.Find("strong.amount").First().Text(), locale)
+		p.DiscountPrice, p.Currency = price.Amount, price.Currency
+		p.OriginalPrice = utils.ParseMoney(link.Find(".oldPrice").First().Text(), locale).Amount
+		if matches := discountRe.FindStringSubmatch(link.Find(".profit").First().Text()); len(matches) > 1 {
+			p.DiscountPercent, _ = strconv.Atoi(matches[1])
+		}
+		if src, ok := link.Find("img").First().Attr("src"); ok {
+			p.MainImageURL = src
+		}
+
+		products = append(products, p)
+	})
+
+	return products
+}
+
+// canonicalProductURL turns a Noon product link into an absolute URL without
+// the offer query string, so the same product always maps to the same URL.
+func canonicalProductURL(href, baseURL string) string {
+	u, err := url.Parse(strings.TrimSpace(href))
+	if err != nil || (!strings.Contains(u.Path, "/p/") && !strings.HasSuffix(u.Path, "/p")) {
+		return ""
+	}
+	if utils.ExtractNoonSKU(u.Path) == "" {
+		return ""
+	}
+	u.RawQuery = ""
+	u.Fragment = ""
+	if u.Host == "" {
+		base, err := url.Parse(baseURL)
+		if err != nil {
+			return ""
+		}
+		u.Scheme = base.Scheme
+		u.Host = base.Host
+	}
+	return u.String()
+}
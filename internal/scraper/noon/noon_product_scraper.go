@@ -0,0 +1,209 @@
+package noon
+
+import (
+	"NovelScraper/internal/models"
+	"NovelScraper/internal/scraper"
+	"NovelScraper/utils"
+	"fmt"
+	"log"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// ScrapeProductDetails loads a Noon product page and fills in all product fields.
+func ScrapeProductDetails(browser *rod.Browser, product *models.Product) error {
+	log.Printf("Starting to scrape %s", product.ProductURL)
+	doc, err := loadProductDocument(browser, product.ProductURL)
+	if err != nil {
+		return err
+	}
+
+	parseProductDetails(doc, product)
+	product.ScrapedAt = time.Now()
+
+	if product.TitleEnglish == "" {
+		return scraper.Fail(scraper.FailureParse, "failed to extract a title, scraping likely failed for %s", product.ProductURL)
+	}
+
+	log.Printf("Successfully scraped details for: %s", product.TitleEnglish)
+	return nil
+}
+
+// RefreshProduct re-reads only the availability and prices of a Noon
+// product, leaving the text that has already been translated untouched.
+func RefreshProduct(browser *rod.Browser, product *models.Product) error {
+	log.Printf("Refreshing %s", product.ProductURL)
+	doc, err := loadProductDocument(browser, product.ProductURL)
+	if err != nil {
+		return err
+	}
+	return refreshFromDocument(doc, product)
+}
+
+// refreshFromDocument reads the prices and availability of a loaded page.
+// A page with neither, e.g. one that was blocked or never rendered, fails
+// rather than refreshing the product to a price of 0.
+func refreshFromDocument(doc *goquery.Document, product *models.Product) error {
+	parsePriceAndAvailability(doc, product)
+	if product.DiscountPrice == 0 && product.AvailabilityState == models.AvailabilityUnknown {
+		return scraper.Fail(scraper.FailureParse, "no price or availability found, refresh likely failed for %s", product.ProductURL)
+	}
+	return nil
+}
+
+// loadProductDocument loads a Noon product page and returns its rendered DOM.
+func loadProductDocument(browser *rod.Browser, productURL string) (*goquery.Document, error) {
+	page, err := browser.Page(proto.TargetCreateTarget{URL: productURL})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open page %s: %w", productURL, err)
+	}
+	defer page.MustClose()
+
+	// Add random delay to avoid rate-limiting (1-3 seconds)
+	time.Sleep(time.Duration(1000+rand.Intn(2000)) * time.Millisecond)
+
+	if err := page.Timeout(60 * time.Second).WaitLoad(); err != nil {
+		return nil, scraper.Fail(scraper.FailureTimeout, "failed to load page %s: %v", productURL, err)
+	}
+
+	// The product title is rendered client-side; wait for it before reading the DOM.
+	if _, err := page.Timeout(30 * time.Second).Element("h1[data-qa^='pdp-name']"); err != nil {
+		if title, titleErr := page.Eval(`() => document.title`); titleErr == nil && scraper.IsNotFoundTitle(title.Value.Str()) {
+			return nil, scraper.Fail(scraper.FailureNotFound, "product page %s no longer exists", productURL)
+		}
+		return nil, scraper.Fail(scraper.FailureNoContainer, "no product title found for %s: %v", productURL, err)
+	}
+
+	pageHTML, err := page.HTML()
+	if err != nil {
+		return nil, scraper.Fail(scraper.FailureParse, "failed to read page html for %s: %v", productURL, err)
+	}
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(pageHTML))
+	if err != nil {
+		return nil, scraper.Fail(scraper.FailureParse, "failed to parse page html for %s: %v", productURL, err)
+	}
+	return doc, nil
+}
+
+// parsePriceAndAvailability fills the fields a refresh updates.
+func parsePriceAndAvailability(doc *goquery.Document, product *models.Product) {
+	product.SetAvailability(parseAvailability(doc))
+	product.OriginalPrice, product.DiscountPrice, product.DiscountPercent, product.Currency = parsePrices(doc, localeFromURL(product.ProductURL))
+	product.UpdateEffectivePrice()
+}
+
+// parseProductDetails fills product from a rendered Noon product page.
+func parseProductDetails(doc *goquery.Document, product *models.Product) {
+	product.SourceSite = SourceSite
+	if product.ASIN == "" {
+		product.ASIN = utils.ExtractNoonSKU(product.ProductURL)
+	}
+	product.TitleEnglish = strings.TrimSpace(doc.Find("h1[data-qa^='pdp-name']").First().Text())
+	product.Brand = strings.TrimSpace(doc.Find("[data-qa^='pdp-brand']").First().Text())
+	parsePriceAndAvailability(doc, product)
+	product.MainImageURL, product.GalleryImageURLs = parseGallery(doc)
+	product.Specs = parseSpecifications(doc)
+	product.Specifications = product.Specs.HTML()
+	scraper.FillIdentifiers(product)
+	product.DescriptionEnglish = parseDescription(doc)
+	product.SellerName = utils.CleanText(doc.Find("[data-qa='pdp-seller-name']").First().Text())
+	product.DeliveryEstimate = utils.CleanText(doc.Find("[data-qa='pdp-delivery-estimate']").First().Text())
+}
+
+func parseAvailability(doc *goquery.Document) string {
+	if text := strings.TrimSpace(doc.Find("[data-qa='pdp-stock']").First().Text()); text != "" {
+		return text
+	}
+	if doc.Find("[data-qa='pdp-add-to-cart']").Length() > 0 {
+		return "In Stock"
+	}
+	if strings.Contains(strings.ToLower(doc.Text()), "out of stock") {
+		return "Out of Stock"
+	}
+	return "Unknown"
+}
+
+func parsePrices(doc *goquery.Document, locale utils.Locale) (original float64, discount float64, percent int, currency string) {
+	price := utils.ParseMoney(doc.Find("[data-qa='div-price-now']").First().Text(), locale)
+	discount, currency = price.Amount, price.Currency
+	original = utils.ParseMoney(doc.Find("[data-qa='div-price-was']").First().Text(), locale).Amount
+	if matches := discountRe.FindStringSubmatch(doc.Find("[data-qa='div-price-saving']").First().Text()); len(matches) > 1 {
+		percent, _ = strconv.Atoi(matches[1])
+	}
+
+	// Same sanity rules as the Amazon scraper: no strikethrough price means no sale.
+	if original == 0.0 && discount > 0.0 {
+		original = discount
+	}
+	if percent == 0 && original > discount && discount > 0 {
+		percent = int(((original - discount) / original) * 100)
+	}
+	return original, discount, percent, currency
+}
+
+// parseGallery returns the first gallery image as the main image and the rest
+// as the gallery. Noon serves resized thumbnails via query parameters, so
+// they are stripped to get the full-size image.
+func parseGallery(doc *goquery.Document) (mainImage string, gallery []string) {
+	seen := make(map[string]bool)
+	var images []string
+	doc.Find("[data-qa='pdp-gallery'] img").Each(func(i int, img *goquery.Selection) {
+		src, ok := img.Attr("src")
+		if !ok || src == "" {
+			return
+		}
+		src = strings.Split(src, "?")[0]
+		if !seen[src] {
+			seen[src] = true
+			images = append(images, src)
+		}
+	})
+	if len(images) == 0 {
+		return "", nil
+	}
+	return images[0], images[1:]
+}
+
+// parseSpecifications reads the specifications table as ordered key/value pairs.
+func parseSpecifications(doc *goquery.Document) models.SpecList {
+	var specs models.SpecList
+	seen := make(map[string]bool)
+	doc.Find("[data-qa='pdp-specifications'] tr").Each(func(i int, row *goquery.Selection) {
+		cells := row.Find("td, th")
+		if cells.Length() < 2 {
+			return
+		}
+		key := utils.CleanText(cells.Eq(0).Text())
+		value := utils.CleanText(cells.Eq(1).Text())
+		if key == "" || value == "" || seen[strings.ToLower(key)] {
+			return
+		}
+		seen[strings.ToLower(key)] = true
+		specs = append(specs, models.Spec{Key: key, Value: value})
+	})
+	return specs
+}
+
+// parseDescription joins the highlight bullets and the overview text, like
+// the feature bullets and description on Amazon.
+func parseDescription(doc *goquery.Document) string {
+	var builder strings.Builder
+	overview := doc.Find("[data-qa='pdp-overview']").First()
+	overview.Find("li").Each(func(i int, li *goquery.Selection) {
+		if text := strings.TrimSpace(li.Text()); text != "" {
+			builder.WriteString(text + "\n")
+		}
+	})
+	overview.Find("p").Each(func(i int, p *goquery.Selection) {
+		if text := strings.TrimSpace(p.Text()); text != "" {
+			builder.WriteString(text + "\n")
+		}
+	})
+	return strings.TrimSpace(builder.String())
+}
@@ -0,0 +1,124 @@
+package noon
+
+import (
+	"NovelScraper/internal/models"
+	"NovelScraper/internal/scraper"
+	"NovelScraper/internal/scraper/scrapertest"
+	"reflect"
+	"testing"
+)
+
+func TestParseProductList(t *testing.T) {
+	products := parseProductList(scrapertest.LoadFixture(t, "listing.html"), "https://www.noon.com")
+
+	expected := []models.Product{
+		{
+			SourceSite:      SourceSite,
+			ProductURL:      "https://www.noon.com/uae-en/casual-lace-up-sneakers-white/N53346840A/p/",
+			ASIN:            "N53346840A",
+			TitleEnglish:    "Casual Lace-Up Sneakers White",
+			OriginalPrice:   199.00,
+			DiscountPrice:   89.00,
+			DiscountPercent: 55,
+			Currency:        "AED",
+			MainImageURL:    "https://f.nooncdn.com/p/v1678523213/N53346840A_1.jpg?format=avif&width=240",
+		},
+		{
+			SourceSite:    SourceSite,
+			ProductURL:    "https://www.noon.com/uae-en/slim-fit-denim-jacket-blue/N70012345V/p/",
+			ASIN:          "N70012345V",
+			TitleEnglish:  "Slim Fit Denim Jacket Blue",
+			DiscountPrice: 1079.00,
+			Currency:      "AED",
+			MainImageURL:  "https://f.nooncdn.com/p/v1690000000/N70012345V_1.jpg?format=avif&width=240",
+		},
+	}
+
+	if !reflect.DeepEqual(products, expected) {
+		t.Errorf("parseProductList() =\n%+v\nwant\n%+v", products, expected)
+	}
+}
+
+func TestParseProductDetails(t *testing.T) {
+	var p models.Product
+	parseProductDetails(scrapertest.LoadFixture(t, "product.html"), &p)
+
+	if p.TitleEnglish != "Casual Lace-Up Sneakers White" {
+		t.Errorf("TitleEnglish = %q", p.TitleEnglish)
+	}
+	if p.Brand != "Urban Step" {
+		t.Errorf("Brand = %q", p.Brand)
+	}
+	if p.Availability != "Only 3 left in stock" {
+		t.Errorf("Availability = %q", p.Availability)
+	}
+	if p.OriginalPrice != 199.00 || p.DiscountPrice != 89.00 || p.DiscountPercent != 55 {
+		t.Errorf("prices = %f/%f/%d; want 199/89/55", p.OriginalPrice, p.DiscountPrice, p.DiscountPercent)
+	}
+	if p.Currency != "AED" {
+		t.Errorf("Currency = %q; want AED", p.Currency)
+	}
+
+	if p.MainImageURL != "https://f.nooncdn.com/p/v1678523213/N53346840A_1.jpg" {
+		t.Errorf("MainImageURL = %q", p.MainImageURL)
+	}
+	wantGallery := models.JSONStringSlice{
+		"https://f.nooncdn.com/p/v1678523213/N53346840A_2.jpg",
+		"https://f.nooncdn.com/p/v1678523213/N53346840A_3.jpg",
+	}
+	if !reflect.DeepEqual(p.GalleryImageURLs, wantGallery) {
+		t.Errorf("GalleryImageURLs = %v; want %v", p.GalleryImageURLs, wantGallery)
+	}
+
+	wantSpecs := models.SpecList{
+		{Key: "Colour Name", Value: "White"},
+		{Key: "Upper Material", Value: "Mesh & Synthetic"},
+		{Key: "Model Number", Value: "US-2231-WHT"},
+	}
+	if !reflect.DeepEqual(p.Specs, wantSpecs) {
+		t.Errorf("Specs = %+v; want %+v", p.Specs, wantSpecs)
+	}
+	if p.Specifications != wantSpecs.HTML() {
+		t.Errorf("Specifications was not rendered from Specs: %s", p.Specifications)
+	}
+
+	if p.ModelNumber != "US-2231-WHT" {
+		t.Errorf("ModelNumber = %q; want US-2231-WHT", p.ModelNumber)
+	}
+
+	if p.SellerName != "Step Up Trading" || p.DeliveryEstimate != "Tomorrow, Oct 19" {
+		t.Errorf("seller/delivery = %q / %q", p.SellerName, p.DeliveryEstimate)
+	}
+
+	wantDesc := "Breathable mesh upper\nCushioned insole for all-day comfort\nLightweight everyday sneakers with a classic lace-up design."
+	if p.DescriptionEnglish != wantDesc {
+		t.Errorf("DescriptionEnglish = %q; want %q", p.DescriptionEnglish, wantDesc)
+	}
+}
+
+func TestRefreshEmptyPage(t *testing.T) {
+	p := models.Product{ProductURL: "https://www.noon.com/uae-en/x/N53346840A/p/", DiscountPrice: 89}
+	err := refreshFromDocument(scrapertest.LoadFixture(t, "empty_product.html"), &p)
+	if err == nil || scraper.Classify(err) != scraper.FailureParse {
+		t.Errorf("refreshFromDocument() on a page without prices = %v, want a parse failure", err)
+	}
+
+	p = models.Product{ProductURL: "https://www.noon.com/uae-en/x/N53346840A/p/"}
+	if err := refreshFromDocument(scrapertest.LoadFixture(t, "product.html"), &p); err != nil || p.DiscountPrice != 89 {
+		t.Errorf("refreshFromDocument() = %v with price %.2f, want nil and 89", err, p.DiscountPrice)
+	}
+}
+
+func TestLocaleFromURL(t *testing.T) {
+	testCases := map[string]string{
+		"https://www.noon.com/uae-en/x/N1/p/":   "AED",
+		"https://www.noon.com/saudi-ar/x/N1/p/": "SAR",
+		"https://www.noon.com/egypt-en/x/N1/p/": "EGP",
+		"https://www.noon.com/unknown/x/N1/p/":  "AED",
+	}
+	for productURL, currency := range testCases {
+		if got := localeFromURL(productURL).Currency; got != currency {
+			t.Errorf("localeFromURL(%q).Currency = %s; want %s", productURL, got, currency)
+		}
+	}
+}
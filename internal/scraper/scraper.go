@@ -7,10 +7,16 @@ import "NovelScraper/internal/models"
 // will follow a standard structure.
 type Scraper interface {
 	// ScrapeProductList scrapes the main listing page (like deals or search results)
-	// and returns a slice of products with only basic info (URL, Title, etc.).
-	ScrapeProductList() ([]models.Product, error)
+	// and hands every product, with only basic info (URL, Title, etc.), to
+	// crawl as soon as it is found. Progress is checkpointed to crawl, so an
+	// interrupted crawl of the same listing resumes.
+	ScrapeProductList(crawl Crawl) error
 
 	// ScrapeProductDetails takes a product with a URL and scrapes its detail page
 	// to fill in all the other fields (Brand, Price, Description, etc.).
 	ScrapeProductDetails(product *models.Product) error
+
+	// RefreshProduct re-scrapes only the volatile fields of an already
+	// scraped product: availability, prices, discount and offers.
+	RefreshProduct(product *models.Product) error
 }
@@ -0,0 +1,27 @@
+// Package scrapertest holds helpers shared by the scraper tests.
+package scrapertest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// LoadFixture parses an offline HTML fixture from the testdata directory of
+// the package under test.
+func LoadFixture(t *testing.T, name string) *goquery.Document {
+	t.Helper()
+	f, err := os.Open(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("failed to open fixture %s: %v", name, err)
+	}
+	defer f.Close()
+
+	doc, err := goquery.NewDocumentFromReader(f)
+	if err != nil {
+		t.Fatalf("failed to parse fixture %s: %v", name, err)
+	}
+	return doc
+}
@@ -4,27 +4,34 @@ import (
 	"NovelScraper/internal/models"
 	"NovelScraper/internal/wpdatabase"
 	"NovelScraper/pkg/config"
+	"database/sql"
 	"encoding/json"
 	"log"
 	"math"
 	"net/http"
 	"strconv"
+	"time"
 )
 
-// Start now accepts the WPRepository for the clean database.
-func Start(repo *wpdatabase.WPRepository, cfg *config.Config) {
+// Start now accepts the Repository of the clean database.
+func Start(repo wpdatabase.Repository, cfg *config.Config) {
 	http.HandleFunc("/products", productsHandler(repo))
+	http.HandleFunc("/reviews", reviewsHandler(repo))
+	http.HandleFunc("/price-history", priceHistoryHandler(repo))
 
 	port := "8080"
 	log.Printf("Starting API server on port %s", port)
 	log.Println("Endpoint available at http://localhost:8080/products")
+	log.Println("Endpoint available at http://localhost:8080/products?q={search}")
+	log.Println("Endpoint available at http://localhost:8080/reviews?asin={asin}")
+	log.Println("Endpoint available at http://localhost:8080/price-history?asin={asin}&site={site}&days={days}")
 
 	if err := http.ListenAndServe(":"+port, nil); err != nil {
 		log.Fatalf("Failed to start server: %v", err)
 	}
 }
 
-func productsHandler(repo *wpdatabase.WPRepository) http.HandlerFunc {
+func productsHandler(repo wpdatabase.Repository) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// 1. Parse Pagination Parameters
 		queryParams := r.URL.Query()
@@ -38,10 +45,18 @@ func productsHandler(repo *wpdatabase.WPRepository) http.HandlerFunc {
 		}
 		offset := (page - 1) * limit
 
-		filters := models.ProductFilters{Limit: limit, Offset: offset}
+		filters := models.ProductFilters{Limit: limit, Offset: offset, SortBy: queryParams.Get("sort"), Query: queryParams.Get("q")}
+
+		// A q= search returns the matching products by relevance instead.
+		countProducts := repo.CountProducts
+		getProducts := repo.GetProducts
+		if filters.Query != "" {
+			countProducts = func() (int, error) { return repo.CountSearchResults(filters.Query) }
+			getProducts = repo.SearchProducts
+		}
 
 		// 2. Get Total Count for Pagination
-		totalProducts, err := repo.CountProducts()
+		totalProducts, err := countProducts()
 		if err != nil {
 			http.Error(w, "Failed to count products", http.StatusInternalServerError)
 			return
@@ -49,7 +64,7 @@ func productsHandler(repo *wpdatabase.WPRepository) http.HandlerFunc {
 		totalPages := int(math.Ceil(float64(totalProducts) / float64(limit)))
 
 		// 3. Get Paginated Products
-		products, err := repo.GetProducts(filters)
+		products, err := getProducts(filters)
 		if err != nil {
 			http.Error(w, "Failed to get products", http.StatusInternalServerError)
 			return
@@ -71,3 +86,73 @@ func productsHandler(repo *wpdatabase.WPRepository) http.HandlerFunc {
 		}
 	}
 }
+
+func reviewsHandler(repo wpdatabase.Repository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		queryParams := r.URL.Query()
+		asin := queryParams.Get("asin")
+		if asin == "" {
+			http.Error(w, "Missing asin parameter", http.StatusBadRequest)
+			return
+		}
+		limit, _ := strconv.Atoi(queryParams.Get("limit"))
+		if limit < 1 {
+			limit = 10 // Default limit
+		}
+
+		reviews, err := repo.GetReviews(asin, limit)
+		if err != nil {
+			http.Error(w, "Failed to get reviews", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		if err := json.NewEncoder(w).Encode(models.ReviewsResponse{ASIN: asin, Reviews: reviews}); err != nil {
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		}
+	}
+}
+
+// defaultSite is the site of price-history requests that name none, the
+// only one listings were published from before Noon was added.
+const defaultSite = "amazon.ae"
+
+func priceHistoryHandler(repo wpdatabase.Repository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		queryParams := r.URL.Query()
+		asin := queryParams.Get("asin")
+		if asin == "" {
+			http.Error(w, "Missing asin parameter", http.StatusBadRequest)
+			return
+		}
+		site := queryParams.Get("site")
+		if site == "" {
+			site = defaultSite
+		}
+		days, _ := strconv.Atoi(queryParams.Get("days"))
+		if days < 1 {
+			days = 90 // Default range for charts
+		}
+
+		stats, err := repo.GetPriceStats(site, asin)
+		if err == sql.ErrNoRows {
+			http.Error(w, "Product not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, "Failed to get price stats", http.StatusInternalServerError)
+			return
+		}
+		history, err := repo.GetPriceHistory(site, asin, time.Now().AddDate(0, 0, -days))
+		if err != nil {
+			http.Error(w, "Failed to get price history", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		response := models.PriceHistoryResponse{ASIN: asin, Stats: stats, History: history}
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		}
+	}
+}
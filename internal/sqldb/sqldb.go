@@ -0,0 +1,276 @@
+// Package sqldb wraps database/sql for the SQL backends the repositories can
+// run on. Queries are written once with ? placeholders, SQLite's style, and
+// rewritten to the backend's own placeholders when they are run.
+package sqldb
+
+import (
+	"NovelScraper/pkg/config"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	_ "modernc.org/sqlite"
+)
+
+// Dialect names a supported database backend.
+type Dialect string
+
+const (
+	SQLite   Dialect = "sqlite"
+	Postgres Dialect = "postgres"
+)
+
+// ParseDialect returns the dialect of a backend name from config.yml. An
+// empty name means SQLite.
+func ParseDialect(name string) (Dialect, error) {
+	switch d := Dialect(strings.ToLower(strings.TrimSpace(name))); d {
+	case "":
+		return SQLite, nil
+	case SQLite, Postgres:
+		return d, nil
+	default:
+		return "", fmt.Errorf("unknown database backend %q (want sqlite or postgres)", name)
+	}
+}
+
+// driverName is the database/sql driver that serves the dialect.
+func (d Dialect) driverName() string {
+	if d == Postgres {
+		return "pgx"
+	}
+	return "sqlite"
+}
+
+// Rebind rewrites the ? placeholders of query for the dialect: PostgreSQL
+// numbers them $1, $2, ... and a numbered ?N becomes $N. Question marks in
+// quoted strings and identifiers are left alone.
+func (d Dialect) Rebind(query string) string {
+	if d != Postgres || !strings.Contains(query, "?") {
+		return query
+	}
+	var b strings.Builder
+	b.Grow(len(query) + 16)
+	var quote byte
+	n := 0
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '?':
+			j := i + 1
+			for j < len(query) && query[j] >= '0' && query[j] <= '9' {
+				j++
+			}
+			b.WriteByte('$')
+			if j > i+1 {
+				b.WriteString(query[i+1 : j])
+				i = j - 1
+			} else {
+				n++
+				b.WriteString(strconv.Itoa(n))
+			}
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// TimeFormat is the text form of times that queries compare. SQLite
+// compares DATETIME values as text, so they are written in UTC at a fixed
+// width to sort in time order; PostgreSQL parses the same text.
+const TimeFormat = "2006-01-02 15:04:05.000000Z07:00"
+
+// Timestamp formats t in TimeFormat, for writing or comparing a time
+// column on either backend.
+func Timestamp(t time.Time) string {
+	return t.UTC().Format(TimeFormat)
+}
+
+// Config describes how to connect to one database.
+type Config struct {
+	Dialect Dialect
+	DSN     string // a file name for SQLite, a connection string for PostgreSQL
+
+	// BusyTimeout is how long SQLite waits for a lock held by another
+	// connection before a statement fails with SQLITE_BUSY.
+	BusyTimeout     time.Duration
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+}
+
+// FromConfig returns the connection settings of products.db and
+// wordpress.db from config.yml, defaults filled in.
+func FromConfig(cfg config.DatabaseConfig) (products, wordpress Config, err error) {
+	cfg = cfg.WithDefaults()
+	dialect, err := ParseDialect(cfg.Backend)
+	if err != nil {
+		return products, wordpress, err
+	}
+	conn := func(dsn string, pool config.PoolConfig) Config {
+		return Config{
+			Dialect:         dialect,
+			DSN:             dsn,
+			BusyTimeout:     cfg.BusyTimeout,
+			MaxOpenConns:    pool.MaxOpen,
+			MaxIdleConns:    pool.MaxIdle,
+			ConnMaxLifetime: pool.MaxLifetime,
+		}
+	}
+	return conn(cfg.ProductsDSN, cfg.ProductsPool), conn(cfg.WordpressDSN, cfg.WordpressPool), nil
+}
+
+// driverDSN returns the DSN handed to the driver. SQLite connections are
+// switched to WAL, so readers never block the writer, and wait for locks
+// instead of failing at once. Transactions take the write lock when they
+// begin: a transaction that reads before writing could otherwise fail
+// with SQLITE_BUSY when upgrading its lock, which the timeout can't help.
+func (c Config) driverDSN() string {
+	if c.Dialect != SQLite {
+		return c.DSN
+	}
+	params := []string{
+		fmt.Sprintf("_pragma=busy_timeout(%d)", c.BusyTimeout.Milliseconds()),
+		"_pragma=journal_mode(WAL)",
+		"_pragma=synchronous(NORMAL)",
+		"_txlock=immediate",
+	}
+	sep := "?"
+	if strings.Contains(c.DSN, "?") {
+		sep = "&"
+	}
+	return c.DSN + sep + strings.Join(params, "&")
+}
+
+// DB is a connection pool that rebinds the queries run through it. It also
+// keeps the statements prepared by Prepared for the life of the pool.
+type DB struct {
+	*sql.DB
+	Dialect Dialect
+
+	mu    sync.Mutex
+	stmts map[string]*sql.Stmt
+}
+
+// Open connects to the database of cfg, sizes its pool and checks that it
+// can be reached.
+func Open(cfg Config) (*DB, error) {
+	if cfg.DSN == "" {
+		return nil, fmt.Errorf("no %s database configured", cfg.Dialect)
+	}
+	db, err := sql.Open(cfg.Dialect.driverName(), cfg.driverDSN())
+	if err != nil {
+		return nil, err
+	}
+	if cfg.MaxOpenConns > 0 {
+		db.SetMaxOpenConns(cfg.MaxOpenConns)
+	}
+	if cfg.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(cfg.MaxIdleConns)
+	}
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	if err = db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &DB{DB: db, Dialect: cfg.Dialect}, nil
+}
+
+// Close closes the prepared statements and the pool.
+func (db *DB) Close() error {
+	db.mu.Lock()
+	for _, stmt := range db.stmts {
+		stmt.Close()
+	}
+	db.stmts = nil
+	db.mu.Unlock()
+	return db.DB.Close()
+}
+
+// Prepared returns query prepared once for the pool, so statements run
+// for every saved row aren't parsed again each time.
+func (db *DB) Prepared(query string) (*sql.Stmt, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if stmt, ok := db.stmts[query]; ok {
+		return stmt, nil
+	}
+	stmt, err := db.DB.Prepare(db.Dialect.Rebind(query))
+	if err != nil {
+		return nil, err
+	}
+	if db.stmts == nil {
+		db.stmts = make(map[string]*sql.Stmt)
+	}
+	db.stmts[query] = stmt
+	return stmt, nil
+}
+
+func (db *DB) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return db.DB.Exec(db.Dialect.Rebind(query), args...)
+}
+
+func (db *DB) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return db.DB.Query(db.Dialect.Rebind(query), args...)
+}
+
+func (db *DB) QueryRow(query string, args ...interface{}) *sql.Row {
+	return db.DB.QueryRow(db.Dialect.Rebind(query), args...)
+}
+
+func (db *DB) Prepare(query string) (*sql.Stmt, error) {
+	return db.DB.Prepare(db.Dialect.Rebind(query))
+}
+
+// Begin starts a transaction whose queries are rebound as well.
+func (db *DB) Begin() (*Tx, error) {
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	return &Tx{Tx: tx, Dialect: db.Dialect, db: db}, nil
+}
+
+// Tx is a transaction that rebinds the queries run through it.
+type Tx struct {
+	*sql.Tx
+	Dialect Dialect
+
+	db *DB
+}
+
+func (tx *Tx) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return tx.Tx.Exec(tx.Dialect.Rebind(query), args...)
+}
+
+func (tx *Tx) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return tx.Tx.Query(tx.Dialect.Rebind(query), args...)
+}
+
+func (tx *Tx) QueryRow(query string, args ...interface{}) *sql.Row {
+	return tx.Tx.QueryRow(tx.Dialect.Rebind(query), args...)
+}
+
+func (tx *Tx) Prepare(query string) (*sql.Stmt, error) {
+	return tx.Tx.Prepare(tx.Dialect.Rebind(query))
+}
+
+// Prepared returns the pool's prepared statement for query, bound to the
+// transaction. It is closed with the transaction.
+func (tx *Tx) Prepared(query string) (*sql.Stmt, error) {
+	stmt, err := tx.db.Prepared(query)
+	if err != nil {
+		return nil, err
+	}
+	return tx.Tx.Stmt(stmt), nil
+}
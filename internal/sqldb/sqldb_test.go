@@ -0,0 +1,119 @@
+package sqldb
+
+import (
+	"database/sql"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRebind(t *testing.T) {
+	tests := []struct {
+		dialect Dialect
+		query   string
+		want    string
+	}{
+		{SQLite, "SELECT * FROM t WHERE a = ? AND b = ?", "SELECT * FROM t WHERE a = ? AND b = ?"},
+		{Postgres, "SELECT * FROM t WHERE a = ? AND b = ?", "SELECT * FROM t WHERE a = $1 AND b = $2"},
+		{Postgres, "SELECT ?1, ?2 WHERE x = ?1", "SELECT $1, $2 WHERE x = $1"},
+		{Postgres, "SELECT '?', \"a?\" FROM t WHERE c = ?", "SELECT '?', \"a?\" FROM t WHERE c = $1"},
+		{Postgres, "SELECT 'it''s ?' WHERE c IN (?, ?)", "SELECT 'it''s ?' WHERE c IN ($1, $2)"},
+		{Postgres, "SELECT 1", "SELECT 1"},
+	}
+	for _, tt := range tests {
+		if got := tt.dialect.Rebind(tt.query); got != tt.want {
+			t.Errorf("%s.Rebind(%q) = %q, want %q", tt.dialect, tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestParseDialect(t *testing.T) {
+	for name, want := range map[string]Dialect{"": SQLite, "sqlite": SQLite, "Postgres": Postgres} {
+		if got, err := ParseDialect(name); err != nil || got != want {
+			t.Errorf("ParseDialect(%q) = %q, %v; want %q", name, got, err, want)
+		}
+	}
+	if _, err := ParseDialect("mysql"); err == nil {
+		t.Error("ParseDialect(\"mysql\") succeeded, want an error")
+	}
+}
+
+func openTestSQLite(t *testing.T, path string) *DB {
+	t.Helper()
+	db, err := Open(Config{Dialect: SQLite, DSN: path, BusyTimeout: 5 * time.Second, MaxOpenConns: 4})
+	if err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestOpenSQLitePragmas(t *testing.T) {
+	db := openTestSQLite(t, filepath.Join(t.TempDir(), "test.db"))
+
+	var mode string
+	var timeout int
+	if err := db.QueryRow("PRAGMA journal_mode").Scan(&mode); err != nil || mode != "wal" {
+		t.Errorf("journal_mode = %q, %v; want wal", mode, err)
+	}
+	if err := db.QueryRow("PRAGMA busy_timeout").Scan(&timeout); err != nil || timeout != 5000 {
+		t.Errorf("busy_timeout = %d, %v; want 5000", timeout, err)
+	}
+	if got := db.Stats().MaxOpenConnections; got != 4 {
+		t.Errorf("MaxOpenConnections = %d, want 4", got)
+	}
+}
+
+// Two pools on one file stand in for the scraper and the API server: the
+// writers queue behind each other's locks and readers are never blocked.
+func TestConcurrentWriters(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	writer, other := openTestSQLite(t, path), openTestSQLite(t, path)
+	if _, err := writer.Exec("CREATE TABLE t (n INTEGER)"); err != nil {
+		t.Fatal(err)
+	}
+
+	const rows = 50
+	var wg sync.WaitGroup
+	errs := make(chan error, 2*rows)
+	for _, db := range []*DB{writer, other} {
+		wg.Add(1)
+		go func(db *DB) {
+			defer wg.Done()
+			for i := 0; i < rows; i++ {
+				tx, err := db.Begin()
+				if err != nil {
+					errs <- err
+					return
+				}
+				var count int
+				err = tx.QueryRow("SELECT COUNT(*) FROM t").Scan(&count)
+				if err == nil {
+					var stmt *sql.Stmt
+					if stmt, err = tx.Prepared("INSERT INTO t (n) VALUES (?)"); err == nil {
+						_, err = stmt.Exec(count)
+					}
+				}
+				if err == nil {
+					err = tx.Commit()
+				} else {
+					tx.Rollback()
+				}
+				if err != nil {
+					errs <- err
+				}
+			}
+		}(db)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("write failed: %v", err)
+	}
+
+	var count int
+	if err := other.QueryRow("SELECT COUNT(*) FROM t").Scan(&count); err != nil || count != 2*rows {
+		t.Errorf("rows = %d, %v; want %d", count, err, 2*rows)
+	}
+}
@@ -0,0 +1,92 @@
+// Package sqldbtest runs repository tests against every database backend.
+package sqldbtest
+
+import (
+	"NovelScraper/internal/migrate"
+	"NovelScraper/internal/sqldb"
+	"fmt"
+	"math/rand"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// PostgresEnv names the environment variable holding the connection string
+// of a PostgreSQL database the tests may use. Every test creates its own
+// schema there and drops it afterwards. Without it only SQLite is tested.
+const PostgresEnv = "POSTGRES_TEST_DSN"
+
+// Run calls test once per backend with a fresh database migrated by
+// newMigrator: a temporary SQLite file, and a PostgreSQL schema when
+// PostgresEnv is set.
+func Run(t *testing.T, newMigrator func(*sqldb.DB) (*migrate.Migrator, error), test func(t *testing.T, db *sqldb.DB)) {
+	t.Run(string(sqldb.SQLite), func(t *testing.T) {
+		db, err := sqldb.Open(sqldb.Config{Dialect: sqldb.SQLite, DSN: filepath.Join(t.TempDir(), "test.db"), BusyTimeout: 5 * time.Second})
+		if err != nil {
+			t.Fatalf("failed to open sqlite: %v", err)
+		}
+		t.Cleanup(func() { db.Close() })
+		migrateUp(t, db, newMigrator)
+		test(t, db)
+	})
+
+	t.Run(string(sqldb.Postgres), func(t *testing.T) {
+		dsn := os.Getenv(PostgresEnv)
+		if dsn == "" {
+			t.Skipf("%s is not set", PostgresEnv)
+		}
+		db := openSchema(t, dsn)
+		migrateUp(t, db, newMigrator)
+		test(t, db)
+	})
+}
+
+func migrateUp(t *testing.T, db *sqldb.DB, newMigrator func(*sqldb.DB) (*migrate.Migrator, error)) {
+	t.Helper()
+	migrator, err := newMigrator(db)
+	if err != nil {
+		t.Fatalf("failed to load migrations: %v", err)
+	}
+	if _, err := migrator.Up(); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+}
+
+// openSchema creates an empty schema in the database at dsn and returns a
+// connection that uses it.
+func openSchema(t *testing.T, dsn string) *sqldb.DB {
+	t.Helper()
+	admin, err := sqldb.Open(sqldb.Config{Dialect: sqldb.Postgres, DSN: dsn})
+	if err != nil {
+		t.Fatalf("failed to open postgres: %v", err)
+	}
+	t.Cleanup(func() { admin.Close() })
+
+	schema := fmt.Sprintf("test_%d", rand.Int63())
+	if _, err := admin.Exec("CREATE SCHEMA " + schema); err != nil {
+		t.Fatalf("failed to create schema: %v", err)
+	}
+	t.Cleanup(func() { admin.Exec("DROP SCHEMA " + schema + " CASCADE") })
+
+	db, err := sqldb.Open(sqldb.Config{Dialect: sqldb.Postgres, DSN: withSearchPath(dsn, schema)})
+	if err != nil {
+		t.Fatalf("failed to open postgres schema: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// withSearchPath adds a search_path run-time parameter to a URL or
+// key=value connection string.
+func withSearchPath(dsn, schema string) string {
+	if u, err := url.Parse(dsn); err == nil && strings.Contains(dsn, "://") {
+		query := u.Query()
+		query.Set("search_path", schema)
+		u.RawQuery = query.Encode()
+		return u.String()
+	}
+	return dsn + " search_path=" + schema
+}
@@ -0,0 +1,32 @@
+package wpdatabase
+
+import (
+	"NovelScraper/internal/migrate"
+	"NovelScraper/internal/sqldb"
+	"embed"
+)
+
+//go:embed migrations/*.sql migrations/postgres/*.sql
+var migrationFiles embed.FS
+
+// goMigrations are the schema changes that need Go code, numbered in the
+// same sequence as the SQL files in migrations/.
+var goMigrations = []migrate.Migration{
+	{Version: 14, Name: "index_published", Up: indexPublished},
+	{Version: 17, Name: "normalize_price_times", Up: normalizePriceTimes},
+}
+
+// NewMigrator returns the migrator for a wordpress database. As with
+// products.db, PostgreSQL databases have their own migrations and the Go
+// migrations only apply to SQLite.
+func NewMigrator(db *sqldb.DB) (*migrate.Migrator, error) {
+	dir, extra := "migrations", goMigrations
+	if db.Dialect == sqldb.Postgres {
+		dir, extra = "migrations/postgres", nil
+	}
+	migrations, err := migrate.Load(migrationFiles, dir)
+	if err != nil {
+		return nil, err
+	}
+	return migrate.New(db, append(migrations, extra...))
+}
@@ -0,0 +1,18 @@
+-- Schema of the first release. Later columns are added by the migrations
+-- that follow, so databases created by any version end up the same.
+CREATE TABLE IF NOT EXISTS wp_products (
+	"id" INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+	"product_url" TEXT UNIQUE,
+	"asin" TEXT,
+	"title_farsi" TEXT,
+	"title_english" TEXT,
+	"slug" TEXT,
+	"image_url" TEXT,
+	"original_price" REAL,
+	"discount_price" REAL,
+	"discount_percent" INTEGER,
+	"brand" TEXT,
+	"availability" TEXT,
+	"description_farsi" TEXT,
+	"specifications" TEXT
+);
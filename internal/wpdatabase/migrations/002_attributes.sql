@@ -0,0 +1,2 @@
+-- Specifications as key/value JSON for attribute tables and filters.
+ALTER TABLE wp_products ADD COLUMN "attributes" TEXT;
@@ -0,0 +1,5 @@
+ALTER TABLE wp_products ADD COLUMN "seller_name" TEXT DEFAULT '';
+ALTER TABLE wp_products ADD COLUMN "sold_by_amazon" BOOLEAN DEFAULT 0;
+ALTER TABLE wp_products ADD COLUMN "fulfilled_by_amazon" BOOLEAN DEFAULT 0;
+ALTER TABLE wp_products ADD COLUMN "prime_eligible" BOOLEAN DEFAULT 0;
+ALTER TABLE wp_products ADD COLUMN "delivery_estimate" TEXT DEFAULT '';
@@ -0,0 +1,3 @@
+ALTER TABLE wp_products ADD COLUMN "offers" TEXT;
+ALTER TABLE wp_products ADD COLUMN "effective_price" REAL DEFAULT 0;
+ALTER TABLE wp_products ADD COLUMN "effective_discount_percent" INTEGER DEFAULT 0;
@@ -0,0 +1,14 @@
+ALTER TABLE wp_products ADD COLUMN "product_type" TEXT DEFAULT 'simple';
+ALTER TABLE wp_products ADD COLUMN "parent_asin" TEXT DEFAULT '';
+ALTER TABLE wp_products ADD COLUMN "variation_dimensions" TEXT;
+
+CREATE TABLE IF NOT EXISTS wp_product_variants (
+	"id" INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+	"parent_asin" TEXT NOT NULL,
+	"asin" TEXT NOT NULL,
+	"attributes" TEXT,
+	"price" REAL DEFAULT 0,
+	"availability" TEXT DEFAULT '',
+	"url" TEXT DEFAULT '',
+	UNIQUE(parent_asin, asin)
+);
@@ -0,0 +1,16 @@
+ALTER TABLE wp_products ADD COLUMN "rating" REAL DEFAULT 0;
+ALTER TABLE wp_products ADD COLUMN "rating_count" INTEGER DEFAULT 0;
+ALTER TABLE wp_products ADD COLUMN "rating_histogram" TEXT;
+
+CREATE TABLE IF NOT EXISTS wp_reviews (
+	"id" INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+	"asin" TEXT NOT NULL,
+	"author" TEXT DEFAULT '',
+	"stars" REAL DEFAULT 0,
+	"title" TEXT DEFAULT '',
+	"body" TEXT DEFAULT '',
+	"reviewed_at" DATETIME,
+	"verified" BOOLEAN DEFAULT 0,
+	"helpful_votes" INTEGER DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS idx_wp_reviews_asin ON wp_reviews(asin);
@@ -0,0 +1 @@
+ALTER TABLE wp_products ADD COLUMN "currency" TEXT DEFAULT '';
@@ -0,0 +1,6 @@
+-- One listing per ASIN: drop older copies published under other URLs
+-- before enforcing it.
+DELETE FROM wp_products WHERE asin != '' AND id NOT IN (
+	SELECT MAX(id) FROM wp_products WHERE asin != '' GROUP BY asin
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_wp_products_asin ON wp_products(asin) WHERE asin != '';
@@ -0,0 +1,15 @@
+ALTER TABLE wp_products ADD COLUMN "lowest_price_30d" REAL DEFAULT 0;
+ALTER TABLE wp_products ADD COLUMN "first_seen" DATETIME;
+ALTER TABLE wp_products ADD COLUMN "last_seen" DATETIME;
+
+-- Price observations copied from products.db when a product is published.
+CREATE TABLE IF NOT EXISTS wp_price_history (
+	"id" INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+	"asin" TEXT NOT NULL,
+	"price" REAL DEFAULT 0,
+	"original_price" REAL DEFAULT 0,
+	"currency" TEXT DEFAULT '',
+	"availability" TEXT DEFAULT '',
+	"observed_at" DATETIME NOT NULL,
+	UNIQUE(asin, observed_at)
+);
@@ -0,0 +1,5 @@
+-- Normalized availability and whether the listing is shown. Products that
+-- are out of stock are hidden, unavailable or removed ones are unpublished.
+ALTER TABLE wp_products ADD COLUMN "availability_state" TEXT DEFAULT 'unknown';
+ALTER TABLE wp_products ADD COLUMN "stock_count" INTEGER DEFAULT 0;
+ALTER TABLE wp_products ADD COLUMN "visibility" TEXT DEFAULT 'visible';
@@ -0,0 +1,7 @@
+-- Full-text index of the published products for the API's q= search. Rows
+-- are keyed by wp_products.id and hold the text written by indexProduct;
+-- the listings published so far are indexed by migration 014.
+CREATE VIRTUAL TABLE IF NOT EXISTS wp_products_fts USING fts5(
+	title_english, title_farsi, brand, description_english, description_farsi,
+	tokenize = 'unicode61 remove_diacritics 2'
+);
@@ -0,0 +1,24 @@
+-- Parent ASINs of different marketplaces may be the same, so listings
+-- record their site and variants are keyed by it. Existing rows take the
+-- site from their URL; variants take it from their parent's listing.
+ALTER TABLE wp_products ADD COLUMN "source_site" TEXT DEFAULT '';
+UPDATE wp_products SET source_site = CASE WHEN product_url LIKE '%noon.com/%' THEN 'noon.com' ELSE 'amazon.ae' END;
+
+CREATE TABLE wp_product_variants_new (
+	"id" INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+	"source_site" TEXT NOT NULL DEFAULT '',
+	"parent_asin" TEXT NOT NULL,
+	"asin" TEXT NOT NULL,
+	"attributes" TEXT,
+	"price" REAL DEFAULT 0,
+	"availability" TEXT DEFAULT '',
+	"url" TEXT DEFAULT '',
+	UNIQUE(source_site, parent_asin, asin)
+);
+INSERT OR IGNORE INTO wp_product_variants_new (id, source_site, parent_asin, asin, attributes, price, availability, url)
+SELECT v.id,
+	COALESCE((SELECT p.source_site FROM wp_products p WHERE p.parent_asin = v.parent_asin ORDER BY p.id DESC LIMIT 1), 'amazon.ae'),
+	v.parent_asin, v.asin, v.attributes, v.price, v.availability, v.url
+FROM wp_product_variants v;
+DROP TABLE wp_product_variants;
+ALTER TABLE wp_product_variants_new RENAME TO wp_product_variants;
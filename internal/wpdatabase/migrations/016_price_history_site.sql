@@ -0,0 +1,21 @@
+-- Price observations are keyed by site like the products they belong to, so
+-- Amazon and Noon items with the same ID keep their own history. Existing
+-- rows take the site of their listing.
+CREATE TABLE wp_price_history_new (
+	"id" INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+	"source_site" TEXT NOT NULL DEFAULT '',
+	"asin" TEXT NOT NULL,
+	"price" REAL DEFAULT 0,
+	"original_price" REAL DEFAULT 0,
+	"currency" TEXT DEFAULT '',
+	"availability" TEXT DEFAULT '',
+	"observed_at" DATETIME NOT NULL,
+	UNIQUE(source_site, asin, observed_at)
+);
+INSERT OR IGNORE INTO wp_price_history_new (id, source_site, asin, price, original_price, currency, availability, observed_at)
+SELECT h.id,
+	COALESCE((SELECT p.source_site FROM wp_products p WHERE p.asin = h.asin), 'amazon.ae'),
+	h.asin, h.price, h.original_price, h.currency, h.availability, h.observed_at
+FROM wp_price_history h;
+DROP TABLE wp_price_history;
+ALTER TABLE wp_price_history_new RENAME TO wp_price_history;
@@ -0,0 +1,86 @@
+-- Schema of wordpress.db for PostgreSQL, matching the SQLite schema after
+-- migration 012. Later changes are added as migrations that follow, numbered
+-- on their own.
+CREATE TABLE IF NOT EXISTS wp_products (
+	"id" BIGSERIAL PRIMARY KEY,
+	"product_url" TEXT UNIQUE,
+	"asin" TEXT,
+	"title_farsi" TEXT,
+	"title_english" TEXT,
+	"slug" TEXT,
+	"image_url" TEXT,
+	"original_price" DOUBLE PRECISION,
+	"discount_price" DOUBLE PRECISION,
+	"discount_percent" INTEGER,
+	"brand" TEXT,
+	"availability" TEXT,
+	"description_farsi" TEXT,
+	"specifications" TEXT,
+	"attributes" TEXT,
+	"model_number" TEXT DEFAULT '',
+	"ean" TEXT DEFAULT '',
+	"upc" TEXT DEFAULT '',
+	"country_of_origin" TEXT DEFAULT '',
+	"length_cm" DOUBLE PRECISION DEFAULT 0,
+	"width_cm" DOUBLE PRECISION DEFAULT 0,
+	"height_cm" DOUBLE PRECISION DEFAULT 0,
+	"weight_g" DOUBLE PRECISION DEFAULT 0,
+	"aplus_html" TEXT DEFAULT '',
+	"seller_name" TEXT DEFAULT '',
+	"sold_by_amazon" BOOLEAN DEFAULT FALSE,
+	"fulfilled_by_amazon" BOOLEAN DEFAULT FALSE,
+	"prime_eligible" BOOLEAN DEFAULT FALSE,
+	"delivery_estimate" TEXT DEFAULT '',
+	"offers" TEXT,
+	"effective_price" DOUBLE PRECISION DEFAULT 0,
+	"effective_discount_percent" INTEGER DEFAULT 0,
+	"product_type" TEXT DEFAULT 'simple',
+	"parent_asin" TEXT DEFAULT '',
+	"variation_dimensions" TEXT,
+	"rating" DOUBLE PRECISION DEFAULT 0,
+	"rating_count" INTEGER DEFAULT 0,
+	"rating_histogram" TEXT,
+	"currency" TEXT DEFAULT '',
+	"lowest_price_30d" DOUBLE PRECISION DEFAULT 0,
+	"first_seen" TIMESTAMPTZ,
+	"last_seen" TIMESTAMPTZ,
+	"availability_state" TEXT DEFAULT 'unknown',
+	"stock_count" INTEGER DEFAULT 0,
+	"visibility" TEXT DEFAULT 'visible'
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_wp_products_asin ON wp_products(asin) WHERE asin != '';
+
+CREATE TABLE IF NOT EXISTS wp_product_variants (
+	"id" BIGSERIAL PRIMARY KEY,
+	"parent_asin" TEXT NOT NULL,
+	"asin" TEXT NOT NULL,
+	"attributes" TEXT,
+	"price" DOUBLE PRECISION DEFAULT 0,
+	"availability" TEXT DEFAULT '',
+	"url" TEXT DEFAULT '',
+	UNIQUE(parent_asin, asin)
+);
+
+CREATE TABLE IF NOT EXISTS wp_reviews (
+	"id" BIGSERIAL PRIMARY KEY,
+	"asin" TEXT NOT NULL,
+	"author" TEXT DEFAULT '',
+	"stars" DOUBLE PRECISION DEFAULT 0,
+	"title" TEXT DEFAULT '',
+	"body" TEXT DEFAULT '',
+	"reviewed_at" TIMESTAMPTZ,
+	"verified" BOOLEAN DEFAULT FALSE,
+	"helpful_votes" INTEGER DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS idx_wp_reviews_asin ON wp_reviews(asin);
+
+CREATE TABLE IF NOT EXISTS wp_price_history (
+	"id" BIGSERIAL PRIMARY KEY,
+	"asin" TEXT NOT NULL,
+	"price" DOUBLE PRECISION DEFAULT 0,
+	"original_price" DOUBLE PRECISION DEFAULT 0,
+	"currency" TEXT DEFAULT '',
+	"availability" TEXT DEFAULT '',
+	"observed_at" TIMESTAMPTZ NOT NULL,
+	UNIQUE(asin, observed_at)
+);
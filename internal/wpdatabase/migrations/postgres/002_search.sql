@@ -0,0 +1,30 @@
+-- Full-text index of the published products for the API's q= search. Rows
+-- are keyed by wp_products.id and hold the text written by indexProduct.
+-- The backfill below approximates it in SQL: tags are stripped and the
+-- replacements of utils.NormalizePersian applied. English descriptions are
+-- only indexed once a product is published again, as wp_products doesn't
+-- store them. The document column is generated, weighting titles over
+-- brands over descriptions, so no trigger function is needed.
+CREATE TABLE IF NOT EXISTS wp_products_search (
+	"product_id" BIGINT PRIMARY KEY REFERENCES wp_products(id) ON DELETE CASCADE,
+	"title_english" TEXT DEFAULT '',
+	"title_farsi" TEXT DEFAULT '',
+	"brand" TEXT DEFAULT '',
+	"description_english" TEXT DEFAULT '',
+	"description_farsi" TEXT DEFAULT '',
+	"document" TSVECTOR GENERATED ALWAYS AS (
+		setweight(to_tsvector('simple', COALESCE(title_english, '') || ' ' || COALESCE(title_farsi, '')), 'A') ||
+		setweight(to_tsvector('simple', COALESCE(brand, '')), 'B') ||
+		setweight(to_tsvector('simple', COALESCE(description_english, '') || ' ' || COALESCE(description_farsi, '')), 'C')
+	) STORED
+);
+CREATE INDEX IF NOT EXISTS idx_wp_products_search_document ON wp_products_search USING GIN (document);
+
+INSERT INTO wp_products_search (product_id, title_english, title_farsi, brand, description_farsi)
+SELECT id,
+	COALESCE(title_english, ''),
+	replace(translate(COALESCE(title_farsi, ''), 'يىك', 'ییک'), chr(8204), ' '),
+	replace(translate(COALESCE(brand, ''), 'يىك', 'ییک'), chr(8204), ' '),
+	replace(translate(regexp_replace(COALESCE(description_farsi, ''), '<[^>]*>', ' ', 'g'), 'يىك', 'ییک'), chr(8204), ' ')
+FROM wp_products
+ON CONFLICT (product_id) DO NOTHING;
@@ -0,0 +1,11 @@
+-- Parent ASINs of different marketplaces may be the same, so listings
+-- record their site and variants are keyed by it, as in SQLite migration
+-- 015.
+ALTER TABLE wp_products ADD COLUMN IF NOT EXISTS "source_site" TEXT DEFAULT '';
+UPDATE wp_products SET source_site = CASE WHEN product_url LIKE '%noon.com/%' THEN 'noon.com' ELSE 'amazon.ae' END;
+
+ALTER TABLE wp_product_variants ADD COLUMN IF NOT EXISTS "source_site" TEXT NOT NULL DEFAULT '';
+UPDATE wp_product_variants v SET source_site = COALESCE(
+	(SELECT p.source_site FROM wp_products p WHERE p.parent_asin = v.parent_asin ORDER BY p.id DESC LIMIT 1), 'amazon.ae');
+ALTER TABLE wp_product_variants DROP CONSTRAINT IF EXISTS wp_product_variants_parent_asin_asin_key;
+ALTER TABLE wp_product_variants ADD CONSTRAINT wp_product_variants_site_asin_key UNIQUE (source_site, parent_asin, asin);
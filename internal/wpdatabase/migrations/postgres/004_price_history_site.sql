@@ -0,0 +1,7 @@
+-- Price observations are keyed by site like the products they belong to,
+-- as in SQLite migration 016.
+ALTER TABLE wp_price_history ADD COLUMN IF NOT EXISTS "source_site" TEXT NOT NULL DEFAULT '';
+UPDATE wp_price_history h SET source_site = COALESCE(
+	(SELECT p.source_site FROM wp_products p WHERE p.asin = h.asin), 'amazon.ae');
+ALTER TABLE wp_price_history DROP CONSTRAINT IF EXISTS wp_price_history_asin_observed_at_key;
+ALTER TABLE wp_price_history ADD CONSTRAINT wp_price_history_site_asin_observed_at_key UNIQUE (source_site, asin, observed_at);
@@ -0,0 +1,74 @@
+package wpdatabase
+
+import (
+	"NovelScraper/internal/models"
+	"NovelScraper/internal/sqldb"
+	"database/sql"
+	"time"
+)
+
+// SavePriceHistory copies price observations of a product into wordpress.db.
+// Observations already copied by an earlier publish are left alone.
+func (repo *WPRepository) SavePriceHistory(sourceSite, asin string, history []models.PriceSnapshot) error {
+	tx, err := repo.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepared(`INSERT INTO wp_price_history (source_site, asin, price, original_price, currency, availability, observed_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(source_site, asin, observed_at) DO NOTHING`)
+	if err != nil {
+		return err
+	}
+
+	for _, s := range history {
+		if _, err := stmt.Exec(sourceSite, asin, s.Price, s.OriginalPrice, s.Currency, s.Availability, sqldb.Timestamp(s.ObservedAt)); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// UpdatePriceStats stores the price summary shown with a listing.
+func (repo *WPRepository) UpdatePriceStats(sourceSite, asin string, stats models.PriceStats) error {
+	_, err := repo.DB.Exec("UPDATE wp_products SET lowest_price_30d = ?, first_seen = ?, last_seen = ? WHERE source_site = ? AND asin = ?",
+		stats.LowestPrice30d, sqldb.Timestamp(stats.FirstSeen), sqldb.Timestamp(stats.LastSeen), sourceSite, asin)
+	return err
+}
+
+// GetPriceHistory returns the published observations of a product since the
+// given time, oldest first, for price charts.
+func (repo *WPRepository) GetPriceHistory(sourceSite, asin string, since time.Time) ([]models.PriceSnapshot, error) {
+	rows, err := repo.DB.Query(`SELECT price, original_price, currency, availability, observed_at
+		FROM wp_price_history WHERE source_site = ? AND asin = ? AND observed_at >= ? ORDER BY observed_at`,
+		sourceSite, asin, sqldb.Timestamp(since))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := []models.PriceSnapshot{}
+	for rows.Next() {
+		s := models.PriceSnapshot{SourceSite: sourceSite, ASIN: asin}
+		if err := rows.Scan(&s.Price, &s.OriginalPrice, &s.Currency, &s.Availability, &s.ObservedAt); err != nil {
+			return nil, err
+		}
+		history = append(history, s)
+	}
+	return history, rows.Err()
+}
+
+// GetPriceStats returns the price summary stored for a listing.
+func (repo *WPRepository) GetPriceStats(sourceSite, asin string) (models.PriceStats, error) {
+	var stats models.PriceStats
+	var firstSeen, lastSeen sql.NullTime
+	err := repo.DB.QueryRow(`SELECT lowest_price_30d, first_seen, last_seen,
+		(SELECT COUNT(*) FROM wp_price_history h WHERE h.source_site = wp_products.source_site AND h.asin = wp_products.asin)
+		FROM wp_products WHERE source_site = ? AND asin = ?`, sourceSite, asin,
+	).Scan(&stats.LowestPrice30d, &firstSeen, &lastSeen, &stats.Observations)
+	stats.FirstSeen = firstSeen.Time
+	stats.LastSeen = lastSeen.Time
+	return stats, err
+}
@@ -0,0 +1,16 @@
+package wpdatabase
+
+import (
+	"NovelScraper/internal/migrate"
+	"database/sql"
+)
+
+// normalizePriceTimes rewrites the price history times written before they
+// were stored in UTC, in sqldb.TimeFormat, so the time window of a price
+// chart selects the right observations.
+func normalizePriceTimes(tx *sql.Tx) error {
+	if err := migrate.NormalizeTimes(tx, "wp_price_history", "observed_at"); err != nil {
+		return err
+	}
+	return migrate.NormalizeTimes(tx, "wp_products", "first_seen", "last_seen")
+}
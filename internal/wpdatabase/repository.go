@@ -0,0 +1,31 @@
+package wpdatabase
+
+import (
+	"NovelScraper/internal/models"
+	"time"
+)
+
+// Repository is the published catalog, written by the publisher and the
+// refresher and read by the API server. WPRepository implements it on
+// SQLite and PostgreSQL.
+type Repository interface {
+	Close()
+
+	SaveProduct(p models.Product, asin string, slug string) error
+	UpdatePrices(asin string, p models.Product) error
+	SetAvailability(asin string, state models.AvailabilityState) error
+	HasParentListing(sourceSite, parentASIN, productURL string) (bool, error)
+	SaveVariants(sourceSite, parentASIN string, variants []models.Variant) error
+	SavePriceHistory(sourceSite, asin string, history []models.PriceSnapshot) error
+	UpdatePriceStats(sourceSite, asin string, stats models.PriceStats) error
+
+	GetProducts(filters models.ProductFilters) ([]models.WordpressProduct, error)
+	CountProducts() (int, error)
+	SearchProducts(filters models.ProductFilters) ([]models.WordpressProduct, error)
+	CountSearchResults(query string) (int, error)
+	GetReviews(asin string, limit int) ([]models.Review, error)
+	GetPriceHistory(sourceSite, asin string, since time.Time) ([]models.PriceSnapshot, error)
+	GetPriceStats(sourceSite, asin string) (models.PriceStats, error)
+}
+
+var _ Repository = (*WPRepository)(nil)
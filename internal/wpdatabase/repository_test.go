@@ -0,0 +1,263 @@
+package wpdatabase
+
+import (
+	"NovelScraper/internal/models"
+	"NovelScraper/internal/sqldb"
+	"NovelScraper/internal/sqldb/sqldbtest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// runRepositoryTest runs test against a fresh wordpress database of every
+// backend; see sqldbtest for how PostgreSQL is enabled.
+func runRepositoryTest(t *testing.T, test func(t *testing.T, repo *WPRepository)) {
+	sqldbtest.Run(t, NewMigrator, func(t *testing.T, db *sqldb.DB) {
+		test(t, &WPRepository{DB: db})
+	})
+}
+
+func testProduct(asin string) models.Product {
+	return models.Product{
+		SourceSite: "amazon.ae", ProductURL: "https://www.amazon.ae/dp/" + asin, ASIN: asin,
+		TitleFarsi: "کتری", TitleEnglish: "Kettle", OriginalPrice: 100, DiscountPrice: 60,
+		Availability: "In Stock", SoldByAmazon: true,
+		Specs: models.SpecList{{Key: "Capacity", Value: "1.7L"}},
+	}
+}
+
+func TestPublishAndList(t *testing.T) {
+	runRepositoryTest(t, func(t *testing.T, repo *WPRepository) {
+		p := testProduct("B000000001")
+		p.Reviews = []models.Review{{Author: "a", Stars: 4, Body: "fine", Verified: true}}
+		// A+ translations stored before they were sanitized are cleaned.
+		p.APlusFarsi = `<p>کتری</p><script>alert(1)</script><img src="https://m.media-amazon.com/a.jpg" onerror="alert(2)">`
+		if err := repo.SaveProduct(p, p.ASIN, "kettle"); err != nil {
+			t.Fatalf("SaveProduct() error: %v", err)
+		}
+		// Publishing again under a new URL updates the same listing.
+		p.ProductURL += "?ref=deal"
+		p.DiscountPrice = 55
+		if err := repo.SaveProduct(p, p.ASIN, "kettle"); err != nil {
+			t.Fatalf("SaveProduct() again error: %v", err)
+		}
+		other := testProduct("B000000002")
+		if err := repo.SaveProduct(other, other.ASIN, "kettle-2"); err != nil {
+			t.Fatal(err)
+		}
+
+		count, err := repo.CountProducts()
+		if err != nil || count != 2 {
+			t.Fatalf("CountProducts() = %d, %v; want 2", count, err)
+		}
+		products, err := repo.GetProducts(models.ProductFilters{Limit: 10})
+		if err != nil || len(products) != 2 {
+			t.Fatalf("GetProducts() = %d, %v; want 2", len(products), err)
+		}
+		got := products[1]
+		if got.ID != "B000000001" || got.DiscountedPrice != 55 || !got.SoldByAmazon || got.Currency != "AED" || got.AvailabilityState != models.AvailabilityInStock {
+			t.Errorf("listed product = %+v", got)
+		}
+		if got.APlusHTML != `<p>کتری</p><img src="https://m.media-amazon.com/a.jpg">` {
+			t.Errorf("listed A+ content = %s", got.APlusHTML)
+		}
+		reviews, err := repo.GetReviews(p.ASIN, 5)
+		if err != nil || len(reviews) != 1 || !reviews[0].Verified {
+			t.Errorf("GetReviews() = %+v, %v", reviews, err)
+		}
+
+		// Unavailable products are hidden from the listing.
+		if err := repo.SetAvailability(other.ASIN, models.AvailabilityRemoved); err != nil {
+			t.Fatal(err)
+		}
+		if count, err := repo.CountProducts(); err != nil || count != 1 {
+			t.Errorf("CountProducts() after removal = %d, %v; want 1", count, err)
+		}
+		other.Availability = "In Stock"
+		if err := repo.UpdatePrices(other.ASIN, other); err != nil {
+			t.Fatal(err)
+		}
+		if count, err := repo.CountProducts(); err != nil || count != 2 {
+			t.Errorf("CountProducts() back in stock = %d, %v; want 2", count, err)
+		}
+	})
+}
+
+func TestVariants(t *testing.T) {
+	runRepositoryTest(t, func(t *testing.T, repo *WPRepository) {
+		p := testProduct("B000000003")
+		p.ParentASIN = "P000000001"
+		p.Variants = []models.Variant{{ASIN: "B000000003", Price: 60}, {ASIN: "B000000004", Price: 65}}
+		if err := repo.SaveProduct(p, p.ASIN, "shirt"); err != nil {
+			t.Fatal(err)
+		}
+		// The same parent ASIN on another site is a different product.
+		other := testProduct("N000000003")
+		other.SourceSite, other.ProductURL = "noon.com", "https://www.noon.com/uae-en/shirt/N000000003/p/"
+		other.ParentASIN = p.ParentASIN
+		other.Variants = []models.Variant{{ASIN: "N000000003", Price: 70}}
+		if err := repo.SaveProduct(other, other.ASIN, "shirt-noon"); err != nil {
+			t.Fatal(err)
+		}
+
+		has, err := repo.HasParentListing(p.SourceSite, p.ParentASIN, "https://elsewhere")
+		if err != nil || !has {
+			t.Errorf("HasParentListing() = %v, %v; want true", has, err)
+		}
+		if has, err := repo.HasParentListing("amazon.sa", p.ParentASIN, "https://elsewhere"); err != nil || has {
+			t.Errorf("HasParentListing() on another site = %v, %v; want false", has, err)
+		}
+		products, err := repo.GetProducts(models.ProductFilters{Limit: 10})
+		if err != nil || len(products) != 2 {
+			t.Fatalf("GetProducts() = %+v, %v; want 2 products", products, err)
+		}
+		want := map[string]int{p.ASIN: 2, other.ASIN: 1}
+		for _, got := range products {
+			if got.ProductType != "variable" || len(got.Variants) != want[got.ID] {
+				t.Errorf("product %s on %s = %s with %d variants; want variable with %d", got.ID, got.SourceSite, got.ProductType, len(got.Variants), want[got.ID])
+			}
+		}
+	})
+}
+
+func TestPriceHistory(t *testing.T) {
+	runRepositoryTest(t, func(t *testing.T, repo *WPRepository) {
+		p := testProduct("B000000005")
+		if err := repo.SaveProduct(p, p.ASIN, "kettle"); err != nil {
+			t.Fatal(err)
+		}
+
+		// Observations are written in UTC whatever zone they were read in.
+		dubai := time.FixedZone("GST", 4*60*60)
+		day := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+		history := []models.PriceSnapshot{
+			{Price: 70, Currency: "AED", ObservedAt: day},
+			{Price: 60, Currency: "AED", ObservedAt: day.AddDate(0, 0, 1).In(dubai)},
+		}
+		// Copying the same observations twice keeps one of each.
+		for i := 0; i < 2; i++ {
+			if err := repo.SavePriceHistory(p.SourceSite, p.ASIN, history); err != nil {
+				t.Fatalf("SavePriceHistory() error: %v", err)
+			}
+		}
+		// The same ID on another site has a history of its own.
+		if err := repo.SavePriceHistory("noon.com", p.ASIN, []models.PriceSnapshot{{Price: 99, Currency: "AED", ObservedAt: day}}); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := repo.GetPriceHistory(p.SourceSite, p.ASIN, time.Time{})
+		if err != nil || len(got) != 2 || got[1].Price != 60 || !got[1].ObservedAt.Equal(history[1].ObservedAt) {
+			t.Errorf("GetPriceHistory() = %+v, %v", got, err)
+		}
+		// 13:00 in Dubai is 09:00 UTC, before the second observation only.
+		since := time.Date(2025, 1, 2, 13, 0, 0, 0, dubai)
+		if got, err := repo.GetPriceHistory(p.SourceSite, p.ASIN, since); err != nil || len(got) != 1 || got[0].Price != 60 {
+			t.Errorf("GetPriceHistory(since %v) = %+v, %v; want the second observation", since, got, err)
+		}
+		if got, err := repo.GetPriceHistory("noon.com", p.ASIN, time.Time{}); err != nil || len(got) != 1 || got[0].Price != 99 {
+			t.Errorf("GetPriceHistory(noon.com) = %+v, %v", got, err)
+		}
+
+		stats := models.PriceStats{LowestPrice30d: 60, FirstSeen: day, LastSeen: day.AddDate(0, 0, 1)}
+		if err := repo.UpdatePriceStats(p.SourceSite, p.ASIN, stats); err != nil {
+			t.Fatal(err)
+		}
+		stored, err := repo.GetPriceStats(p.SourceSite, p.ASIN)
+		if err != nil || stored.LowestPrice30d != 60 || stored.Observations != 2 || !stored.FirstSeen.Equal(day) {
+			t.Errorf("GetPriceStats() = %+v, %v", stored, err)
+		}
+	})
+}
+
+func TestSearch(t *testing.T) {
+	runRepositoryTest(t, func(t *testing.T, repo *WPRepository) {
+		kettle := testProduct("B000000006")
+		kettle.TitleFarsi = "کتری برقی"
+		kettle.Brand = "Philips"
+		kettle.DescriptionFarsi = "کتری استیل با ظرفیت ۱.۷ لیتر که آب را سریع می‌جوشاند."
+		lamp := testProduct("B000000007")
+		lamp.TitleFarsi = "چراغ مطالعه"
+		lamp.TitleEnglish = "Desk Lamp"
+		lamp.Brand = "Ikea"
+		for _, p := range []models.Product{kettle, lamp} {
+			if err := repo.SaveProduct(p, p.ASIN, p.ASIN); err != nil {
+				t.Fatal(err)
+			}
+		}
+		// Publishing again replaces the indexed text.
+		lamp.DescriptionEnglish = "An adjustable lamp for reading."
+		if err := repo.SaveProduct(lamp, lamp.ASIN, lamp.ASIN); err != nil {
+			t.Fatal(err)
+		}
+
+		tests := []struct {
+			query string
+			want  []string
+		}{
+			{"kettle", []string{kettle.ASIN}},
+			{"KETT", []string{kettle.ASIN}},   // prefixes and any case match
+			{"كتري", []string{kettle.ASIN}},   // Arabic forms of ی and ک
+			{"جوشاند", []string{kettle.ASIN}}, // the half after a ZWNJ is a word
+			{"philips برقی", []string{kettle.ASIN}},
+			{"reading", []string{lamp.ASIN}},
+			{"kettle lamp", nil},
+			{"\"*( )", nil},
+		}
+		for _, tt := range tests {
+			products, err := repo.SearchProducts(models.ProductFilters{Query: tt.query, Limit: 10})
+			if err != nil {
+				t.Fatalf("SearchProducts(%q) error: %v", tt.query, err)
+			}
+			var got []string
+			for _, p := range products {
+				got = append(got, p.ID)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("SearchProducts(%q) = %v, want %v", tt.query, got, tt.want)
+			}
+			if count, err := repo.CountSearchResults(tt.query); err != nil || count != len(tt.want) {
+				t.Errorf("CountSearchResults(%q) = %d, %v; want %d", tt.query, count, err, len(tt.want))
+			}
+		}
+
+		products, err := repo.SearchProducts(models.ProductFilters{Query: "کتری", Limit: 10})
+		if err != nil || len(products) != 1 || products[0].Match == nil {
+			t.Fatalf("SearchProducts() = %+v, %v", products, err)
+		}
+		if match := products[0].Match; match.Title != "<mark>کتری</mark> برقی" || !strings.Contains(match.Snippet, "<mark>") || match.Score <= 0 {
+			t.Errorf("match = %+v", match)
+		}
+
+		// Hidden listings are not found.
+		if err := repo.SetAvailability(kettle.ASIN, models.AvailabilityRemoved); err != nil {
+			t.Fatal(err)
+		}
+		if count, err := repo.CountSearchResults("kettle"); err != nil || count != 0 {
+			t.Errorf("CountSearchResults() after removal = %d, %v; want 0", count, err)
+		}
+	})
+}
+
+func TestSearchEscapesMatches(t *testing.T) {
+	runRepositoryTest(t, func(t *testing.T, repo *WPRepository) {
+		// The entities decode to a script tag in the indexed text.
+		p := testProduct("B000000008")
+		p.TitleFarsi = "کتری &lt;script&gt;alert(1)&lt;/script&gt;"
+		p.DescriptionFarsi = "کتری &lt;img src=x onerror=alert(2)&gt;"
+		if err := repo.SaveProduct(p, p.ASIN, p.ASIN); err != nil {
+			t.Fatal(err)
+		}
+
+		products, err := repo.SearchProducts(models.ProductFilters{Query: "کتری alert", Limit: 10})
+		if err != nil || len(products) != 1 || products[0].Match == nil {
+			t.Fatalf("SearchProducts() = %+v, %v", products, err)
+		}
+		match := products[0].Match
+		if want := "<mark>کتری</mark> &lt;script&gt;<mark>alert</mark>(1)&lt;/script&gt;"; match.Title != want {
+			t.Errorf("match title = %q, want %q", match.Title, want)
+		}
+		if strings.Contains(match.Snippet, "<img") || strings.Contains(match.Snippet, "<script") || !strings.Contains(match.Snippet, "&lt;") {
+			t.Errorf("match snippet was not escaped: %q", match.Snippet)
+		}
+	})
+}
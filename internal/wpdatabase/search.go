@@ -0,0 +1,237 @@
+package wpdatabase
+
+import (
+	"NovelScraper/internal/models"
+	"NovelScraper/internal/sqldb"
+	"NovelScraper/utils"
+	"database/sql"
+	"fmt"
+	"html"
+	"strings"
+	"unicode"
+)
+
+// The search index is an FTS5 table on SQLite and a table with a generated
+// tsvector column on PostgreSQL; see the search migrations. Both hold the
+// searchable text of a listing normalized by utils.NormalizePersian and are
+// written by SaveProduct in the transaction that publishes the listing.
+
+// The index returns matches between these two characters, which
+// markMatches turns into <mark> tags once the text around them has been
+// escaped. They are removed from indexed text so only matches carry them.
+const (
+	matchStart = "\x01"
+	matchEnd   = "\x02"
+)
+
+var (
+	stripMatchMarks = strings.NewReplacer(matchStart, "", matchEnd, "")
+	matchTags       = strings.NewReplacer(matchStart, "<mark>", matchEnd, "</mark>")
+)
+
+// searchText is text as it is stored in the search index: the words of
+// an HTML fragment, normalized.
+func searchText(s string) string {
+	return stripMatchMarks.Replace(utils.NormalizePersian(utils.HTMLText(s)))
+}
+
+// markMatches escapes text returned by the index for HTML and marks its
+// matches with <mark> tags. The indexed text is plain text that may
+// contain "<" and "&", e.g. from an escaped tag in a title.
+func markMatches(s string) string {
+	return matchTags.Replace(html.EscapeString(s))
+}
+
+// indexProduct writes the searchable text of the listing id.
+func indexProduct(tx *sqldb.Tx, id int64, p models.Product) error {
+	fields := []interface{}{
+		searchText(p.TitleEnglish),
+		searchText(p.TitleFarsi),
+		searchText(p.Brand),
+		searchText(p.DescriptionEnglish),
+		searchText(p.DescriptionFarsi),
+	}
+	if tx.Dialect == sqldb.Postgres {
+		_, err := tx.Exec(`INSERT INTO wp_products_search (product_id, title_english, title_farsi, brand, description_english, description_farsi)
+			VALUES (?, ?, ?, ?, ?, ?)
+			ON CONFLICT(product_id) DO UPDATE SET
+				title_english=excluded.title_english,
+				title_farsi=excluded.title_farsi,
+				brand=excluded.brand,
+				description_english=excluded.description_english,
+				description_farsi=excluded.description_farsi`,
+			append([]interface{}{id}, fields...)...)
+		return err
+	}
+
+	// FTS5 tables have no upsert, so the old row is replaced.
+	if _, err := tx.Exec("DELETE FROM wp_products_fts WHERE rowid = ?", id); err != nil {
+		return err
+	}
+	stmt, err := tx.Prepared(`INSERT INTO wp_products_fts (rowid, title_english, title_farsi, brand, description_english, description_farsi)
+		VALUES (?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	_, err = stmt.Exec(append([]interface{}{id}, fields...)...)
+	return err
+}
+
+// indexPublished fills the SQLite search index with the listings published
+// before it existed. wp_products keeps no English descriptions, so those are
+// indexed when a product is published again.
+func indexPublished(tx *sql.Tx) error {
+	rows, err := tx.Query(`SELECT id, COALESCE(title_english, ''), COALESCE(title_farsi, ''), COALESCE(brand, ''),
+		COALESCE(description_farsi, '') FROM wp_products`)
+	if err != nil {
+		return err
+	}
+	var listings [][5]interface{}
+	for rows.Next() {
+		var id int64
+		var titleEnglish, titleFarsi, brand, descriptionFarsi string
+		if err := rows.Scan(&id, &titleEnglish, &titleFarsi, &brand, &descriptionFarsi); err != nil {
+			rows.Close()
+			return err
+		}
+		listings = append(listings, [5]interface{}{id, searchText(titleEnglish), searchText(titleFarsi), searchText(brand), searchText(descriptionFarsi)})
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	stmt, err := tx.Prepare(`INSERT INTO wp_products_fts (rowid, title_english, title_farsi, brand, description_english, description_farsi)
+		VALUES (?, ?, ?, ?, '', ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for _, l := range listings {
+		if _, err := stmt.Exec(l[:]...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// searchTerms splits a search into the words looked up in the index. Only
+// letters, digits and combining marks are kept, so a term can be put in a
+// query of either backend without escaping.
+func searchTerms(query string) []string {
+	return strings.FieldsFunc(strings.ToLower(utils.NormalizePersian(query)), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r) && !unicode.Is(unicode.Mn, r)
+	})
+}
+
+// matchQuery returns the full-text query of the backend for terms. Every
+// term must be found, and is matched as a prefix so a word being typed
+// already finds results.
+func matchQuery(dialect sqldb.Dialect, terms []string) string {
+	parts := make([]string, len(terms))
+	for i, term := range terms {
+		if dialect == sqldb.Postgres {
+			parts[i] = term + ":*"
+		} else {
+			parts[i] = `"` + term + `"*`
+		}
+	}
+	if dialect == sqldb.Postgres {
+		return strings.Join(parts, " & ")
+	}
+	return strings.Join(parts, " ")
+}
+
+// searchQueries holds the search and count queries of each backend. The
+// search query selects listingColumns followed by the score, the title and
+// the snippet with their matches between matchStart and matchEnd. Titles
+// weigh more than brands, and brands more than descriptions.
+var searchQueries = map[sqldb.Dialect]struct{ search, count string }{
+	sqldb.SQLite: {
+		search: `SELECT ` + listingColumns + `,
+			-bm25(wp_products_fts, 10.0, 10.0, 5.0, 1.0, 1.0),
+			highlight(wp_products_fts, 1, char(1), char(2)),
+			snippet(wp_products_fts, -1, char(1), char(2), '…', 24)
+			FROM wp_products_fts JOIN wp_products p ON p.id = wp_products_fts.rowid
+			WHERE wp_products_fts MATCH ? AND p.visibility = 'visible'
+			ORDER BY bm25(wp_products_fts, 10.0, 10.0, 5.0, 1.0, 1.0), p.id DESC
+			LIMIT ? OFFSET ?`,
+		count: `SELECT COUNT(*)
+			FROM wp_products_fts JOIN wp_products p ON p.id = wp_products_fts.rowid
+			WHERE wp_products_fts MATCH ? AND p.visibility = 'visible'`,
+	},
+	sqldb.Postgres: {
+		search: `SELECT ` + listingColumns + `,
+			ts_rank(s.document, q.query),
+			ts_headline('simple', s.title_farsi, q.query, 'StartSel=' || chr(1) || ', StopSel=' || chr(2) || ', HighlightAll=true'),
+			ts_headline('simple', s.title_english || ' ' || s.brand || ' ' || s.description_farsi || ' ' || s.description_english,
+				q.query, 'StartSel=' || chr(1) || ', StopSel=' || chr(2) || ', MaxWords=24, MinWords=8')
+			FROM wp_products_search s
+			JOIN wp_products p ON p.id = s.product_id
+			CROSS JOIN to_tsquery('simple', ?) AS q(query)
+			WHERE s.document @@ q.query AND p.visibility = 'visible'
+			ORDER BY ts_rank(s.document, q.query) DESC, p.id DESC
+			LIMIT ? OFFSET ?`,
+		count: `SELECT COUNT(*)
+			FROM wp_products_search s
+			JOIN wp_products p ON p.id = s.product_id
+			WHERE s.document @@ to_tsquery('simple', ?) AND p.visibility = 'visible'`,
+	},
+}
+
+// SearchProducts returns the listed products matching filters.Query, most
+// relevant first, with the matched text marked.
+func (repo *WPRepository) SearchProducts(filters models.ProductFilters) ([]models.WordpressProduct, error) {
+	terms := searchTerms(filters.Query)
+	if len(terms) == 0 {
+		return nil, nil
+	}
+	queries, ok := searchQueries[repo.DB.Dialect]
+	if !ok {
+		return nil, fmt.Errorf("search is not supported on %s", repo.DB.Dialect)
+	}
+
+	rows, err := repo.DB.Query(queries.search, matchQuery(repo.DB.Dialect, terms), filters.Limit, filters.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var products []models.WordpressProduct
+	for rows.Next() {
+		var match models.SearchMatch
+		p, err := scanListing(rows, &match.Score, &match.Title, &match.Snippet)
+		if err != nil {
+			return nil, err
+		}
+		match.Title = markMatches(match.Title)
+		match.Snippet = markMatches(match.Snippet)
+		p.Match = &match
+		products = append(products, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	if err := repo.attachVariants(products); err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+// CountSearchResults returns how many listed products match query, for
+// pagination.
+func (repo *WPRepository) CountSearchResults(query string) (int, error) {
+	terms := searchTerms(query)
+	if len(terms) == 0 {
+		return 0, nil
+	}
+	queries, ok := searchQueries[repo.DB.Dialect]
+	if !ok {
+		return 0, fmt.Errorf("search is not supported on %s", repo.DB.Dialect)
+	}
+	var count int
+	err := repo.DB.QueryRow(queries.count, matchQuery(repo.DB.Dialect, terms)).Scan(&count)
+	return count, err
+}
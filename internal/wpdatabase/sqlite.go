@@ -2,47 +2,45 @@ package wpdatabase
 
 import (
 	"NovelScraper/internal/models"
+	"NovelScraper/internal/sqldb"
+	"NovelScraper/utils"
 	"database/sql"
+	"fmt"
 	"log"
-
-	_ "modernc.org/sqlite"
+	"strings"
 )
 
+// WPRepository implements Repository on SQLite and PostgreSQL.
 type WPRepository struct {
-	DB *sql.DB
+	DB *sqldb.DB
 }
 
-// InitDB creates and initializes the wordpress.db database.
-func InitDB(filepath string) *WPRepository {
-	db, err := sql.Open("sqlite", filepath)
+// InitDB opens the wordpress.db database. Like database.InitDB it refuses
+// a schema with pending migrations, but returns an error instead of
+// exiting, as the daemon opens it for every publish.
+func InitDB(cfg sqldb.Config) (*WPRepository, error) {
+	db, err := Open(cfg)
 	if err != nil {
-		log.Fatalf("Error opening wordpress.db: %v", err)
-	}
-
-	createTableSQL := `
-	CREATE TABLE IF NOT EXISTS wp_products (
-		"id" INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
-		"product_url" TEXT UNIQUE,
-		"asin" TEXT,
-		"title_farsi" TEXT,
-		"title_english" TEXT,
-		"slug" TEXT,
-		"image_url" TEXT,
-		"original_price" REAL,
-		"discount_price" REAL,
-		"discount_percent" INTEGER,
-		"brand" TEXT,
-		"availability" TEXT,
-		"description_farsi" TEXT,
-		"specifications" TEXT
-	);`
-	_, err = db.Exec(createTableSQL)
+		return nil, fmt.Errorf("error opening wordpress.db: %w", err)
+	}
+
+	migrator, err := NewMigrator(db)
 	if err != nil {
-		log.Fatalf("Error creating wp_products table: %v", err)
+		db.Close()
+		return nil, fmt.Errorf("error loading wordpress.db migrations: %w", err)
+	}
+	if err := migrator.Check(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("the %s wordpress database is not up to date (%v); run with -task=migrate first", cfg.Dialect, err)
 	}
 
 	log.Println("wordpress.db and wp_products table initialized successfully.")
-	return &WPRepository{DB: db}
+	return &WPRepository{DB: db}, nil
+}
+
+// Open connects to a wordpress database without checking its schema.
+func Open(cfg sqldb.Config) (*sqldb.DB, error) {
+	return sqldb.Open(cfg)
 }
 
 func (repo *WPRepository) Close() {
@@ -51,34 +49,293 @@ func (repo *WPRepository) Close() {
 
 // SaveProduct inserts or updates a product in the clean database.
 // SaveProduct now accepts the raw product and the generated asin and slug.
+// The listing, its search index entry, its reviews and its variants are
+// written in one transaction, so the API server never reads a half-published
+// product.
 func (repo *WPRepository) SaveProduct(p models.Product, asin string, slug string) error {
 	query := `
 	INSERT INTO wp_products (
 		product_url, asin, title_farsi, title_english, slug, image_url, 
 		original_price, discount_price, discount_percent, brand, availability,
-		description_farsi, specifications
-	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		description_farsi, specifications, attributes,
+		model_number, ean, upc, country_of_origin, length_cm, width_cm, height_cm, weight_g,
+		aplus_html, seller_name, sold_by_amazon, fulfilled_by_amazon, prime_eligible, delivery_estimate,
+		offers, effective_price, effective_discount_percent,
+		product_type, parent_asin, variation_dimensions, rating, rating_count, rating_histogram,
+		currency, availability_state, stock_count, visibility, source_site
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	ON CONFLICT(product_url) DO UPDATE SET
 		title_farsi=excluded.title_farsi,
 		slug=excluded.slug,
 		image_url=excluded.image_url,
 		original_price=excluded.original_price,
 		discount_price=excluded.discount_price,
-		discount_percent=excluded.discount_percent;
+		discount_percent=excluded.discount_percent,
+		specifications=excluded.specifications,
+		attributes=excluded.attributes,
+		model_number=excluded.model_number,
+		ean=excluded.ean,
+		upc=excluded.upc,
+		country_of_origin=excluded.country_of_origin,
+		length_cm=excluded.length_cm,
+		width_cm=excluded.width_cm,
+		height_cm=excluded.height_cm,
+		weight_g=excluded.weight_g,
+		aplus_html=excluded.aplus_html,
+		seller_name=excluded.seller_name,
+		sold_by_amazon=excluded.sold_by_amazon,
+		fulfilled_by_amazon=excluded.fulfilled_by_amazon,
+		prime_eligible=excluded.prime_eligible,
+		delivery_estimate=excluded.delivery_estimate,
+		offers=excluded.offers,
+		effective_price=excluded.effective_price,
+		effective_discount_percent=excluded.effective_discount_percent,
+		product_type=excluded.product_type,
+		parent_asin=excluded.parent_asin,
+		variation_dimensions=excluded.variation_dimensions,
+		rating=excluded.rating,
+		rating_count=excluded.rating_count,
+		rating_histogram=excluded.rating_histogram,
+		currency=excluded.currency,
+		availability=excluded.availability,
+		availability_state=excluded.availability_state,
+		stock_count=excluded.stock_count,
+		visibility=excluded.visibility,
+		source_site=excluded.source_site
+	RETURNING id;
 	`
+	// Publish the translated A+ content, or the original if translation failed.
+	// Translations saved before they were sanitized are cleaned here.
+	aplus := p.APlusFarsi
+	if aplus == "" {
+		aplus = p.APlusHTML
+	}
+	aplus = utils.SanitizeHTML(aplus)
+
+	// Rows scraped before prices carried a currency use the site's own.
+	currency := p.Currency
+	if currency == "" {
+		currency = utils.LocaleForSite(p.SourceSite).Currency
+	}
+
+	state, stockCount := p.NormalizedAvailability()
+
+	productType := "simple"
+	if p.ParentASIN != "" {
+		productType = "variable"
+	}
+
+	tx, err := repo.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// A listing published under an older URL of the same ASIN moves to the
+	// new URL, so the upsert below updates it instead of adding a duplicate.
+	if asin != "" {
+		if _, err := tx.Exec("UPDATE wp_products SET product_url = ? WHERE asin = ? AND product_url != ?", p.ProductURL, asin, p.ProductURL); err != nil {
+			return err
+		}
+	}
+
+	stmt, err := tx.Prepared(query)
+	if err != nil {
+		return err
+	}
 	// Use the passed-in asin and slug variables directly.
-	_, err := repo.DB.Exec(query,
+	var id int64
+	err = stmt.QueryRow(
 		p.ProductURL, asin, p.TitleFarsi, p.TitleEnglish, slug, p.MainImageURL,
 		p.OriginalPrice, p.DiscountPrice, p.DiscountPercent, p.Brand, p.Availability,
-		p.DescriptionFarsi, p.Specifications,
-	)
+		p.DescriptionFarsi, p.Specifications, p.Specs,
+		p.ModelNumber, p.EAN, p.UPC, p.CountryOfOrigin, p.LengthCM, p.WidthCM, p.HeightCM, p.WeightGrams,
+		aplus, p.SellerName, p.SoldByAmazon, p.FulfilledByAmazon, p.PrimeEligible, p.DeliveryEstimate,
+		p.Offers, p.EffectivePrice, p.EffectiveDiscountPercent,
+		productType, p.ParentASIN, p.VariationDimensions, p.Rating, p.RatingCount, p.RatingHistogram,
+		currency, state, stockCount, state.Visibility(), p.SourceSite,
+	).Scan(&id)
+	if err == nil {
+		err = indexProduct(tx, id, p)
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(p.Reviews) > 0 {
+		if err := saveReviews(tx, asin, p.Reviews); err != nil {
+			return err
+		}
+	}
+
+	if p.ParentASIN != "" && len(p.Variants) > 0 {
+		if err := saveVariants(tx, p.SourceSite, p.ParentASIN, p.Variants); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// UpdatePrices writes the result of a refresh to a published product,
+// leaving its translated text alone. The availability decides whether the
+// product stays listed.
+func (repo *WPRepository) UpdatePrices(asin string, p models.Product) error {
+	state, stockCount := p.NormalizedAvailability()
+	_, err := repo.DB.Exec(`
+	UPDATE wp_products SET
+		availability = ?,
+		availability_state = ?,
+		stock_count = ?,
+		visibility = ?,
+		original_price = ?,
+		discount_price = ?,
+		discount_percent = ?,
+		currency = COALESCE(NULLIF(?, ''), currency),
+		offers = ?,
+		effective_price = ?,
+		effective_discount_percent = ?
+	WHERE asin = ?`,
+		p.Availability, state, stockCount, state.Visibility(),
+		p.OriginalPrice, p.DiscountPrice, p.DiscountPercent, p.Currency,
+		p.Offers, p.EffectivePrice, p.EffectiveDiscountPercent, asin)
 	return err
 }
 
+// SetAvailability changes only the availability of a published product,
+// e.g. when its page has been removed from the marketplace.
+func (repo *WPRepository) SetAvailability(asin string, state models.AvailabilityState) error {
+	_, err := repo.DB.Exec(`
+	UPDATE wp_products SET availability_state = ?, stock_count = 0, visibility = ?
+	WHERE asin = ?`, state, state.Visibility(), asin)
+	return err
+}
+
+// HasParentListing reports whether a variable product for parentASIN on
+// sourceSite has already been published from a URL other than productURL.
+func (repo *WPRepository) HasParentListing(sourceSite, parentASIN, productURL string) (bool, error) {
+	var count int
+	err := repo.DB.QueryRow(
+		"SELECT COUNT(*) FROM wp_products WHERE source_site = ? AND parent_asin = ? AND product_url != ?",
+		sourceSite, parentASIN, productURL,
+	).Scan(&count)
+	return count > 0, err
+}
+
+// SaveVariants replaces the published variations of a parent ASIN.
+func (repo *WPRepository) SaveVariants(sourceSite, parentASIN string, variants []models.Variant) error {
+	tx, err := repo.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := saveVariants(tx, sourceSite, parentASIN, variants); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func saveVariants(tx *sqldb.Tx, sourceSite, parentASIN string, variants []models.Variant) error {
+	if _, err := tx.Exec("DELETE FROM wp_product_variants WHERE source_site = ? AND parent_asin = ?", sourceSite, parentASIN); err != nil {
+		return err
+	}
+	stmt, err := tx.Prepared(`INSERT INTO wp_product_variants (source_site, parent_asin, asin, attributes, price, availability, url)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+
+	for _, v := range variants {
+		if _, err := stmt.Exec(sourceSite, parentASIN, v.ASIN, v.Attributes, v.Price, v.Availability, v.URL); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SaveReviews replaces the published reviews of a product.
+func (repo *WPRepository) SaveReviews(asin string, reviews []models.Review) error {
+	tx, err := repo.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := saveReviews(tx, asin, reviews); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func saveReviews(tx *sqldb.Tx, asin string, reviews []models.Review) error {
+	if _, err := tx.Exec("DELETE FROM wp_reviews WHERE asin = ?", asin); err != nil {
+		return err
+	}
+	stmt, err := tx.Prepared(`INSERT INTO wp_reviews (asin, author, stars, title, body, reviewed_at, verified, helpful_votes)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+
+	for _, r := range reviews {
+		if _, err := stmt.Exec(asin, r.Author, r.Stars, r.Title, r.Body, r.ReviewedAt, r.Verified, r.HelpfulVotes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetReviews returns up to limit published reviews of a product.
+func (repo *WPRepository) GetReviews(asin string, limit int) ([]models.Review, error) {
+	rows, err := repo.DB.Query(`SELECT author, stars, title, body, reviewed_at, verified, helpful_votes
+		FROM wp_reviews WHERE asin = ? ORDER BY id LIMIT ?`, asin, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reviews := []models.Review{}
+	for rows.Next() {
+		var r models.Review
+		var reviewedAt sql.NullTime
+		if err := rows.Scan(&r.Author, &r.Stars, &r.Title, &r.Body, &reviewedAt, &r.Verified, &r.HelpfulVotes); err != nil {
+			return nil, err
+		}
+		r.ReviewedAt = reviewedAt.Time
+		reviews = append(reviews, r)
+	}
+	return reviews, rows.Err()
+}
+
+// listingColumns are the wp_products columns read by scanListing.
+const listingColumns = `p.source_site, p.asin, p.title_farsi, p.slug, p.image_url, p.original_price, p.discount_price, p.product_url, p.attributes,
+		p.model_number, p.ean, p.upc, p.country_of_origin, p.length_cm, p.width_cm, p.height_cm, p.weight_g, p.aplus_html,
+		p.seller_name, p.sold_by_amazon, p.fulfilled_by_amazon, p.prime_eligible, p.delivery_estimate,
+		p.offers, p.effective_price, p.effective_discount_percent, p.product_type, p.parent_asin, p.variation_dimensions,
+		p.rating, p.rating_count, p.rating_histogram, p.currency, p.lowest_price_30d, p.availability_state, p.stock_count`
+
+// scanListing reads the listingColumns of a row, followed by extra.
+func scanListing(rows *sql.Rows, extra ...interface{}) (models.WordpressProduct, error) {
+	var p models.WordpressProduct
+	dest := []interface{}{&p.SourceSite, &p.ID, &p.Title, &p.Slug, &p.ImageURL, &p.RealPrice, &p.DiscountedPrice, &p.LinkOfProduct, &p.Attributes,
+		&p.ModelNumber, &p.EAN, &p.UPC, &p.CountryOfOrigin, &p.LengthCM, &p.WidthCM, &p.HeightCM, &p.WeightGrams, &p.APlusHTML,
+		&p.SellerName, &p.SoldByAmazon, &p.FulfilledByAmazon, &p.PrimeEligible, &p.DeliveryEstimate,
+		&p.Offers, &p.EffectivePrice, &p.EffectiveDiscountPercent, &p.ProductType, &p.ParentASIN, &p.VariationDimensions,
+		&p.Rating, &p.RatingCount, &p.RatingHistogram, &p.Currency, &p.LowestPrice30d, &p.AvailabilityState, &p.StockCount}
+	err := rows.Scan(append(dest, extra...)...)
+	return p, err
+}
+
 // GetProducts retrieves a paginated list of products for the API.
 func (repo *WPRepository) GetProducts(filters models.ProductFilters) ([]models.WordpressProduct, error) {
 	// (This function can be enhanced with filters later if needed)
-	query := "SELECT asin, title_farsi, slug, image_url, original_price, discount_price, product_url FROM wp_products ORDER BY id DESC LIMIT ? OFFSET ?"
+	orderBy := "p.id DESC"
+	if filters.SortBy == "discount" {
+		// Rank by what the shopper really saves, coupons and offers included.
+		orderBy = "p.effective_discount_percent DESC, p.id DESC"
+	}
+	query := `SELECT ` + listingColumns + `
+		FROM wp_products p WHERE p.visibility = 'visible' ORDER BY ` + orderBy + ` LIMIT ? OFFSET ?`
 
 	rows, err := repo.DB.Query(query, filters.Limit, filters.Offset)
 	if err != nil {
@@ -88,18 +345,72 @@ func (repo *WPRepository) GetProducts(filters models.ProductFilters) ([]models.W
 
 	var products []models.WordpressProduct
 	for rows.Next() {
-		var p models.WordpressProduct
-		if err := rows.Scan(&p.ID, &p.Title, &p.Slug, &p.ImageURL, &p.RealPrice, &p.DiscountedPrice, &p.LinkOfProduct); err != nil {
-			continue
+		p, err := scanListing(rows)
+		if err != nil {
+			return nil, err
 		}
 		products = append(products, p)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	if err := repo.attachVariants(products); err != nil {
+		return nil, err
+	}
 	return products, nil
 }
 
-// CountProducts returns the total number of products for pagination.
+// variantKey identifies the variations of one parent ASIN.
+type variantKey struct {
+	sourceSite, parentASIN string
+}
+
+// attachVariants adds the variations of the variable products, reading all
+// of them in one query. Call it once the listing rows are read, as SQLite
+// would otherwise need a second connection while rows is still open.
+func (repo *WPRepository) attachVariants(products []models.WordpressProduct) error {
+	var placeholders []string
+	var args []interface{}
+	for _, p := range products {
+		if p.ParentASIN != "" {
+			placeholders = append(placeholders, "?")
+			args = append(args, p.ParentASIN)
+		}
+	}
+	if len(args) == 0 {
+		return nil
+	}
+
+	rows, err := repo.DB.Query(`SELECT source_site, parent_asin, asin, attributes, price, availability, url
+		FROM wp_product_variants WHERE parent_asin IN (`+strings.Join(placeholders, ", ")+`) ORDER BY id`, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	variants := make(map[variantKey][]models.Variant)
+	for rows.Next() {
+		var key variantKey
+		var v models.Variant
+		if err := rows.Scan(&key.sourceSite, &key.parentASIN, &v.ASIN, &v.Attributes, &v.Price, &v.Availability, &v.URL); err != nil {
+			return err
+		}
+		variants[key] = append(variants[key], v)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for i := range products {
+		products[i].Variants = variants[variantKey{products[i].SourceSite, products[i].ParentASIN}]
+	}
+	return nil
+}
+
+// CountProducts returns the total number of listed products for pagination.
 func (repo *WPRepository) CountProducts() (int, error) {
 	var count int
-	err := repo.DB.QueryRow("SELECT COUNT(*) FROM wp_products").Scan(&count)
+	err := repo.DB.QueryRow("SELECT COUNT(*) FROM wp_products WHERE visibility = 'visible'").Scan(&count)
 	return count, err
 }
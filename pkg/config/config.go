@@ -3,6 +3,8 @@ package config
 import (
 	"log"
 	"os"
+	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -23,19 +25,232 @@ type ScraperConfig struct {
 
 // AmazonConfig holds settings specific to Amazon.
 type AmazonConfig struct {
-	BaseURL string `yaml:"base_url"`
+	BaseURL    string `yaml:"base_url"`
+	MaxReviews int    `yaml:"max_reviews"`
+}
+
+// NoonConfig holds settings specific to Noon.
+type NoonConfig struct {
+	BaseURL     string `yaml:"base_url"`
+	Locale      string `yaml:"locale"`
+	DealsPath   string `yaml:"deals_path"`
+	SearchQuery string `yaml:"search_query"`
+	MaxPages    int    `yaml:"max_pages"`
+}
+
+// PublishConfig restricts which completed products are published.
+type PublishConfig struct {
+	OnlySoldByAmazon      bool `yaml:"only_sold_by_amazon"`
+	OnlyFulfilledByAmazon bool `yaml:"only_fulfilled_by_amazon"`
+	OnlyPrime             bool `yaml:"only_prime"`
+}
+
+// RefreshPolicy sets how often products matching Status and DealType are
+// re-scraped. Empty fields match every product.
+type RefreshPolicy struct {
+	Status   string        `yaml:"status"`
+	DealType string        `yaml:"deal_type"`
+	Interval time.Duration `yaml:"interval"`
+}
+
+// RefreshConfig controls re-scraping of prices and availability.
+type RefreshConfig struct {
+	DefaultInterval time.Duration   `yaml:"default_interval"`
+	Policies        []RefreshPolicy `yaml:"policies"`
+}
+
+// IntervalFor returns the interval of the first policy matching a product,
+// or DefaultInterval if none does. Zero means the product is not refreshed.
+func (c RefreshConfig) IntervalFor(status, dealType string) time.Duration {
+	for _, p := range c.Policies {
+		if (p.Status == "" || p.Status == status) && (p.DealType == "" || p.DealType == dealType) {
+			return p.Interval
+		}
+	}
+	return c.DefaultInterval
+}
+
+// QueueConfig tunes how pipeline stages claim products from the job queue.
+type QueueConfig struct {
+	BatchSize   int           `yaml:"batch_size"`   // jobs claimed at a time
+	Lease       time.Duration `yaml:"lease"`        // how long a claimed job is reserved; renewed as each job of a batch starts
+	BaseBackoff time.Duration `yaml:"base_backoff"` // delay after the first failure, doubled after each one
+	MaxBackoff  time.Duration `yaml:"max_backoff"`
+	MaxAttempts int           `yaml:"max_attempts"` // attempts before a stage gives up on a product
+}
+
+// WithDefaults fills in unset fields.
+func (c QueueConfig) WithDefaults() QueueConfig {
+	if c.BatchSize <= 0 {
+		c.BatchSize = 20
+	}
+	if c.Lease <= 0 {
+		c.Lease = 30 * time.Minute
+	}
+	if c.BaseBackoff <= 0 {
+		c.BaseBackoff = time.Minute
+	}
+	if c.MaxBackoff <= 0 {
+		c.MaxBackoff = 6 * time.Hour
+	}
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = 5
+	}
+	return c
+}
+
+// DatabaseConfig selects where products.db and wordpress.db are stored. With
+// the postgres backend the scrapers and the API server of several machines
+// can share one server; the two DSNs should then name different databases.
+type DatabaseConfig struct {
+	Backend      string `yaml:"backend"`       // sqlite (default) or postgres
+	ProductsDSN  string `yaml:"products_dsn"`  // file name for sqlite, connection string for postgres
+	WordpressDSN string `yaml:"wordpress_dsn"` // same, for the published catalog
+
+	// BusyTimeout is how long SQLite waits for a lock held by another
+	// connection or process, e.g. the API server reading wordpress.db
+	// while a publish writes to it, before failing with SQLITE_BUSY.
+	BusyTimeout   time.Duration `yaml:"busy_timeout"`
+	ProductsPool  PoolConfig    `yaml:"products_pool"`
+	WordpressPool PoolConfig    `yaml:"wordpress_pool"`
+}
+
+// PoolConfig sizes the connection pool of one database.
+type PoolConfig struct {
+	MaxOpen     int           `yaml:"max_open"`     // open connections at most
+	MaxIdle     int           `yaml:"max_idle"`     // idle connections kept, max_open if unset
+	MaxLifetime time.Duration `yaml:"max_lifetime"` // zero keeps connections until they fail
+}
+
+// withDefaults fills in unset fields, starting from maxOpen connections.
+func (c PoolConfig) withDefaults(maxOpen int) PoolConfig {
+	if c.MaxOpen <= 0 {
+		c.MaxOpen = maxOpen
+	}
+	if c.MaxIdle <= 0 || c.MaxIdle > c.MaxOpen {
+		c.MaxIdle = c.MaxOpen
+	}
+	return c
+}
+
+// WithDefaults fills in unset fields. SQLite keeps its databases next to
+// the binary; PostgreSQL has no default server. wordpress.db gets the
+// larger pool as the API server reads it concurrently.
+func (c DatabaseConfig) WithDefaults() DatabaseConfig {
+	if c.Backend == "" {
+		c.Backend = "sqlite"
+	}
+	if c.BusyTimeout <= 0 {
+		c.BusyTimeout = 5 * time.Second
+	}
+	c.ProductsPool = c.ProductsPool.withDefaults(4)
+	c.WordpressPool = c.WordpressPool.withDefaults(8)
+	if strings.EqualFold(c.Backend, "sqlite") {
+		if c.ProductsDSN == "" {
+			c.ProductsDSN = "products.db"
+		}
+		if c.WordpressDSN == "" {
+			c.WordpressDSN = "wordpress.db"
+		}
+	}
+	return c
+}
+
+// CrawlConfig controls product list crawls.
+type CrawlConfig struct {
+	// Incremental stops a crawl once StopAfterKnown products in a row are
+	// already stored or were found by the previous crawl of the listing.
+	Incremental    bool `yaml:"incremental"`
+	StopAfterKnown int  `yaml:"stop_after_known"`
+}
+
+// WithDefaults fills in unset fields.
+func (c CrawlConfig) WithDefaults() CrawlConfig {
+	if c.StopAfterKnown <= 0 {
+		c.StopAfterKnown = 30
+	}
+	return c
+}
+
+// PipelineConfig tunes the automatic workflow, which streams products from
+// the listing through detail scraping and translation to publishing.
+type PipelineConfig struct {
+	DetailWorkers    int           `yaml:"detail_workers"`    // browsers scraping details; 0 uses scraper.workers
+	TranslateWorkers int           `yaml:"translate_workers"` // products translated at once
+	Buffer           int           `yaml:"buffer"`            // products waiting between two stages before the earlier one blocks
+	ProgressInterval time.Duration `yaml:"progress_interval"` // how often progress is logged
+}
+
+// WithDefaults fills in unset fields.
+func (c PipelineConfig) WithDefaults() PipelineConfig {
+	if c.TranslateWorkers <= 0 {
+		c.TranslateWorkers = 2
+	}
+	if c.Buffer <= 0 {
+		c.Buffer = 10
+	}
+	if c.ProgressInterval <= 0 {
+		c.ProgressInterval = 30 * time.Second
+	}
+	return c
+}
+
+// Schedule runs one task of the daemon at a fixed interval.
+type Schedule struct {
+	Name  string        `yaml:"name"`  // defaults to the task, plus the site if set
+	Task  string        `yaml:"task"`  // scrape-products, scrape-details, refresh, translate or publish
+	Site  string        `yaml:"site"`  // for scrape-products
+	Every time.Duration `yaml:"every"` // zero disables the schedule
+}
+
+// Key identifies the schedule in the run history.
+func (s Schedule) Key() string {
+	switch {
+	case s.Name != "":
+		return s.Name
+	case s.Site != "":
+		return s.Task + ":" + s.Site
+	default:
+		return s.Task
+	}
+}
+
+// DaemonConfig controls the long-running daemon task.
+type DaemonConfig struct {
+	Tick        time.Duration `yaml:"tick"`         // how often schedules are checked
+	LockTimeout time.Duration `yaml:"lock_timeout"` // after this a run left by a crashed daemon is taken over
+	Schedules   []Schedule    `yaml:"schedules"`
+}
+
+// WithDefaults fills in unset fields.
+func (c DaemonConfig) WithDefaults() DaemonConfig {
+	if c.Tick <= 0 {
+		c.Tick = time.Minute
+	}
+	if c.LockTimeout <= 0 {
+		c.LockTimeout = 6 * time.Hour
+	}
+	return c
 }
 
 // Config is the complete structure for the config.yml file.
 type Config struct {
 	Scraper    ScraperConfig `yaml:"scraper"`
 	Amazon     AmazonConfig  `yaml:"amazon"`
+	Noon       NoonConfig    `yaml:"noon"`
 	Translator struct {
 		PrimaryProvider   string           `yaml:"primary_provider"`
 		FallbackProviders []string         `yaml:"fallback_providers"`
 		Providers         []ProviderConfig `yaml:"providers"`
 	} `yaml:"translator"`
-	Server struct {
+	Database DatabaseConfig `yaml:"database"`
+	Publish  PublishConfig  `yaml:"publish"`
+	Refresh  RefreshConfig  `yaml:"refresh"`
+	Crawl    CrawlConfig    `yaml:"crawl"`
+	Queue    QueueConfig    `yaml:"queue"`
+	Pipeline PipelineConfig `yaml:"pipeline"`
+	Daemon   DaemonConfig   `yaml:"daemon"`
+	Server   struct {
 		ApiKey string `yaml:"api_key"`
 	} `yaml:"server"`
 }
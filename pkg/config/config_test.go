@@ -0,0 +1,113 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestRefreshIntervalFor(t *testing.T) {
+	var cfg Config
+	err := yaml.Unmarshal([]byte(`
+refresh:
+  default_interval: "24h"
+  policies:
+    - deal_type: "lightning"
+      interval: "30m"
+    - status: "published"
+      interval: "6h"
+    - status: "translation_failed"
+      interval: "0s"
+`), &cfg)
+	if err != nil {
+		t.Fatalf("failed to parse config: %v", err)
+	}
+
+	testCases := []struct {
+		status   string
+		dealType string
+		expected time.Duration
+	}{
+		{"published", "lightning", 30 * time.Minute},
+		{"needs_translation", "lightning", 30 * time.Minute},
+		{"published", "", 6 * time.Hour},
+		{"completed", "", 24 * time.Hour},
+		{"translation_failed", "", 0},
+	}
+
+	for _, tc := range testCases {
+		if got := cfg.Refresh.IntervalFor(tc.status, tc.dealType); got != tc.expected {
+			t.Errorf("IntervalFor(%q, %q) = %v, want %v", tc.status, tc.dealType, got, tc.expected)
+		}
+	}
+}
+
+func TestDaemonSchedules(t *testing.T) {
+	var cfg Config
+	err := yaml.Unmarshal([]byte(`
+daemon:
+  schedules:
+    - task: "scrape-products"
+      site: "noon.com"
+      every: "6h"
+    - name: "late-publish"
+      task: "publish"
+      every: "30m"
+    - task: "translate"
+      every: "1h"
+`), &cfg)
+	if err != nil {
+		t.Fatalf("failed to parse config: %v", err)
+	}
+
+	daemon := cfg.Daemon.WithDefaults()
+	if daemon.Tick != time.Minute || daemon.LockTimeout != 6*time.Hour {
+		t.Errorf("defaults = %v, %v", daemon.Tick, daemon.LockTimeout)
+	}
+	expected := []string{"scrape-products:noon.com", "late-publish", "translate"}
+	if len(daemon.Schedules) != len(expected) {
+		t.Fatalf("got %d schedules, want %d", len(daemon.Schedules), len(expected))
+	}
+	for i, s := range daemon.Schedules {
+		if s.Key() != expected[i] {
+			t.Errorf("schedule %d key = %q, want %q", i, s.Key(), expected[i])
+		}
+	}
+	if daemon.Schedules[0].Every != 6*time.Hour {
+		t.Errorf("every = %v", daemon.Schedules[0].Every)
+	}
+}
+
+func TestDatabaseDefaults(t *testing.T) {
+	db := DatabaseConfig{}.WithDefaults()
+	if db.Backend != "sqlite" || db.ProductsDSN != "products.db" || db.WordpressDSN != "wordpress.db" {
+		t.Errorf("sqlite defaults = %+v", db)
+	}
+	if db.BusyTimeout != 5*time.Second || db.ProductsPool.MaxOpen != 4 || db.WordpressPool.MaxIdle != 8 {
+		t.Errorf("pool defaults = %+v", db)
+	}
+
+	var cfg Config
+	err := yaml.Unmarshal([]byte(`
+database:
+  backend: "postgres"
+  products_dsn: "postgres://localhost/products"
+  busy_timeout: "2s"
+  wordpress_pool:
+    max_open: 16
+    max_idle: 32
+`), &cfg)
+	if err != nil {
+		t.Fatalf("failed to parse config: %v", err)
+	}
+	// A missing postgres DSN is left empty rather than pointing at a file.
+	db = cfg.Database.WithDefaults()
+	if db.ProductsDSN != "postgres://localhost/products" || db.WordpressDSN != "" {
+		t.Errorf("postgres defaults = %+v", db)
+	}
+	// More idle connections than open ones are capped.
+	if db.BusyTimeout != 2*time.Second || db.WordpressPool.MaxOpen != 16 || db.WordpressPool.MaxIdle != 16 {
+		t.Errorf("configured pool = %+v", db)
+	}
+}
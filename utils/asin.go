@@ -0,0 +1,61 @@
+package utils
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// asinRegex finds the ASIN in the product URL shapes Amazon links to:
+// /dp/ASIN, /gp/product/ASIN, /gp/aw/d/ASIN and /exec/obidos/ASIN/ASIN.
+var asinRegex = regexp.MustCompile(`(?i)/(?:dp|gp/product|gp/aw/d|exec/obidos/asin)/([A-Z0-9]{10})(?:[/?#]|$)`)
+
+// ExtractASIN returns the upper-cased ASIN of an Amazon product URL, or ""
+// when the URL does not point at a product.
+func ExtractASIN(rawURL string) string {
+	matches := asinRegex.FindStringSubmatch(rawURL)
+	if len(matches) < 2 {
+		return ""
+	}
+	return strings.ToUpper(matches[1])
+}
+
+// ExtractNoonSKU returns the Noon SKU of a product URL (e.g. N53346840A),
+// which is the path segment right before "/p/", or "" when there is none.
+func ExtractNoonSKU(rawURL string) string {
+	parts := strings.Split(strings.Split(rawURL, "?")[0], "/")
+	for i, part := range parts {
+		if part == "p" && i > 0 {
+			return parts[i-1]
+		}
+	}
+	return ""
+}
+
+// ProductKey returns the marketplace item ID in a product URL: the Noon SKU
+// on noon.com and the ASIN on Amazon, the site of rows without one.
+func ProductKey(sourceSite, rawURL string) string {
+	if sourceSite == "noon.com" {
+		return ExtractNoonSKU(rawURL)
+	}
+	return ExtractASIN(rawURL)
+}
+
+// CanonicalAmazonURL rewrites an Amazon product URL to the form
+// https://host/dp/ASIN, dropping the title slug, ref paths and query string.
+// URLs without an ASIN are returned unchanged.
+func CanonicalAmazonURL(rawURL string) string {
+	asin := ExtractASIN(rawURL)
+	if asin == "" {
+		return rawURL
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return rawURL
+	}
+	scheme := u.Scheme
+	if scheme == "" {
+		scheme = "https"
+	}
+	return scheme + "://" + strings.ToLower(u.Host) + "/dp/" + asin
+}
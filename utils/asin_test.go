@@ -0,0 +1,47 @@
+package utils
+
+import "testing"
+
+func TestCanonicalAmazonURL(t *testing.T) {
+	testCases := []struct {
+		input string
+		asin  string
+		url   string
+	}{
+		{
+			"https://www.amazon.ae/Calvin-Klein-Mens-Trunks-Pack/dp/B001BEC6S8?ref=dlx_deals_dg_dcl_B001BEC6S8_dt_sl14_4f_pi&pf_rd_r=7M5J",
+			"B001BEC6S8", "https://www.amazon.ae/dp/B001BEC6S8",
+		},
+		{"https://www.amazon.ae/dp/B0BZ8C6TJ1/ref=sr_1_3?th=1", "B0BZ8C6TJ1", "https://www.amazon.ae/dp/B0BZ8C6TJ1"},
+		{"https://www.amazon.ae/gp/product/b07w7zw1cm", "B07W7ZW1CM", "https://www.amazon.ae/dp/B07W7ZW1CM"},
+		{"https://www.amazon.ae/gp/aw/d/B0D9HZL12W?psc=1", "B0D9HZL12W", "https://www.amazon.ae/dp/B0D9HZL12W"},
+		{"https://www.amazon.ae/deals?ref=nav", "", "https://www.amazon.ae/deals?ref=nav"},
+	}
+
+	for _, tc := range testCases {
+		if got := ExtractASIN(tc.input); got != tc.asin {
+			t.Errorf("ExtractASIN(%q) = %q; want %q", tc.input, got, tc.asin)
+		}
+		if got := CanonicalAmazonURL(tc.input); got != tc.url {
+			t.Errorf("CanonicalAmazonURL(%q) = %q; want %q", tc.input, got, tc.url)
+		}
+	}
+}
+
+func TestProductKey(t *testing.T) {
+	testCases := []struct {
+		site, url, expected string
+	}{
+		{"noon.com", "/uae-en/sneakers/N53346840A/p/?o=abc", "N53346840A"},
+		{"noon.com", "https://www.noon.com/uae-en/jacket/N70012345V/p/", "N70012345V"},
+		{"noon.com", "https://www.noon.com/uae-en/help/", ""},
+		{"amazon.ae", "https://www.amazon.ae/dp/B0BZ8C6TJ1/ref=sr_1_3", "B0BZ8C6TJ1"},
+		{"", "https://www.amazon.ae/gp/product/b07w7zw1cm", "B07W7ZW1CM"},
+	}
+
+	for _, tc := range testCases {
+		if got := ProductKey(tc.site, tc.url); got != tc.expected {
+			t.Errorf("ProductKey(%q, %q) = %q; want %q", tc.site, tc.url, got, tc.expected)
+		}
+	}
+}
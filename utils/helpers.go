@@ -3,6 +3,7 @@ package utils
 import (
 	"regexp"
 	"strings"
+	"time"
 )
 
 // UniqueStrings یک اسلایس از رشته‌ها را دریافت کرده و یک اسلایس جدید
@@ -35,3 +36,32 @@ func CreateSlug(title string) string {
 
 	return slug
 }
+
+// invisibleChars holds the direction marks and zero-width characters Amazon
+// sprinkles through its detail lists (e.g. "Brand \u200f : \u200e Nike").
+var invisibleChars = strings.NewReplacer(
+	"\u200b", "", "\u200e", "", "\u200f", "", "\ufeff", "", "\u2060", "",
+	"\u202a", "", "\u202b", "", "\u202c", "", "\u202d", "", "\u202e", "",
+	"\u00a0", " ",
+)
+
+// CleanText strips invisible characters and collapses all whitespace runs into single spaces.
+func CleanText(s string) string {
+	return strings.Join(strings.Fields(invisibleChars.Replace(s)), " ")
+}
+
+// Backoff returns the delay before retry number attempt (starting at 1):
+// base doubled for every earlier attempt, capped at max.
+func Backoff(attempt int, base, max time.Duration) time.Duration {
+	delay := base
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= max {
+			return max
+		}
+	}
+	if delay > max {
+		return max
+	}
+	return delay
+}
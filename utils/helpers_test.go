@@ -0,0 +1,24 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	testCases := []struct {
+		attempt  int
+		expected time.Duration
+	}{
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{4, 8 * time.Minute},
+		{10, time.Hour},
+		{100, time.Hour},
+	}
+	for _, tc := range testCases {
+		if got := Backoff(tc.attempt, time.Minute, time.Hour); got != tc.expected {
+			t.Errorf("Backoff(%d) = %v, want %v", tc.attempt, got, tc.expected)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package utils
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Money is an amount in a given ISO 4217 currency.
+type Money struct {
+	Amount   float64 `json:"amount"`
+	Currency string  `json:"currency"`
+}
+
+func (m Money) String() string {
+	return fmt.Sprintf("%s %.2f", m.Currency, m.Amount)
+}
+
+// Locale describes how a marketplace writes prices: its decimal and digit
+// grouping separators and the currency assumed when the text names none.
+type Locale struct {
+	Decimal  rune
+	Group    rune
+	Currency string
+}
+
+// DefaultLocale is the amazon.ae / noon.com UAE format, e.g. "AED 1,079.00".
+var DefaultLocale = Locale{Decimal: '.', Group: ',', Currency: "AED"}
+
+// marketLocales maps marketplace hosts (without "www.") to their price format.
+var marketLocales = map[string]Locale{
+	"amazon.ae":    DefaultLocale,
+	"noon.com":     DefaultLocale,
+	"amazon.sa":    {Decimal: '.', Group: ',', Currency: "SAR"},
+	"amazon.eg":    {Decimal: '.', Group: ',', Currency: "EGP"},
+	"amazon.com":   {Decimal: '.', Group: ',', Currency: "USD"},
+	"amazon.co.uk": {Decimal: '.', Group: ',', Currency: "GBP"},
+	"amazon.de":    {Decimal: ',', Group: '.', Currency: "EUR"},
+	"amazon.fr":    {Decimal: ',', Group: ' ', Currency: "EUR"},
+	"amazon.it":    {Decimal: ',', Group: '.', Currency: "EUR"},
+	"amazon.es":    {Decimal: ',', Group: '.', Currency: "EUR"},
+	"amazon.nl":    {Decimal: ',', Group: '.', Currency: "EUR"},
+}
+
+// MarketplaceHost returns the host of a marketplace without "www.", given
+// either the host itself or any URL on it.
+func MarketplaceHost(site string) string {
+	host := site
+	if u, err := url.Parse(site); err == nil && u.Host != "" {
+		host = u.Host
+	}
+	return strings.TrimPrefix(strings.ToLower(host), "www.")
+}
+
+// LocaleForSite returns the price format of a marketplace, given either its
+// host ("amazon.de") or any URL on it. Unknown sites get DefaultLocale.
+func LocaleForSite(site string) Locale {
+	if locale, ok := marketLocales[MarketplaceHost(site)]; ok {
+		return locale
+	}
+	return DefaultLocale
+}
+
+// currencyMarkers maps currency symbols and codes found in price text to
+// ISO codes. Longer markers come first so "US$" is not read as "$".
+var currencyMarkers = []struct {
+	marker   string
+	currency string
+}{
+	{"AED", "AED"}, {"د.إ", "AED"},
+	{"SAR", "SAR"}, {"ر.س", "SAR"},
+	{"EGP", "EGP"}, {"ج.م", "EGP"},
+	{"EUR", "EUR"}, {"€", "EUR"},
+	{"GBP", "GBP"}, {"£", "GBP"},
+	{"USD", "USD"}, {"US$", "USD"}, {"$", "USD"},
+}
+
+// moneyRegex finds the first number, including its separators.
+// spacedMoneyRegex also allows spaces inside it, for locales that group
+// digits with (narrow) spaces such as "1 079,00 €".
+var (
+	moneyRegex       = regexp.MustCompile(`\d[\d.,']*`)
+	spacedMoneyRegex = regexp.MustCompile(`\d(?:[\d.,']|[ \x{00A0}\x{202F}]\d)*`)
+)
+
+// ParseMoney reads a price like "AED 1,079.00", "1.079,00 €" or
+// "١٬٠٧٩٫٥٠ د.إ" using the separators of the given locale. The currency is
+// taken from the text when it names one, otherwise from the locale.
+func ParseMoney(priceStr string, locale Locale) Money {
+	money := Money{Currency: detectCurrency(priceStr, locale.Currency)}
+
+	normalized := normalizeDigits(priceStr)
+	re := moneyRegex
+	if isSpace(locale.Group) {
+		re = spacedMoneyRegex
+	}
+	foundPrice := re.FindString(normalized)
+	if foundPrice == "" {
+		return money
+	}
+	foundPrice = strings.TrimRight(foundPrice, ".,' ")
+
+	cleanedStr := normalizeSeparators(foundPrice, locale)
+	amount, err := strconv.ParseFloat(cleanedStr, 64)
+	if err != nil {
+		log.Printf("ParseMoney: Failed to parse '%s' from original string '%s': %v", cleanedStr, priceStr, err)
+		return money
+	}
+	money.Amount = amount
+	return money
+}
+
+func detectCurrency(s, fallback string) string {
+	for _, m := range currencyMarkers {
+		if strings.Contains(s, m.marker) {
+			return m.currency
+		}
+	}
+	return fallback
+}
+
+// normalizeDigits converts Arabic-Indic and Persian digits to ASCII and the
+// Arabic decimal (٫) and thousands (٬) separators to '.' and ','.
+func normalizeDigits(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= '٠' && r <= '٩':
+			return '0' + (r - '٠')
+		case r >= '۰' && r <= '۹':
+			return '0' + (r - '۰')
+		case r == '٫':
+			return '.'
+		case r == '٬':
+			return ','
+		}
+		return r
+	}, s)
+}
+
+// normalizeSeparators turns a localized number into the "1234.56" form
+// strconv expects.
+func normalizeSeparators(num string, locale Locale) string {
+	num = strings.Map(func(r rune) rune {
+		if isSpace(r) || r == '\'' {
+			return -1
+		}
+		return r
+	}, num)
+
+	lastDot := strings.LastIndex(num, ".")
+	lastComma := strings.LastIndex(num, ",")
+
+	decimal := locale.Decimal
+	switch {
+	case lastDot >= 0 && lastComma >= 0:
+		// Both present: whichever comes last is the decimal separator.
+		if lastDot > lastComma {
+			decimal = '.'
+		} else {
+			decimal = ','
+		}
+	case lastDot >= 0 && strings.Count(num, ".") > 1:
+		decimal = ','
+	case lastComma >= 0 && strings.Count(num, ",") > 1:
+		decimal = '.'
+	}
+
+	var b strings.Builder
+	for _, r := range num {
+		switch {
+		case r == decimal:
+			b.WriteRune('.')
+		case r == '.' || r == ',':
+			// grouping separator
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func isSpace(r rune) bool {
+	return r == ' ' || r == '\u00a0' || r == '\u202f'
+}
@@ -0,0 +1,48 @@
+package utils
+
+import "testing"
+
+func TestParseMoney(t *testing.T) {
+	de := LocaleForSite("https://www.amazon.de/dp/B0TEST")
+	fr := LocaleForSite("amazon.fr")
+
+	testCases := []struct {
+		name     string
+		input    string
+		locale   Locale
+		expected Money
+	}{
+		{"UAE Price", "AED 1,079.00", DefaultLocale, Money{1079, "AED"}},
+		{"No Currency Uses Locale", "219.41", DefaultLocale, Money{219.41, "AED"}},
+		{"German Price", "1.079,00 €", de, Money{1079, "EUR"}},
+		{"German Thousands Only", "1.079 €", de, Money{1079, "EUR"}},
+		{"German Decimal Only", "19,99 €", de, Money{19.99, "EUR"}},
+		{"French Narrow Space Grouping", "1 079,50 €", fr, Money{1079.50, "EUR"}},
+		{"Arabic-Indic Digits", "١٬٠٧٩ د.إ", DefaultLocale, Money{1079, "AED"}},
+		{"Arabic Decimal Separator", "د.إ ٢١٩٫٤١", DefaultLocale, Money{219.41, "AED"}},
+		{"Persian Digits", "۱۲۵ SAR", DefaultLocale, Money{125, "SAR"}},
+		{"Dot Decimal On German Site", "1,079.00 €", de, Money{1079, "EUR"}},
+		{"Invalid String", "No Price", de, Money{0, "EUR"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := ParseMoney(tc.input, tc.locale)
+			if result != tc.expected {
+				t.Errorf("ParseMoney(%q) = %+v; want %+v", tc.input, result, tc.expected)
+			}
+		})
+	}
+}
+
+func TestLocaleForSite(t *testing.T) {
+	if got := LocaleForSite("www.amazon.de").Currency; got != "EUR" {
+		t.Errorf("LocaleForSite(www.amazon.de) currency = %s; want EUR", got)
+	}
+	if got := LocaleForSite("https://www.noon.com/uae-en/x/N123/p/"); got != DefaultLocale {
+		t.Errorf("LocaleForSite(noon URL) = %+v; want DefaultLocale", got)
+	}
+	if got := LocaleForSite("example.org"); got != DefaultLocale {
+		t.Errorf("LocaleForSite(example.org) = %+v; want DefaultLocale", got)
+	}
+}
@@ -0,0 +1,20 @@
+package utils
+
+import "strings"
+
+// persianReplacer maps the Arabic forms of ی and ک, which Arabic keyboards
+// and many product feeds produce, to the Persian ones, and the zero-width
+// non-joiner to a space so both halves of a word like می‌خواهم are words.
+var persianReplacer = strings.NewReplacer(
+	"ي", "ی",
+	"ى", "ی",
+	"ك", "ک",
+	"\u200c", " ",
+)
+
+// NormalizePersian rewrites s so that the spellings a shopper may type
+// match the same text. Search indexes store normalized text and queries
+// are normalized the same way before they are run.
+func NormalizePersian(s string) string {
+	return persianReplacer.Replace(s)
+}
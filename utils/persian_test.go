@@ -0,0 +1,19 @@
+package utils
+
+import "testing"
+
+func TestNormalizePersian(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{"كتري برقي", "کتری برقی"},
+		{"مى\u200cخواهم", "می خواهم"},
+		{"Kettle 1.7L", "Kettle 1.7L"},
+	}
+	for _, tc := range testCases {
+		if got := NormalizePersian(tc.input); got != tc.expected {
+			t.Errorf("NormalizePersian(%q) = %q, want %q", tc.input, got, tc.expected)
+		}
+	}
+}
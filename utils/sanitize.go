@@ -0,0 +1,194 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// allowedTags are the elements kept by SanitizeHTML, with the attributes each may keep.
+var allowedTags = map[atom.Atom][]string{
+	atom.Section: nil,
+	atom.H1:      nil,
+	atom.H2:      nil,
+	atom.H3:      nil,
+	atom.H4:      nil,
+	atom.H5:      nil,
+	atom.H6:      nil,
+	atom.P:       nil,
+	atom.Br:      nil,
+	atom.Ul:      nil,
+	atom.Ol:      nil,
+	atom.Li:      nil,
+	atom.Strong:  nil,
+	atom.B:       nil,
+	atom.Em:      nil,
+	atom.I:       nil,
+	atom.Table:   nil,
+	atom.Thead:   nil,
+	atom.Tbody:   nil,
+	atom.Tr:      nil,
+	atom.Th:      {"colspan", "rowspan"},
+	atom.Td:      {"colspan", "rowspan"},
+	atom.Img:     {"src", "alt"},
+}
+
+// droppedTags are removed together with everything inside them.
+var droppedTags = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Iframe: true,
+	atom.Form: true, atom.Button: true, atom.Input: true, atom.Select: true,
+	atom.Textarea: true, atom.Svg: true, atom.Object: true, atom.Embed: true,
+}
+
+// SanitizeHTML keeps only a small set of structural tags (headings, paragraphs,
+// lists, tables and images) from an HTML fragment. Other elements such as div
+// and span are unwrapped so their content survives, while scripts, forms and
+// the like are dropped entirely. Images keep their src only if it is http(s).
+func SanitizeHTML(fragment string) string {
+	nodes, err := html.ParseFragment(strings.NewReader(fragment), &html.Node{
+		Type:     html.ElementNode,
+		Data:     "div",
+		DataAtom: atom.Div,
+	})
+	if err != nil {
+		return ""
+	}
+
+	var builder strings.Builder
+	for _, n := range nodes {
+		writeSanitized(&builder, n)
+	}
+	out := spaceRuns.ReplaceAllString(builder.String(), " ")
+	out = spaceBetweenTags.ReplaceAllString(out, "><")
+	out = spaceAfterBlockOpen.ReplaceAllString(out, "$1")
+	out = spaceBeforeBlockClose.ReplaceAllString(out, "$1")
+	return strings.TrimSpace(out)
+}
+
+// Whitespace cleanup applied to the sanitized output.
+var (
+	spaceRuns             = regexp.MustCompile(` {2,}`)
+	spaceBetweenTags      = regexp.MustCompile(`>\s+<`)
+	spaceAfterBlockOpen   = regexp.MustCompile(`(<(?:section|h[1-6]|p|li|th|td)(?: [^>]*)?>) `)
+	spaceBeforeBlockClose = regexp.MustCompile(` (</(?:section|h[1-6]|p|li|th|td)>)`)
+)
+
+func writeSanitized(builder *strings.Builder, n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		text := CleanText(n.Data)
+		if text == "" {
+			if n.Data != "" && strings.TrimSpace(n.Data) == "" {
+				builder.WriteString(" ")
+			}
+			return
+		}
+		// Keep one separating space where the source had whitespace, so words
+		// in adjacent inline elements don't run together.
+		if strings.TrimLeft(n.Data, " \t\r\n") != n.Data {
+			text = " " + text
+		}
+		if strings.TrimRight(n.Data, " \t\r\n") != n.Data {
+			text += " "
+		}
+		builder.WriteString(html.EscapeString(text))
+		return
+	case html.ElementNode:
+		if droppedTags[n.DataAtom] {
+			return
+		}
+		attrs, allowed := allowedTags[n.DataAtom]
+		if !allowed {
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				writeSanitized(builder, c)
+			}
+			return
+		}
+
+		if n.DataAtom == atom.Img {
+			src := imageSource(n)
+			if src == "" {
+				return
+			}
+			builder.WriteString(`<img src="` + html.EscapeString(src) + `"`)
+			if alt := attr(n, "alt"); alt != "" {
+				builder.WriteString(` alt="` + html.EscapeString(alt) + `"`)
+			}
+			builder.WriteString(">")
+			return
+		}
+
+		builder.WriteString("<" + n.Data)
+		for _, key := range attrs {
+			if val := attr(n, key); val != "" {
+				builder.WriteString(" " + key + `="` + html.EscapeString(val) + `"`)
+			}
+		}
+		builder.WriteString(">")
+		if n.DataAtom == atom.Br {
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			writeSanitized(builder, c)
+		}
+		builder.WriteString("</" + n.Data + ">")
+	default:
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			writeSanitized(builder, c)
+		}
+	}
+}
+
+// HTMLText returns the text of an HTML fragment, e.g. a description, for a
+// search index. Elements are separated by a space and scripts, forms and
+// the like are dropped as in SanitizeHTML.
+func HTMLText(fragment string) string {
+	nodes, err := html.ParseFragment(strings.NewReader(fragment), &html.Node{
+		Type:     html.ElementNode,
+		Data:     "div",
+		DataAtom: atom.Div,
+	})
+	if err != nil {
+		return CleanText(fragment)
+	}
+
+	var parts []string
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		switch {
+		case n.Type == html.TextNode:
+			parts = append(parts, n.Data)
+		case n.Type == html.ElementNode && droppedTags[n.DataAtom]:
+		default:
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				walk(c)
+			}
+		}
+	}
+	for _, n := range nodes {
+		walk(n)
+	}
+	return CleanText(strings.Join(parts, " "))
+}
+
+// imageSource prefers the lazy-load attribute over the placeholder src.
+func imageSource(n *html.Node) string {
+	for _, key := range []string{"data-src", "src"} {
+		src := strings.TrimSpace(attr(n, key))
+		if strings.HasPrefix(src, "https://") || strings.HasPrefix(src, "http://") {
+			return src
+		}
+	}
+	return ""
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
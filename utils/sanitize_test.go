@@ -0,0 +1,67 @@
+package utils
+
+import "testing"
+
+func TestSanitizeHTML(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			"Unwraps Layout Elements",
+			`<div class="aplus-module"><div class="text"><h3 class="a-spacing-mini">Comfort</h3><p>Soft <span class="a-text-bold">mesh</span> upper.</p></div></div>`,
+			`<h3>Comfort</h3><p>Soft mesh upper.</p>`,
+		},
+		{
+			"Drops Scripts And Forms",
+			`<p>Text</p><script>alert(1)</script><form><button>Buy</button></form>`,
+			`<p>Text</p>`,
+		},
+		{
+			"Prefers Lazy Image Source",
+			`<img src="data:image/gif;base64,R0lGOD" data-src="https://m.media-amazon.com/images/S/aplus-media/a.jpg" alt="Sole" class="a-lazy-loaded">`,
+			`<img src="https://m.media-amazon.com/images/S/aplus-media/a.jpg" alt="Sole">`,
+		},
+		{
+			"Drops Unsafe Image",
+			`<img src="javascript:alert(1)">`,
+			``,
+		},
+		{
+			"Keeps Table Structure",
+			`<table class="a-bordered"><tr><th colspan="2" style="x">Model</th></tr><tr><td onclick="x()"> A </td><td>B</td></tr></table>`,
+			`<table><tbody><tr><th colspan="2">Model</th></tr><tr><td>A</td><td>B</td></tr></tbody></table>`,
+		},
+		{
+			"Strips Attributes And Escapes Text",
+			`<p style="color:red" onclick="x()">5 &lt; 6 &amp; more</p>`,
+			`<p>5 &lt; 6 &amp; more</p>`,
+		},
+		{"Empty String", "", ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if result := SanitizeHTML(tc.input); result != tc.expected {
+				t.Errorf("SanitizeHTML(%q) =\n%q\nwant\n%q", tc.input, result, tc.expected)
+			}
+		})
+	}
+}
+
+func TestHTMLText(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{`<p><strong>جنس:</strong> 98% پنبه<br>قابل شستشو</p>`, "جنس: 98% پنبه قابل شستشو"},
+		{`<h3>Comfort</h3><script>alert(1)</script><p>Soft &amp; light</p>`, "Comfort Soft & light"},
+		{"Kettle 1.7L", "Kettle 1.7L"},
+	}
+	for _, tc := range testCases {
+		if got := HTMLText(tc.input); got != tc.expected {
+			t.Errorf("HTMLText(%q) = %q, want %q", tc.input, got, tc.expected)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// weightRegex matches a number followed by a weight unit, e.g. "850 g",
+// "1,200 g" or "1.2 Kilograms".
+var weightRegex = regexp.MustCompile(`(?i)(\d+(?:,\d{3})*(?:[.,]\d+)?)\s*(kilograms?|kgs?|grams?|g|pounds?|lbs?|ounces?|oz)\b`)
+
+// dimensionsRegex matches "L x W x H unit", e.g. "32 x 20 x 12 cm" or "10.5 x 3 x 2 inches".
+var dimensionsRegex = regexp.MustCompile(`(?i)(\d+(?:,\d{3})*(?:[.,]\d+)?)\s*(?:cm|mm|in|inches)?\s*[x×*]\s*(\d+(?:,\d{3})*(?:[.,]\d+)?)\s*(?:cm|mm|in|inches)?\s*[x×*]\s*(\d+(?:,\d{3})*(?:[.,]\d+)?)\s*(centimetres|centimeters|cm|millimetres|millimeters|mm|metres|meters|m|inches|inch|in|")`)
+
+// gramsPerUnit converts the weight units Amazon and Noon use to grams.
+var gramsPerUnit = map[string]float64{
+	"kilogram": 1000, "kilograms": 1000, "kg": 1000, "kgs": 1000,
+	"gram": 1, "grams": 1, "g": 1,
+	"pound": 453.59237, "pounds": 453.59237, "lb": 453.59237, "lbs": 453.59237,
+	"ounce": 28.349523125, "ounces": 28.349523125, "oz": 28.349523125,
+}
+
+// cmPerUnit converts the length units Amazon and Noon use to centimetres.
+var cmPerUnit = map[string]float64{
+	"centimetres": 1, "centimeters": 1, "cm": 1,
+	"millimetres": 0.1, "millimeters": 0.1, "mm": 0.1,
+	"metres": 100, "meters": 100, "m": 100,
+	"inches": 2.54, "inch": 2.54, "in": 2.54, `"`: 2.54,
+}
+
+// ParseWeightGrams finds the first weight in a string and returns it in grams.
+// It returns 0 when no weight with a known unit is found.
+func ParseWeightGrams(s string) float64 {
+	matches := weightRegex.FindStringSubmatch(s)
+	if len(matches) < 3 {
+		return 0
+	}
+	value, err := parseUnitNumber(matches[1])
+	if err != nil {
+		return 0
+	}
+	return roundTo(value*gramsPerUnit[strings.ToLower(matches[2])], 2)
+}
+
+// ParseDimensionsCM finds the first "L x W x H unit" group in a string and
+// returns the three sides in centimetres, in the order they were listed.
+func ParseDimensionsCM(s string) (length, width, height float64, ok bool) {
+	matches := dimensionsRegex.FindStringSubmatch(s)
+	if len(matches) < 5 {
+		return 0, 0, 0, false
+	}
+	factor := cmPerUnit[strings.ToLower(matches[4])]
+	var sides [3]float64
+	for i := 0; i < 3; i++ {
+		value, err := parseUnitNumber(matches[i+1])
+		if err != nil {
+			return 0, 0, 0, false
+		}
+		sides[i] = roundTo(value*factor, 2)
+	}
+	return sides[0], sides[1], sides[2], true
+}
+
+// parseUnitNumber reads a number of a weight or dimension. A comma followed
+// by exactly three digits groups thousands ("1,200"); any other comma is a
+// decimal comma ("0,5").
+func parseUnitNumber(num string) (float64, error) {
+	groups := strings.Split(num, ",")
+	last := groups[len(groups)-1]
+	if len(groups) > 1 && len(last) != 3 && !strings.Contains(last, ".") {
+		num = strings.Join(groups[:len(groups)-1], "") + "." + last
+	} else {
+		num = strings.ReplaceAll(num, ",", "")
+	}
+	return strconv.ParseFloat(num, 64)
+}
+
+// roundTo rounds a value to the given number of decimal places.
+func roundTo(value float64, places int) float64 {
+	pow := math.Pow(10, float64(places))
+	return math.Round(value*pow) / pow
+}
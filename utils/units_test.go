@@ -0,0 +1,61 @@
+package utils
+
+import "testing"
+
+func TestParseWeightGrams(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected float64
+	}{
+		{"Grams", "850 g", 850},
+		{"Kilograms", "1.2 Kilograms", 1200},
+		{"Pounds", "1.5 Pounds", 680.39},
+		{"Ounces", "12 ounces", 340.19},
+		{"Comma Decimal", "0,5 kg", 500},
+		{"Comma Decimal Two Digits", "1,25 kg", 1250},
+		{"Thousands Separator", "1,200 g", 1200},
+		{"Thousands And Decimals", "1,200.5 g", 1200.5},
+		{"Four Digits After Comma", "1,2500 kg", 1250},
+		{"Grouped Thousands", "1,000,000 g", 1000000},
+		{"From Dimensions", "32 x 20 x 12 cm; 850 g", 850},
+		{"No Unit", "850", 0},
+		{"Empty String", "", 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if result := ParseWeightGrams(tc.input); result != tc.expected {
+				t.Errorf("ParseWeightGrams(%q) = %f; want %f", tc.input, result, tc.expected)
+			}
+		})
+	}
+}
+
+func TestParseDimensionsCM(t *testing.T) {
+	testCases := []struct {
+		name                  string
+		input                 string
+		length, width, height float64
+		ok                    bool
+	}{
+		{"Centimetres", "32 x 20 x 12 cm; 850 g", 32, 20, 12, true},
+		{"Inches", "10.5 x 3 x 2 inches; 1.2 Pounds", 26.67, 7.62, 5.08, true},
+		{"Millimetres", "150 x 70 x 8 mm", 15, 7, 0.8, true},
+		{"Thousands Separator", "1,200 x 300 x 20 mm", 120, 30, 2, true},
+		{"Comma Decimal", "10,5 x 3 x 2 cm", 10.5, 3, 2, true},
+		{"Per Side Units", "30 cm x 20 cm x 10 cm", 30, 20, 10, true},
+		{"No Unit", "32 x 20 x 12", 0, 0, 0, false},
+		{"Not Dimensions", "One Size", 0, 0, 0, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			l, w, h, ok := ParseDimensionsCM(tc.input)
+			if l != tc.length || w != tc.width || h != tc.height || ok != tc.ok {
+				t.Errorf("ParseDimensionsCM(%q) = %v, %v, %v, %v; want %v, %v, %v, %v",
+					tc.input, l, w, h, ok, tc.length, tc.width, tc.height, tc.ok)
+			}
+		})
+	}
+}